@@ -0,0 +1,576 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package elton
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/vicanso/hes"
+)
+
+const (
+	// BindRuleRequired the value should not be zero
+	BindRuleRequired = "required"
+	// BindRuleMin the min value(or length) of value
+	BindRuleMin = "min"
+	// BindRuleMax the max value(or length) of value
+	BindRuleMax = "max"
+	// BindRuleRegexp the value should match the regexp
+	BindRuleRegexp = "regexp"
+	// BindRuleEnum the value should be one of the enum
+	BindRuleEnum = "enum"
+	// BindRuleType the value can not be converted to the type of field
+	BindRuleType = "type"
+
+	formContentType = "application/x-www-form-urlencoded"
+
+	// bindBodyLimit the limit size of request body read by bind,
+	// it's the same as the default limit of body parser
+	bindBodyLimit = 50 * 1024
+)
+
+// ErrBindInvalid the request params are invalid,
+// the field violations are listed in Errs.
+var ErrBindInvalid = &hes.Error{
+	StatusCode: http.StatusBadRequest,
+	Message:    "request params are invalid",
+	Category:   ErrCategory,
+}
+
+// ErrBindBodyTooLarge the request body read by bind is too large
+var ErrBindBodyTooLarge = &hes.Error{
+	StatusCode: http.StatusRequestEntityTooLarge,
+	Message:    "request body is too large",
+	Category:   ErrCategory,
+}
+
+type (
+	bindRule struct {
+		name  string
+		num   float64
+		re    *regexp.Regexp
+		enums []string
+	}
+	bindField struct {
+		// index the index sequence for FieldByIndex
+		index []int
+		// name the name of field for violation
+		name   string
+		form   string
+		query  string
+		param  string
+		header string
+		rules  []*bindRule
+	}
+	bindStruct struct {
+		fields []*bindField
+		// err the error of validate tag
+		err error
+	}
+	bindViolations struct {
+		errs []*hes.Error
+	}
+)
+
+var bindStructCache sync.Map
+
+// bindTypeCache 缓存struct（包括嵌套的struct）validate tag的校验结果
+var bindTypeCache sync.Map
+
+type bindTypeResult struct {
+	err error
+}
+
+func (vs *bindViolations) add(field, rule, message string) {
+	vs.errs = append(vs.errs, &hes.Error{
+		StatusCode: http.StatusBadRequest,
+		Code:       rule,
+		Category:   ErrCategory,
+		Message:    message,
+		Extra: map[string]any{
+			"field": field,
+		},
+	})
+}
+
+// parseBindRules parses the rules of validate tag, the regexp rule
+// should be the last one as it may contain comma.
+func parseBindRules(tag string) ([]*bindRule, error) {
+	if tag == "" {
+		return nil, nil
+	}
+	var rules []*bindRule
+	for tag != "" {
+		var item string
+		tag = strings.TrimLeft(tag, " ")
+		if strings.HasPrefix(tag, BindRuleRegexp+"=") {
+			item, tag = tag, ""
+		} else {
+			item, tag, _ = strings.Cut(tag, ",")
+		}
+		name, arg, _ := strings.Cut(strings.TrimSpace(item), "=")
+		rule := &bindRule{
+			name: name,
+		}
+		switch name {
+		case BindRuleRequired:
+		case BindRuleMin, BindRuleMax:
+			num, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return nil, fmt.Errorf("elton: invalid %s rule %q", name, item)
+			}
+			rule.num = num
+		case BindRuleRegexp:
+			re, err := regexp.Compile(arg)
+			if err != nil {
+				return nil, fmt.Errorf("elton: invalid %s rule %q: %w", name, item, err)
+			}
+			rule.re = re
+		case BindRuleEnum:
+			rule.enums = strings.Split(arg, "|")
+		default:
+			return nil, fmt.Errorf("elton: unknown validate rule %q", item)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// getBindStruct returns the fields of struct, the error of
+// validate tag is also cached and returned.
+func getBindStruct(t reflect.Type) (*bindStruct, error) {
+	if v, ok := bindStructCache.Load(t); ok {
+		bs := v.(*bindStruct)
+		return bs, bs.err
+	}
+	bs := &bindStruct{}
+	bs.fields, bs.err = parseBindFields(t, nil)
+	bindStructCache.Store(t, bs)
+	return bs, bs.err
+}
+
+// checkBind checks the validate tags of struct and its nested structs,
+// the result is cached.
+func checkBind(t reflect.Type) error {
+	if v, ok := bindTypeCache.Load(t); ok {
+		return v.(*bindTypeResult).err
+	}
+	err := checkBindType(t, make(map[reflect.Type]bool))
+	bindTypeCache.Store(t, &bindTypeResult{
+		err: err,
+	})
+	return err
+}
+
+// checkBindType checks the validate tags of struct and its nested structs,
+// the nested structs are validated only if they are not empty, so the
+// invalid tag should be found before the request is handled.
+func checkBindType(t reflect.Type, checked map[reflect.Type]bool) error {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	// 与validateNested一致，实现TextUnmarshaler的struct不校验
+	if t.Kind() != reflect.Struct || checked[t] ||
+		reflect.PointerTo(t).Implements(reflect.TypeFor[encoding.TextUnmarshaler]()) {
+		return nil
+	}
+	checked[t] = true
+	bs, err := getBindStruct(t)
+	if err != nil {
+		return err
+	}
+	for _, field := range bs.fields {
+		if err := checkBindType(t.FieldByIndex(field.index).Type, checked); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseBindFields(t reflect.Type, index []int) ([]*bindField, error) {
+	var fields []*bindField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		jsonName, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		fieldIndex := append(slices.Clone(index), i)
+		// 匿名嵌入的struct，字段展开
+		if sf.Anonymous && jsonName == "" && sf.Type.Kind() == reflect.Struct {
+			embedded, err := parseBindFields(sf.Type, fieldIndex)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, embedded...)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		rules, err := parseBindRules(sf.Tag.Get("validate"))
+		if err != nil {
+			return nil, fmt.Errorf("%w of %s.%s", err, t, sf.Name)
+		}
+		f := &bindField{
+			index:  fieldIndex,
+			form:   sf.Tag.Get("form"),
+			query:  sf.Tag.Get("query"),
+			param:  sf.Tag.Get("param"),
+			header: sf.Tag.Get("header"),
+			rules:  rules,
+		}
+		f.name = jsonName
+		for _, name := range []string{f.form, f.query, f.param, f.header, sf.Name} {
+			if f.name != "" && f.name != "-" {
+				break
+			}
+			f.name = name
+		}
+		if f.form == "" && jsonName != "-" {
+			f.form = f.name
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// setBindValue sets the string values to the field
+func setBindValue(v reflect.Value, values []string) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setBindValue(v.Elem(), values)
+	}
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(values[0]))
+	}
+	if v.Kind() == reflect.Slice {
+		s := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setBindValue(s.Index(i), []string{value}); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+	value := values[0]
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// getFormValues returns the form values, the form is converted to json
+// by body parser, otherwise it is parsed from request.
+func getFormValues(c *Context) (map[string][]string, error) {
+	if len(c.RequestBody) == 0 {
+		if err := c.Request.ParseForm(); err != nil {
+			return nil, err
+		}
+		return c.Request.PostForm, nil
+	}
+	data := make(map[string]any)
+	if err := json.Unmarshal(c.RequestBody, &data); err != nil {
+		return nil, err
+	}
+	form := make(map[string][]string, len(data))
+	for key, value := range data {
+		switch value := value.(type) {
+		case []any:
+			for _, item := range value {
+				form[key] = append(form[key], fmt.Sprint(item))
+			}
+		default:
+			form[key] = []string{fmt.Sprint(value)}
+		}
+	}
+	return form, nil
+}
+
+// readBindBody reads the request body if it is not read by body parser,
+// the body is saved to RequestBody so it can be read again.
+func readBindBody(c *Context) error {
+	body := c.Request.Body
+	if len(c.RequestBody) != 0 || body == nil || body == http.NoBody {
+		return nil
+	}
+	data, err := io.ReadAll(io.LimitReader(body, bindBodyLimit+1))
+	if err != nil {
+		return ErrBindInvalid.WithCause(err)
+	}
+	if len(data) > bindBodyLimit {
+		return ErrBindBodyTooLarge
+	}
+	c.RequestBody = data
+	return nil
+}
+
+func bindFieldValues(c *Context, field *bindField, form map[string][]string) []string {
+	var values []string
+	if field.form != "" && field.form != "-" {
+		values = form[field.form]
+	}
+	if field.query != "" {
+		if v := c.getCacheQuery()[field.query]; len(v) != 0 {
+			values = v
+		}
+	}
+	if field.header != "" {
+		if v := c.Request.Header.Values(field.header); len(v) != 0 {
+			values = v
+		}
+	}
+	if field.param != "" {
+		if v := c.Param(field.param); v != "" {
+			values = []string{v}
+		}
+	}
+	return values
+}
+
+func bind(c *Context, v reflect.Value, vs *bindViolations) error {
+	bs, err := getBindStruct(v.Type())
+	if err != nil {
+		return err
+	}
+	var form map[string][]string
+	if len(c.RequestBody) != 0 || c.Request.Body != nil {
+		contentType := c.GetRequestHeader(HeaderContentType)
+		if strings.HasPrefix(contentType, formContentType) {
+			data, err := getFormValues(c)
+			if err != nil {
+				return ErrBindInvalid.WithCause(err)
+			}
+			form = data
+		} else {
+			if err := readBindBody(c); err != nil {
+				return err
+			}
+			if len(c.RequestBody) != 0 {
+				if err := json.Unmarshal(c.RequestBody, v.Addr().Interface()); err != nil {
+					var typeErr *json.UnmarshalTypeError
+					if !errors.As(err, &typeErr) {
+						return ErrBindInvalid.WithCause(err)
+					}
+					vs.add(typeErr.Field, BindRuleType, fmt.Sprintf("%s should be %s", typeErr.Field, typeErr.Type))
+				}
+			}
+		}
+	}
+	for _, field := range bs.fields {
+		values := bindFieldValues(c, field, form)
+		if len(values) == 0 {
+			continue
+		}
+		if err := setBindValue(v.FieldByIndex(field.index), values); err != nil {
+			vs.add(field.name, BindRuleType, fmt.Sprintf("%s is invalid: %s", field.name, err))
+		}
+	}
+	return nil
+}
+
+func isBindZero(v reflect.Value) bool {
+	if v.Kind() == reflect.Pointer {
+		return v.IsNil()
+	}
+	return v.IsZero()
+}
+
+func checkBindRule(rule *bindRule, name string, v reflect.Value) string {
+	switch rule.name {
+	case BindRuleMin, BindRuleMax:
+		var value float64
+		isLength := false
+		switch v.Kind() {
+		case reflect.String:
+			isLength = true
+			value = float64(utf8.RuneCountInString(v.String()))
+		case reflect.Slice, reflect.Array, reflect.Map:
+			isLength = true
+			value = float64(v.Len())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			value = float64(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			value = float64(v.Uint())
+		case reflect.Float32, reflect.Float64:
+			value = v.Float()
+		default:
+			return ""
+		}
+		desc := name
+		if isLength {
+			desc += "'s length"
+		}
+		num := strconv.FormatFloat(rule.num, 'f', -1, 64)
+		if rule.name == BindRuleMin && value < rule.num {
+			return desc + " should be at least " + num
+		}
+		if rule.name == BindRuleMax && value > rule.num {
+			return desc + " should be at most " + num
+		}
+	case BindRuleRegexp:
+		if v.Kind() == reflect.String && !rule.re.MatchString(v.String()) {
+			return fmt.Sprintf("%s should match %s", name, rule.re.String())
+		}
+	case BindRuleEnum:
+		if !slices.Contains(rule.enums, fmt.Sprint(v.Interface())) {
+			return fmt.Sprintf("%s should be one of [%s]", name, strings.Join(rule.enums, ", "))
+		}
+	}
+	return ""
+}
+
+// validate validates the struct value by the rules of validate tag,
+// the field which is zero and not required is not validated.
+// It returns the error of validate tag.
+func validate(v reflect.Value, prefix string, vs *bindViolations) error {
+	bs, err := getBindStruct(v.Type())
+	if err != nil {
+		return err
+	}
+	for _, field := range bs.fields {
+		fv := v.FieldByIndex(field.index)
+		name := prefix + field.name
+		if isBindZero(fv) {
+			if slices.ContainsFunc(field.rules, func(rule *bindRule) bool {
+				return rule.name == BindRuleRequired
+			}) {
+				vs.add(name, BindRuleRequired, name+" is required")
+			}
+			continue
+		}
+		for fv.Kind() == reflect.Pointer {
+			fv = fv.Elem()
+		}
+		for _, rule := range field.rules {
+			if message := checkBindRule(rule, name, fv); message != "" {
+				vs.add(name, rule.name, message)
+			}
+		}
+		if err := validateNested(fv, name, vs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateNested validates the nested struct, slice(array) of struct
+func validateNested(v reflect.Value, name string, vs *bindViolations) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		if _, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return nil
+		}
+		return validate(v, name+".", vs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			item := v.Index(i)
+			for item.Kind() == reflect.Pointer && !item.IsNil() {
+				item = item.Elem()
+			}
+			if item.Kind() == reflect.Struct {
+				if err := validateNested(item, name+"["+strconv.Itoa(i)+"]", vs); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Bind decodes the request params to a struct of T and validates it.
+// The params are decoded as below(the latter overrides the former):
+//   - request body: json is unmarshaled by json tag, form is decoded by form tag(default to json name).
+//     The body is read from request(limit 50KB) if it is not read by body parser.
+//   - query: by query tag
+//   - header: by header tag
+//   - route params: by param tag
+//
+// The rules of validate tag are required, min, max(value of number,
+// length of string/slice/map), enum(split by "|") and regexp(should be the last one),
+// e.g. `validate:"required,min=1,max=20,regexp=^[a-z]+$"`.
+// The nested structs are also validated. It returns a 400 hes.Error
+// listing every field violation in Errs if validate fail, and returns
+// the error of validate tag if it is invalid.
+func Bind[T any](c *Context) (T, error) {
+	var result T
+	v := reflect.ValueOf(&result).Elem()
+	if v.Kind() != reflect.Struct {
+		return result, fmt.Errorf("elton: bind type should be struct, not %s", v.Type())
+	}
+	if err := checkBind(v.Type()); err != nil {
+		return result, err
+	}
+	vs := &bindViolations{}
+	if err := bind(c, v, vs); err != nil {
+		return result, err
+	}
+	if err := validate(v, "", vs); err != nil {
+		return result, err
+	}
+	if len(vs.errs) != 0 {
+		he := ErrBindInvalid.Clone()
+		he.Errs = vs.errs
+		return result, he
+	}
+	return result, nil
+}
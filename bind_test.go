@@ -0,0 +1,234 @@
+// MIT License
+
+// Copyright (c) 2020 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package elton
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/hes"
+)
+
+type bindAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type bindPage struct {
+	Limit int `query:"limit" validate:"max=100"`
+}
+
+type bindParams struct {
+	bindPage
+	ID        int           `param:"id" validate:"min=1"`
+	Account   string        `json:"account" validate:"required,min=2,max=10,regexp=^[a-z,]+$"`
+	Type      string        `json:"type" validate:"enum=vip|normal"`
+	Tags      []string      `json:"tags" query:"tag" validate:"max=2"`
+	Age       *int          `json:"age" validate:"required"`
+	Token     string        `json:"-" header:"X-Token"`
+	Address   *bindAddress  `json:"address"`
+	Addresses []bindAddress `json:"addresses"`
+	CreatedAt time.Time     `json:"createdAt" query:"createdAt"`
+}
+
+func newBindContext(method, url, contentType, body string) *Context {
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set(HeaderContentType, contentType)
+	}
+	c := NewContext(httptest.NewRecorder(), req)
+	if contentType == "application/json" {
+		c.RequestBody = []byte(body)
+	}
+	return c
+}
+
+func TestParseBindRules(t *testing.T) {
+	assert := assert.New(t)
+	rules, err := parseBindRules("required,min=1,enum=a|b,regexp=^[a,b]+$")
+	assert.Nil(err)
+	assert.Equal(4, len(rules))
+	assert.Equal(BindRuleRequired, rules[0].name)
+	assert.Equal(float64(1), rules[1].num)
+	assert.Equal([]string{"a", "b"}, rules[2].enums)
+	assert.Equal("^[a,b]+$", rules[3].re.String())
+
+	// regexp前有空格
+	rules, err = parseBindRules("min=1, regexp=^a,b$")
+	assert.Nil(err)
+	assert.Equal(2, len(rules))
+	assert.Equal("^a,b$", rules[1].re.String())
+
+	for _, tag := range []string{
+		"abc",
+		"min=a",
+		"regexp=[a",
+	} {
+		_, err = parseBindRules(tag)
+		assert.NotNil(err, tag)
+	}
+}
+
+type bindInvalidTagItem struct {
+	Name string `json:"name" validate:"max=a"`
+}
+
+type bindInvalidTagParams struct {
+	Account string               `json:"account" validate:"required"`
+	Items   []bindInvalidTagItem `json:"items"`
+}
+
+type bindTreeNode struct {
+	Name     string         `json:"name" validate:"required"`
+	Children []bindTreeNode `json:"children"`
+}
+
+func TestBindInvalidTag(t *testing.T) {
+	assert := assert.New(t)
+
+	// validate tag出错时返回error而非panic
+	for range 2 {
+		c := newBindContext("POST", "/", "application/json", `{"account":"tree"}`)
+		_, err := Bind[bindInvalidTagParams](c)
+		assert.Equal(`elton: invalid max rule "max=a" of elton.bindInvalidTagItem.Name`, err.Error())
+	}
+
+	// 递归的struct
+	c := newBindContext("POST", "/", "application/json", `{"name":"a","children":[{"name":"b"}]}`)
+	node, err := Bind[bindTreeNode](c)
+	assert.Nil(err)
+	assert.Equal("b", node.Children[0].Name)
+
+	// Typed创建时即校验
+	assert.PanicsWithError(`elton: invalid max rule "max=a" of elton.bindInvalidTagItem.Name`, func() {
+		Typed(func(c *Context, req bindInvalidTagParams) (string, error) {
+			return "", nil
+		})
+	})
+	assert.NotPanics(func() {
+		Typed(func(c *Context, req bindParams) (string, error) {
+			return "", nil
+		})
+	})
+}
+
+func TestBind(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newBindContext("POST", "/users/1?limit=10&tag=a&tag=b&createdAt=2026-01-02T15:04:05Z", "application/json",
+		`{"account":"tree","type":"vip","tags":["c"],"age":18,"address":{"city":"GZ"},"addresses":[{"city":"SZ"}]}`)
+	c.Request.Header.Set("X-Token", "token")
+	c.Params.Add("id", "1")
+	params, err := Bind[bindParams](c)
+	assert.Nil(err)
+	assert.Equal(1, params.ID)
+	assert.Equal(10, params.Limit)
+	assert.Equal("tree", params.Account)
+	assert.Equal([]string{"a", "b"}, params.Tags)
+	assert.Equal(18, *params.Age)
+	assert.Equal("token", params.Token)
+	assert.Equal("GZ", params.Address.City)
+	assert.Equal("SZ", params.Addresses[0].City)
+	assert.Equal(2026, params.CreatedAt.Year())
+
+	// form body
+	c = newBindContext("POST", "/", "application/x-www-form-urlencoded", "account=tree&age=20&tags=a&tags=b")
+	params, err = Bind[bindParams](c)
+	assert.Nil(err)
+	assert.Equal("tree", params.Account)
+	assert.Equal(20, *params.Age)
+	assert.Equal([]string{"a", "b"}, params.Tags)
+
+	// form body converted by body parser
+	c = newBindContext("POST", "/", "application/x-www-form-urlencoded", "")
+	c.RequestBody = []byte(`{"account":"tree","age":"20"}`)
+	params, err = Bind[bindParams](c)
+	assert.Nil(err)
+	assert.Equal(20, *params.Age)
+
+	_, err = Bind[string](c)
+	assert.NotNil(err)
+}
+
+func TestBindWithoutBodyParser(t *testing.T) {
+	assert := assert.New(t)
+
+	// 未使用body parser时从请求中读取
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"account":"tree","age":18}`))
+	req.Header.Set(HeaderContentType, "application/json")
+	c := NewContext(httptest.NewRecorder(), req)
+	params, err := Bind[bindParams](c)
+	assert.Nil(err)
+	assert.Equal("tree", params.Account)
+	assert.Equal(18, *params.Age)
+	// 读取的数据保存至RequestBody
+	assert.Equal(`{"account":"tree","age":18}`, string(c.RequestBody))
+
+	// 数据过大
+	req = httptest.NewRequest("POST", "/", strings.NewReader(strings.Repeat("a", bindBodyLimit+1)))
+	req.Header.Set(HeaderContentType, "application/json")
+	c = NewContext(httptest.NewRecorder(), req)
+	_, err = Bind[bindParams](c)
+	assert.Equal(ErrBindBodyTooLarge, err)
+}
+
+func TestBindValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newBindContext("POST", "/users?limit=1000&tag=a&tag=b&tag=c", "application/json",
+		`{"account":"T","type":"abc","address":{},"addresses":[{"city":"SZ"},{}]}`)
+	c.Params.Add("id", "0")
+	_, err := Bind[bindParams](c)
+	he, ok := hes.As(err)
+	assert.True(ok)
+	assert.Equal(400, he.StatusCode)
+	result := make(map[string]string)
+	for _, item := range he.Errs {
+		result[item.Extra["field"].(string)] = item.Code + ": " + item.Message
+	}
+	assert.Equal(map[string]string{
+		"limit":             "max: limit should be at most 100",
+		"account":           "regexp: account should match ^[a-z,]+$",
+		"address.city":      "required: address.city is required",
+		"addresses[1].city": "required: addresses[1].city is required",
+		"age":               "required: age is required",
+		"tags":              "max: tags's length should be at most 2",
+		"type":              "enum: type should be one of [vip, normal]",
+	}, result)
+
+	// type error
+	c = newBindContext("POST", "/?limit=a", "application/json", `{"account":1,"age":1}`)
+	_, err = Bind[bindParams](c)
+	he, _ = hes.As(err)
+	assert.Equal(3, len(he.Errs))
+	assert.Equal(BindRuleType, he.Errs[0].Code)
+	assert.Equal("account", he.Errs[0].Extra["field"])
+	assert.Equal("limit", he.Errs[1].Extra["field"])
+
+	// invalid json
+	c = newBindContext("POST", "/", "application/json", `{`)
+	_, err = Bind[bindParams](c)
+	assert.Equal(ErrBindInvalid.Message, err.(*hes.Error).Message)
+}
@@ -96,6 +96,10 @@ const (
 	HeaderServerTiming = "Server-Timing"
 	// HeaderTransferEncoding transfer encoding
 	HeaderTransferEncoding = "Transfer-Encoding"
+	// HeaderConnection connection
+	HeaderConnection = "Connection"
+	// HeaderUpgrade upgrade
+	HeaderUpgrade = "Upgrade"
 
 	// MinRedirectCode min redirect code
 	MinRedirectCode = 300
@@ -108,6 +112,8 @@ const (
 	MIMEApplicationJSON = "application/json; charset=utf-8"
 	// MIMEBinary binary data
 	MIMEBinary = "application/octet-stream"
+	// MIMETextEventStream server-sent events
+	MIMETextEventStream = "text/event-stream"
 
 	// Gzip gzip compress
 	Gzip = "gzip"
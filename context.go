@@ -26,6 +26,7 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"maps"
 	"mime"
 	"mime/multipart"
 	"net"
@@ -33,6 +34,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync/atomic"
@@ -93,6 +95,10 @@ type (
 		handlerNames []string
 		// activeTrace is non-nil only for the current traced request.
 		activeTrace *Trace
+		// sse is non-nil only after SSE is called, closed when the chain returns.
+		sse *SSEWriter
+		// matchedRoute the route of request, it is used to get name and metadata.
+		matchedRoute *Route
 	}
 )
 
@@ -159,8 +165,10 @@ func (c *Context) chainNext() error {
 		Middleware: true,
 	}
 	c.activeTrace.Add(info)
+	span := c.activeTrace.startMiddlewareSpan(name, startedAt)
 	err := fn(c)
 	info.Duration = time.Since(startedAt)
+	c.activeTrace.endMiddlewareSpan(span, info.Duration, err)
 	return err
 }
 
@@ -179,6 +187,8 @@ func (c *Context) Reset() {
 		c.handlerNames = c.handlerNames[:0]
 	}
 	c.activeTrace = nil
+	c.sse = nil
+	c.matchedRoute = nil
 	c.Params.Reset()
 	c.StatusCode = 0
 	c.Body = nil
@@ -703,6 +713,30 @@ func (c *Context) Pass(another *Elton) {
 	another.ServeHTTP(c.Response, c.Request)
 }
 
+// Fork returns a new context which continues the rest handlers of chain
+// after the current one with the request and response, the upstream
+// middlewares are not run again. The params and store of context are
+// copied, the trace is not enabled. It returns nil if the context is
+// not created by the router of elton(without handler chain).
+func (c *Context) Fork(resp http.ResponseWriter, req *http.Request) *Context {
+	if len(c.handlers) == 0 {
+		return nil
+	}
+	fc := NewContext(resp, req)
+	fc.ID = c.ID
+	fc.Route = c.Route
+	fc.elton = c.elton
+	fc.handlers = c.handlers
+	fc.handlerIndex = c.handlerIndex
+	fc.matchedRoute = c.matchedRoute
+	fc.Params.Keys = slices.Clone(c.Params.Keys)
+	fc.Params.Values = slices.Clone(c.Params.Values)
+	if c.m != nil {
+		fc.m = maps.Clone(c.m)
+	}
+	return fc
+}
+
 // Pipe the reader to the response
 func (c *Context) Pipe(r io.Reader) (int64, error) {
 	c.Committed = true
@@ -743,6 +777,12 @@ func (c *Context) Trace() *Trace {
 	return TraceFromContext(c.Context())
 }
 
+// Span returns the current span of distributed tracing,
+// it returns nil if Elton.Tracer is not set.
+func (c *Context) Span() *Span {
+	return SpanFromContext(c.Context())
+}
+
 // NewTrace returns a new trace and set it to context value
 func (c *Context) NewTrace() *Trace {
 	trace := NewTrace()
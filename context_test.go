@@ -822,6 +822,43 @@ func TestContextPass(t *testing.T) {
 	assert.Equal("new data", resp.Body.String())
 }
 
+func TestContextFork(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(NewContext(nil, nil).Fork(nil, nil))
+
+	e := New()
+	globalCount := 0
+	e.Use(func(c *Context) error {
+		globalCount++
+		c.Set("account", "tree")
+		return c.Next()
+	})
+	var fc *Context
+	e.Use(func(c *Context) error {
+		fc = c.Fork(httptest.NewRecorder(), c.Request)
+		return c.Next()
+	})
+	handlerCount := 0
+	e.GET("/users/{id}", func(c *Context) error {
+		handlerCount++
+		account, _ := c.Get("account")
+		c.BodyBuffer = bytes.NewBufferString(c.Param("id") + ":" + account.(string))
+		return nil
+	})
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/users/1", nil))
+	assert.Equal("1:tree", resp.Body.String())
+
+	// fork的context仅执行后续的handler
+	assert.NotNil(fc)
+	assert.Nil(fc.Next())
+	assert.Equal("1:tree", fc.BodyBuffer.String())
+	assert.Equal("/users/{id}", fc.Route)
+	assert.Equal(1, globalCount)
+	assert.Equal(2, handlerCount)
+}
+
 func TestContextServerTiming(t *testing.T) {
 	assert := assert.New(t)
 	traceInfos := make(TraceInfos, 0)
@@ -78,15 +78,22 @@ type (
 		GenerateID GenerateID
 		// EnableTrace enable trace
 		EnableTrace bool
+		// Tracer the tracer of distributed tracing, a root span is created
+		// for each request and the middlewares are traced as child spans
+		Tracer *Tracer
 		// SignedKeys signed keys
 		SignedKeys SignedKeysGenerator
 
 		// status of elton
 		status atomic.Int32
-		// mux is the standard library router (Go 1.22+ patterns / wildcards)
-		mux *http.ServeMux
-		// routers all router infos
-		routers []RouterInfo
+		// table the live route table, it can be swapped atomically
+		table atomic.Pointer[RouteTable]
+		// tableMu serializes the changes of live route table
+		tableMu sync.Mutex
+		// hosts the virtual hosts with params or wildcard
+		hosts []*virtualHost
+		// exactHosts the virtual hosts of exact host name
+		exactHosts map[string]*virtualHost
 		// middlewares middleware function
 		middlewares []Handler
 		// preMiddlewares pre middleware function
@@ -110,9 +117,15 @@ type (
 
 	// Router router
 	Router struct {
-		Method     string    `json:"method,omitempty"`
-		Path       string    `json:"path,omitempty"`
-		HandleList []Handler `json:"-"`
+		Method     string         `json:"method,omitempty"`
+		Path       string         `json:"path,omitempty"`
+		HandleList []Handler      `json:"-"`
+		Doc        *RouteDoc      `json:"-"`
+		Name       string         `json:"name,omitempty"`
+		Meta       map[string]any `json:"-"`
+		// requestType and responseType the types of typed handler
+		requestType  reflect.Type
+		responseType reflect.Type
 	}
 	// Group group router
 	Group struct {
@@ -120,6 +133,8 @@ type (
 		HandlerList []Handler
 		routers     []*Router
 		children    []*Group
+		// lastRouterIndex the index of first router added by the last handle
+		lastRouterIndex int
 	}
 	// ErrorHandler error handle function
 	ErrorHandler func(*Context, error)
@@ -160,9 +175,9 @@ func New() *Elton {
 // NewWithoutServer returns a new elton instance without http server
 func NewWithoutServer() *Elton {
 	e := &Elton{
-		mux:           http.NewServeMux(),
 		functionInfos: make(map[uintptr]string),
 	}
+	e.table.Store(e.NewRouteTable())
 	e.ctxPool.New = func() any {
 		c := &Context{
 			elton:        e,
@@ -355,17 +370,38 @@ func (e *Elton) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
 		}
 		return
 	}
+	e.serve(resp, req)
+}
+
+// serve handles the request without status check, it is shared
+// by the virtual hosts of elton.
+func (e *Elton) serve(resp http.ResponseWriter, req *http.Request) {
+	e.serveHost(resp, req, nil)
+}
+
+// serveHost handles the request, the hostParamNames are the param names
+// of the host pattern which is matched by the request.
+func (e *Elton) serveHost(resp http.ResponseWriter, req *http.Request, hostParamNames []string) {
 	for _, preHandler := range e.preMiddlewares {
 		preHandler(req)
 	}
+	// 匹配虚拟主机，未匹配则由当前实例处理（默认主机）
+	if len(e.hosts) != 0 {
+		if vh := e.matchHost(req); vh != nil {
+			vh.elton.serveHost(resp, req, vh.paramNames)
+			return
+		}
+	}
 
 	// Single ServeMux match: PathValue is filled here. edgeWriter lets elton routes
 	// mark themselves handled; mux-internal 404/405 are replaced by elton handlers,
 	// while redirects (and other non-route mux responses) are flushed as-is.
 	// edgeWriter is pooled; handlers must detach it from Context before return
 	// (see detachEdgeFromContext in the route HandleFunc).
+	// 每次请求读取当前的路由表，替换路由表不影响处理中的请求
 	ew := acquireEdgeWriter(resp)
-	e.mux.ServeHTTP(ew, req)
+	ew.hostParamNames = hostParamNames
+	e.table.Load().mux.ServeHTTP(ew, req)
 	if !ew.handled {
 		switch ew.status {
 		case http.StatusMethodNotAllowed:
@@ -385,7 +421,7 @@ func (e *Elton) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
 
 // Routers returns routers of elton
 func (e *Elton) Routers() []RouterInfo {
-	return slices.Clone(e.routers)
+	return e.RouteTable().Routers()
 }
 
 // Handle adds http handle function.
@@ -398,42 +434,64 @@ func (e *Elton) Routers() []RouterInfo {
 //
 // path 使用 net/http ServeMux 模式（Go 1.22+）：{name}、{name...}、{$}；
 // 仍兼容段首 :name 与末尾 /*（分别转为 {name}、{path...}）。
-// 注册 pattern 为 "METHOD path"；冲突的 pattern 会 panic（标准库行为）。
+// 注册 pattern 为 "METHOD path"；冲突的 pattern 会 panic（标准库行为），
+// 如需以 error 返回或运行时替换路由，请使用 RouteTable。
 //
 // 注意：应在 Listen 前完成 Use + 路由注册。某路由注册之后再 Use 的中间件
 // 不会作用于该路由（链在 Handle 时已快照）。
-func (e *Elton) Handle(method, path string, handlerList ...Handler) *Elton {
+//
+// 返回的 *Route 可设置路由名称、元数据以及路由级中间件，如：
+//
+//	e.GET("/users/{id}", getUser).Name("user").Meta("timeout", time.Second)
+func (e *Elton) Handle(method, path string, handlerList ...Handler) *Route {
+	e.tableMu.Lock()
+	defer e.tableMu.Unlock()
+	route, err := e.RouteTable().Handle(method, path, handlerList...)
+	if err != nil {
+		panic(err)
+	}
+	return route
+}
+
+// newRoute returns a new route, the global middlewares are snapshotted.
+func (e *Elton) newRoute(path string, handlerList []Handler) *Route {
 	for _, fn := range handlerList {
 		e.ensureFunctionName(fn)
 	}
-
 	path = normalizeRoutePath(path)
-	paramNames := extractParamNames(path)
-
-	// Snapshot: later e.Use does not change already-registered routes.
-	handlers := make([]Handler, 0, len(e.middlewares)+len(handlerList))
-	handlers = append(handlers, e.middlewares...)
-	handlers = append(handlers, handlerList...)
-
-	e.routers = append(e.routers, RouterInfo{
-		Method: method,
-		Route:  path,
-	})
-
-	pattern := path
-	if method != "" {
-		pattern = method + " " + path
-	}
-
-	e.mux.HandleFunc(pattern, func(resp http.ResponseWriter, req *http.Request) {
+	route := &Route{
+		e:          e,
+		path:       path,
+		paramNames: extractParamNames(path),
+		// Snapshot: later e.Use does not change already-registered routes.
+		globals:     slices.Clone(e.middlewares),
+		handlerList: handlerList,
+	}
+	route.buildHandlers()
+	route.handler = e.newRouteHandler(route)
+	return route
+}
+
+// newRouteHandler returns the http handler of route, it is shared
+// by all methods of route.
+func (e *Elton) newRouteHandler(route *Route) http.HandlerFunc {
+	path := route.path
+	paramNames := route.paramNames
+	return func(resp http.ResponseWriter, req *http.Request) {
+		// 禁用的路由不标记为已处理，由ServeHTTP按not found处理
+		if route.disabled.Load() {
+			return
+		}
 		// Mark before any write so application 404/405 is not treated as mux miss.
 		markRouteHandled(resp)
 
+		handlers := route.handlers
 		c := e.ctxPool.Get().(*Context)
 		c.Reset()
 		c.Request = req
 		c.Response = resp
 		c.handlers = handlers
+		c.matchedRoute = route
 		c.handlerIndex = -1
 		// Detach edgeWriter before this func returns so ServeHTTP can pool it safely.
 		// Also returns Context to the pool when reuse is allowed.
@@ -448,7 +506,12 @@ func (e *Elton) Handle(method, path string, handlerList ...Handler) *Elton {
 			c.ID = e.GenerateID()
 		}
 		// Fill Params in one pass with pre-sized slices (ToMap / Values[0] / tests).
-		if n := len(paramNames); n > 0 {
+		// The host params of virtual host are appended after path params.
+		var hostParamNames []string
+		if ew, ok := resp.(*edgeWriter); ok {
+			hostParamNames = ew.hostParamNames
+		}
+		if n := len(paramNames) + len(hostParamNames); n > 0 {
 			p := c.Params
 			if cap(p.Keys) < n {
 				p.Keys = make([]string, n)
@@ -461,6 +524,11 @@ func (e *Elton) Handle(method, path string, handlerList ...Handler) *Elton {
 				p.Keys[i] = name
 				p.Values[i] = req.PathValue(name)
 			}
+			offset := len(paramNames)
+			for i, name := range hostParamNames {
+				p.Keys[offset+i] = name
+				p.Values[offset+i] = req.PathValue(name)
+			}
 		}
 
 		if e.beforeListeners != nil {
@@ -471,7 +539,8 @@ func (e *Elton) Handle(method, path string, handlerList ...Handler) *Elton {
 		}
 		c.Route = path
 
-		if e.EnableTrace {
+		tracer := e.Tracer
+		if e.EnableTrace || tracer != nil {
 			maxNext := len(handlers)
 			trace := &Trace{
 				Infos: make(TraceInfos, 0, maxNext),
@@ -490,8 +559,20 @@ func (e *Elton) Handle(method, path string, handlerList ...Handler) *Elton {
 			e.functionInfosMutex.RUnlock()
 		}
 
-		err := c.Next()
-		if c.activeTrace != nil {
+		var err error
+		if tracer != nil {
+			trace := c.activeTrace
+			tracer.startRequest(c, trace)
+			defer func() {
+				tracer.endRequest(c, trace, err)
+			}()
+		}
+		err = c.Next()
+		// handler返回后不可再写入，关闭sse（停止keep-alive）
+		if c.sse != nil {
+			c.sse.Close()
+		}
+		if e.EnableTrace {
 			c.activeTrace.Calculate()
 			e.EmitTrace(c, c.activeTrace.Infos)
 		}
@@ -531,61 +612,63 @@ func (e *Elton) Handle(method, path string, handlerList ...Handler) *Elton {
 				e.EmitError(c, pipeErr)
 			}
 		}
-	})
-	return e
+	}
 }
 
 // GET adds http get method handle
-func (e *Elton) GET(path string, handlerList ...Handler) *Elton {
+func (e *Elton) GET(path string, handlerList ...Handler) *Route {
 	return e.Handle(http.MethodGet, path, handlerList...)
 }
 
 // POST adds http post method handle
-func (e *Elton) POST(path string, handlerList ...Handler) *Elton {
+func (e *Elton) POST(path string, handlerList ...Handler) *Route {
 	return e.Handle(http.MethodPost, path, handlerList...)
 }
 
 // PUT adds http put method handle
-func (e *Elton) PUT(path string, handlerList ...Handler) *Elton {
+func (e *Elton) PUT(path string, handlerList ...Handler) *Route {
 	return e.Handle(http.MethodPut, path, handlerList...)
 }
 
 // PATCH adds http patch method handle
-func (e *Elton) PATCH(path string, handlerList ...Handler) *Elton {
+func (e *Elton) PATCH(path string, handlerList ...Handler) *Route {
 	return e.Handle(http.MethodPatch, path, handlerList...)
 }
 
 // DELETE adds http delete method handle
-func (e *Elton) DELETE(path string, handlerList ...Handler) *Elton {
+func (e *Elton) DELETE(path string, handlerList ...Handler) *Route {
 	return e.Handle(http.MethodDelete, path, handlerList...)
 }
 
 // HEAD adds http head method handle
-func (e *Elton) HEAD(path string, handlerList ...Handler) *Elton {
+func (e *Elton) HEAD(path string, handlerList ...Handler) *Route {
 	return e.Handle(http.MethodHead, path, handlerList...)
 }
 
 // OPTIONS adds http options method handle
-func (e *Elton) OPTIONS(path string, handlerList ...Handler) *Elton {
+func (e *Elton) OPTIONS(path string, handlerList ...Handler) *Route {
 	return e.Handle(http.MethodOptions, path, handlerList...)
 }
 
 // TRACE adds http trace method handle
-func (e *Elton) TRACE(path string, handlerList ...Handler) *Elton {
+func (e *Elton) TRACE(path string, handlerList ...Handler) *Route {
 	return e.Handle(http.MethodTrace, path, handlerList...)
 }
 
 // ALL adds http all method handle
-func (e *Elton) ALL(path string, handlerList ...Handler) *Elton {
+func (e *Elton) ALL(path string, handlerList ...Handler) *Route {
 	return e.Multi(methods, path, handlerList...)
 }
 
-// Multi adds multi method
-func (e *Elton) Multi(methods []string, path string, handlerList ...Handler) *Elton {
-	for _, method := range methods {
-		e.Handle(method, path, handlerList...)
+// Multi adds multi method, the methods share the same route
+func (e *Elton) Multi(methods []string, path string, handlerList ...Handler) *Route {
+	e.tableMu.Lock()
+	defer e.tableMu.Unlock()
+	route, err := e.RouteTable().Multi(methods, path, handlerList...)
+	if err != nil {
+		panic(err)
 	}
-	return e
+	return route
 }
 
 // Use adds middleware handler function to elton's middleware list
@@ -734,11 +817,10 @@ func (e *Elton) emitBefore(c *Context) {
 
 // AddGroup adds the group and its sub groups to elton
 func (e *Elton) AddGroup(groups ...*Group) *Elton {
-	for _, g := range groups {
-		for _, r := range g.routers {
-			e.Handle(r.Method, r.Path, r.HandleList...)
-		}
-		e.AddGroup(g.children...)
+	e.tableMu.Lock()
+	defer e.tableMu.Unlock()
+	if err := e.RouteTable().AddGroup(groups...); err != nil {
+		panic(err)
 	}
 	return e
 }
@@ -765,6 +847,7 @@ func (g *Group) NewGroup(path string, handlerList ...Handler) *Group {
 }
 
 func (g *Group) handle(method, path string, handlerList ...Handler) *Group {
+	g.lastRouterIndex = len(g.routers)
 	g.routers = append(g.routers, &Router{
 		Method:     method,
 		Path:       g.Path + path,
@@ -820,9 +903,32 @@ func (g *Group) ALL(path string, handlerList ...Handler) *Group {
 
 // Multi adds multi http methods handler to group
 func (g *Group) Multi(methods []string, path string, handlerList ...Handler) *Group {
+	index := len(g.routers)
 	for _, method := range methods {
 		g.handle(method, path, handlerList...)
 	}
+	g.lastRouterIndex = index
+	return g
+}
+
+// Name sets the name of the routes added by the last
+// handle(GET, POST, Multi...) call of group.
+func (g *Group) Name(name string) *Group {
+	for _, r := range g.routers[g.lastRouterIndex:] {
+		r.Name = name
+	}
+	return g
+}
+
+// Meta sets the metadata of the routes added by the last
+// handle(GET, POST, Multi...) call of group.
+func (g *Group) Meta(key string, value any) *Group {
+	for _, r := range g.routers[g.lastRouterIndex:] {
+		if r.Meta == nil {
+			r.Meta = make(map[string]any)
+		}
+		r.Meta[key] = value
+	}
 	return g
 }
 
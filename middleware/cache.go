@@ -27,11 +27,14 @@ import (
 	"context"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/textproto"
 	"regexp"
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/vicanso/elton/v2"
@@ -53,6 +56,8 @@ type CacheConfig struct {
 	Marshal func(any) ([]byte, error)
 	// IgnoreHeaders ignore the headers for cache
 	IgnoreHeaders []string
+	// Counter counts the stats of cache, it's nil by default
+	Counter *CacheCounter
 }
 
 type CacheStatus uint8
@@ -64,6 +69,9 @@ const (
 	StatusHitForPass
 	// StatusHit hit cache status
 	StatusHit
+	// StatusVary the response varies by request headers,
+	// the cache is saved with the secondary key
+	StatusVary
 )
 
 type CacheStore interface {
@@ -87,11 +95,16 @@ var defaultIgnoreHeaders = []string{
 }
 
 var (
-	noCacheReg = regexp.MustCompile(`no-cache|no-store|private`)
-	sMaxAgeReg = regexp.MustCompile(`s-maxage=(\d+)`)
-	maxAgeReg  = regexp.MustCompile(`max-age=(\d+)`)
+	noCacheReg              = regexp.MustCompile(`no-cache|no-store|private`)
+	sMaxAgeReg              = regexp.MustCompile(`s-maxage=(\d+)`)
+	maxAgeReg               = regexp.MustCompile(`max-age=(\d+)`)
+	staleWhileRevalidateReg = regexp.MustCompile(`stale-while-revalidate=(\d+)`)
+	staleIfErrorReg         = regexp.MustCompile(`stale-if-error=(\d+)`)
 )
 
+// varyKeySeparator 主key与vary请求头生成的二级key的分隔符
+const varyKeySeparator = "|"
+
 // IsPassCacheMethod is the method pass cache
 func IsPassCacheMethod(reqMethod string) bool {
 	if reqMethod != http.MethodGet && reqMethod != http.MethodHead {
@@ -151,14 +164,98 @@ func GetCacheMaxAge(header http.Header) int {
 	}
 
 	// 如果有设置了 age 字段，则最大缓存时长减少
-	if age := header.Get(HeaderAge); age != "" {
-		v, _ := strconv.Atoi(age)
-		maxAge -= v
-	}
+	maxAge -= getCacheAge(header)
 
 	return maxAge
 }
 
+// getCacheAge returns the value of Age header, 0 if it's invalid
+func getCacheAge(header http.Header) int {
+	age := header.Get(HeaderAge)
+	if age == "" {
+		return 0
+	}
+	v, _ := strconv.Atoi(age)
+	return max(v, 0)
+}
+
+// GetCacheStaleAge returns the stale-while-revalidate and
+// stale-if-error seconds of cache-control
+func GetCacheStaleAge(header http.Header) (staleWhileRevalidate int, staleIfError int) {
+	cc := strings.Join(header.Values(elton.HeaderCacheControl), ",")
+	if cc == "" {
+		return
+	}
+	if result := staleWhileRevalidateReg.FindStringSubmatch(cc); len(result) == 2 {
+		staleWhileRevalidate, _ = strconv.Atoi(result[1])
+	}
+	if result := staleIfErrorReg.FindStringSubmatch(cc); len(result) == 2 {
+		staleIfError, _ = strconv.Atoi(result[1])
+	}
+	return
+}
+
+// parseVary returns the canonical header names of Vary,
+// the result contains "*" if the response varies by anything
+func parseVary(header http.Header) []string {
+	var vary []string
+	for _, value := range header.Values(headerVary) {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if name != "*" {
+				name = textproto.CanonicalMIMEHeaderKey(name)
+			}
+			if !slices.Contains(vary, name) {
+				vary = append(vary, name)
+			}
+		}
+	}
+	return vary
+}
+
+// normalizeVaryValue 规范化请求头的值，避免同义的请求头生成不同的key
+func normalizeVaryValue(name string, values []string) string {
+	// 缓存数据的压缩由缓存中间件根据Accept-Encoding处理，
+	// 因此不需要区分
+	if name == elton.HeaderAcceptEncoding {
+		return ""
+	}
+	value := strings.ToLower(strings.Join(values, ","))
+	return strings.Join(strings.Fields(value), "")
+}
+
+// getVaryKey returns the secondary key of request for vary
+func getVaryKey(key string, header http.Header, vary []string) string {
+	var b strings.Builder
+	b.WriteString(key)
+	b.WriteString(varyKeySeparator)
+	for i, name := range vary {
+		if i != 0 {
+			b.WriteString("&")
+		}
+		b.WriteString(name)
+		b.WriteString("=")
+		b.WriteString(normalizeVaryValue(name, header.Values(name)))
+	}
+	return b.String()
+}
+
+type cacheFreshness uint8
+
+const (
+	// 缓存未过期
+	cacheFresh cacheFreshness = iota
+	// 已过期，但在stale-while-revalidate时间内
+	cacheStaleWhileRevalidate
+	// 已过期，但在stale-if-error时间内
+	cacheStaleIfError
+	// 已过期
+	cacheExpired
+)
+
 const (
 	// 缓存状态字节数
 	statusByteSize = 1
@@ -175,7 +272,8 @@ const (
 // 数据结构[状态(1字节), 创建时间(4字节), 状态码(2字节), 请求头长度(4字节), 请求头内容(N字节), 压缩类型(1字节) 响应内容(N字节)]
 type CacheResponse struct {
 	Status CacheStatus
-	// 创建时间
+	// 创建时间，已扣除上游响应的Age，
+	// 缓存的过期判断与store的TTL均以此计算
 	CreatedAt uint32
 	// 状态码
 	StatusCode int
@@ -185,6 +283,8 @@ type CacheResponse struct {
 	Compression CompressionType
 	// 响应数据
 	Body *bytes.Buffer
+	// 响应头Vary的请求头列表
+	Vary []string
 }
 
 // hitForPassData hit-for-pass状态的预编码数据（仅1个状态字节），
@@ -195,6 +295,12 @@ var hitForPassData = (&CacheResponse{
 
 // Bytes converts the cache response to bytes
 func (cp *CacheResponse) Bytes(ignoreHeaders ...string) []byte {
+	// vary只保存请求头列表
+	if cp.Status == StatusVary {
+		return append([]byte{
+			byte(cp.Status),
+		}, strings.Join(cp.Vary, ",")...)
+	}
 	// 只有hit的才需要保存后续的数据
 	if cp.Status != StatusHit {
 		return []byte{
@@ -281,6 +387,44 @@ func (cp *CacheResponse) SetBody(c *elton.Context, compressor CacheCompressor) e
 	return nil
 }
 
+// Age returns the age(seconds) of cache response
+func (cp *CacheResponse) Age() int {
+	now := uint32(time.Now().Unix())
+	if now < cp.CreatedAt {
+		return 0
+	}
+	return int(now - cp.CreatedAt)
+}
+
+// freshness 根据缓存的Cache-Control与创建时间判断缓存是否过期，
+// 缓存保存时已去除Age响应头，其时长已计入创建时间
+func (cp *CacheResponse) freshness() cacheFreshness {
+	age := cp.Age()
+	maxAge := GetCacheMaxAge(cp.Header)
+	if age < maxAge {
+		return cacheFresh
+	}
+	staleWhileRevalidate, staleIfError := GetCacheStaleAge(cp.Header)
+	if age < maxAge+staleWhileRevalidate {
+		return cacheStaleWhileRevalidate
+	}
+	if age < maxAge+staleIfError {
+		return cacheStaleIfError
+	}
+	return cacheExpired
+}
+
+// respond 以缓存数据响应
+func (cp *CacheResponse) respond(c *elton.Context, xCache string, compressor CacheCompressor) error {
+	c.SetHeader(HeaderXCache, xCache)
+	c.SetHeader(HeaderAge, strconv.Itoa(cp.Age()))
+	c.StatusCode = cp.StatusCode
+	// 要先清除原有的响应头中的Cache-Control
+	c.SetHeader(elton.HeaderCacheControl, "")
+	c.MergeHeader(cp.Header)
+	return cp.SetBody(c, compressor)
+}
+
 // NewCacheResponse decodes the cache data to cache response,
 // it's the reverse operation of CacheResponse.Bytes.
 // 数据布局: [状态(1字节)][创建时间(4字节)][状态码(2字节)][请求头长度(4字节)][请求头内容(N字节)][压缩类型(1字节)][响应内容(N字节)]
@@ -294,6 +438,12 @@ func NewCacheResponse(data []byte) *CacheResponse {
 			Status: StatusUnknown,
 		}
 	}
+	if CacheStatus(data[0]) == StatusVary {
+		return &CacheResponse{
+			Status: StatusVary,
+			Vary:   strings.Split(string(data[statusByteSize:]), ","),
+		}
+	}
 	// 定长头部（状态+创建时间+状态码+请求头长度）共11字节，
 	// 不足时仅返回状态（hit-for-pass只写入状态字节）
 	prefixSize := statusByteSize + createAtByteSize + statusCodeByteSize + headerBytesSize
@@ -331,14 +481,16 @@ func NewCacheResponse(data []byte) *CacheResponse {
 	offset += compressionBytesSize
 
 	body := data[offset:]
+	header := hs.Header()
 
 	return &CacheResponse{
 		Status:      CacheStatus(status),
 		CreatedAt:   createdAt,
 		StatusCode:  int(statusCode),
-		Header:      hs.Header(),
+		Header:      header,
 		Compression: CompressionType(compression),
 		Body:        bytes.NewBuffer(body),
+		Vary:        parseVary(header),
 	}
 }
 
@@ -355,6 +507,72 @@ func cacheDefaultGetKey(c *elton.Context) string {
 	return c.Request.Method + " " + c.Request.RequestURI
 }
 
+// cacheFlightGroup 同一key的fetch请求合并，
+// 仅一个请求转至后续中间件，其它请求等待其完成后再读取缓存
+type cacheFlightGroup struct {
+	mu      sync.Mutex
+	flights map[string]chan struct{}
+}
+
+// acquire 获取key的fetch权限，若已有请求在fetch，
+// 则返回其完成通知的chan
+func (g *cacheFlightGroup) acquire(key string) (<-chan struct{}, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if done, ok := g.flights[key]; ok {
+		return done, false
+	}
+	g.flights[key] = make(chan struct{})
+	return nil, true
+}
+
+// release 释放key的fetch权限并通知等待的请求
+func (g *cacheFlightGroup) release(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if done, ok := g.flights[key]; ok {
+		delete(g.flights, key)
+		close(done)
+	}
+}
+
+// revalidate 在后台执行fn以刷新缓存，需已获取key的fetch权限
+func (g *cacheFlightGroup) revalidate(key string, fn func()) {
+	go func() {
+		defer g.release(key)
+		// 后台刷新出错不能影响主流程
+		defer func() {
+			_ = recover()
+		}()
+		fn()
+	}()
+}
+
+// forkRevalidateContext 复制context用于后台刷新，仅执行缓存中间件之后的handler，
+// 前置中间件与全局中间件（如日志、统计、限流等）不再重复执行
+func forkRevalidateContext(c *elton.Context) *elton.Context {
+	// 后台刷新不受原请求结束的影响
+	req := c.Request.Clone(context.WithoutCancel(c.Context()))
+	return c.Fork(&cacheDiscardWriter{
+		header: make(http.Header),
+	}, req)
+}
+
+// cacheDiscardWriter 后台刷新时的响应，丢弃所有数据
+type cacheDiscardWriter struct {
+	header http.Header
+}
+
+func (w *cacheDiscardWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *cacheDiscardWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *cacheDiscardWriter) WriteHeader(int) {}
+
 func getBodyBuffer(c *elton.Context, marshal func(any) ([]byte, error)) (*bytes.Buffer, error) {
 	if c.BodyBuffer != nil {
 		return c.BodyBuffer, nil
@@ -376,6 +594,17 @@ func getBodyBuffer(c *elton.Context, marshal func(any) ([]byte, error)) (*bytes.
 //   - hit-for-pass: 此前已确认该URL响应不可缓存，直接透传至后续中间件，
 //     避免在TTL内反复尝试缓存判定。
 //
+// 同一key的并发fetch会合并，仅一个请求转至后续中间件，其它请求等待其完成后读取缓存。
+// 命中的缓存按其创建时间（已扣除上游的Age）与Cache-Control判断是否过期，
+// 与store的TTL一致，因此即使store未及时淘汰过期数据（如TTL不精确的store），
+// 过期的缓存也不会以hit响应。
+// 缓存过期后，若在 Cache-Control 的 stale-while-revalidate 时间内，
+// 以过期缓存响应（X-Cache: stale）并在后台刷新（仅重新执行缓存中间件之后的handler）；
+// 若在 stale-if-error 时间内，则重新fetch，出错（返回error或5xx）时以过期缓存响应。
+//
+// 响应有Vary时，按Vary的请求头生成二级key保存缓存（Accept-Encoding由缓存自行处理，不区分），
+// Vary: * 的响应不可缓存。
+//
 // 仅 GET/HEAD 请求走缓存逻辑，其它method直接透传。
 func NewCache(config CacheConfig) elton.Handler {
 	skipper := getSkipper(config.Skipper)
@@ -397,46 +626,36 @@ func NewCache(config CacheConfig) elton.Handler {
 	}
 	ignoreHeaders := config.IgnoreHeaders
 	compressor := config.Compressor
-	return func(c *elton.Context) error {
-		if skipper(c) {
-			return c.Next()
-		}
-		if IsPassCacheMethod(c.Request.Method) {
-			return c.Next()
-		}
+	counter := config.Counter
+	flights := &cacheFlightGroup{
+		flights: make(map[string]chan struct{}),
+	}
+
+	// fetch 转至后续中间件获取响应并缓存，
+	// stale不为空时，出错则以stale响应
+	fetch := func(c *elton.Context, key string, stale *CacheResponse) error {
 		ctx := c.Context()
-		key := getKey(c)
-		data, err := store.Get(ctx, key)
-		if err != nil {
-			return err
-		}
-		cacheResp := NewCacheResponse(data)
-		switch cacheResp.Status {
-		// 如果是hit for pass，直接转至后续中间件
-		case StatusHitForPass:
-			c.SetHeader(HeaderXCache, "hit-for-pass")
-			return c.Next()
-		// 如果获取到数据，则直接响应，不需要next转至后续中间件
-		case StatusHit:
-			c.SetHeader(HeaderXCache, "hit")
-			age := uint32(time.Now().Unix()) - cacheResp.CreatedAt
-			c.SetHeader(HeaderAge, strconv.Itoa(int(age)))
-			c.StatusCode = cacheResp.StatusCode
-			// 要先清除原有的响应头中的Cache-Control
-			c.SetHeader(elton.HeaderCacheControl, "")
-			c.MergeHeader(cacheResp.Header)
-			return cacheResp.SetBody(c, compressor)
-		}
 		c.SetHeader(HeaderXCache, "fetch")
-		err = c.Next()
+		err := c.Next()
+		if stale != nil && (err != nil || c.StatusCode >= http.StatusInternalServerError) {
+			c.Body = nil
+			c.BodyBuffer = nil
+			counter.stale()
+			return stale.respond(c, "stale", compressor)
+		}
 		if err != nil {
 			return err
 		}
+		vary := parseVary(c.Header())
 		cacheable, cacheAge := isCacheable(c)
+		// Vary: * 表示响应随任意请求信息变化，不可缓存
+		if slices.Contains(vary, "*") {
+			cacheable = false
+		}
 		// 不可缓存
 		if !cacheable {
 			// 对于fetch请求，不能缓存的设置hit for pass
-			return store.Set(ctx, key, hitForPassData, hitForPassTTL)
+			return counter.storeError(store.Set(ctx, key, hitForPassData, hitForPassTTL))
 		}
 
 		buffer, err := getBodyBuffer(c, marshal)
@@ -453,22 +672,133 @@ func NewCache(config CacheConfig) elton.Handler {
 			}
 		}
 
-		cacheResp = &CacheResponse{
+		cacheResp := &CacheResponse{
 			// 状态设置为hit
 			Status:      StatusHit,
 			Compression: compressionType,
-			CreatedAt:   uint32(time.Now().Unix()),
-			StatusCode:  c.StatusCode,
-			Header:      c.Header(),
-			Body:        buffer,
+			// 上游响应的Age计入创建时间，与TTL保持一致
+			CreatedAt:  uint32(time.Now().Unix() - int64(getCacheAge(c.Header()))),
+			StatusCode: c.StatusCode,
+			Header:     c.Header(),
+			Body:       buffer,
+			Vary:       vary,
+		}
+		// Surrogate-Key仅用于缓存的标签索引，不缓存也不响应给客户端
+		tags := getSurrogateKeys(c.Header())
+		c.Header().Del(HeaderSurrogateKey)
+		data := cacheResp.Bytes(ignoreHeaders...)
+		// 过期后仍需保留stale-while-revalidate/stale-if-error的时长
+		staleWhileRevalidate, staleIfError := GetCacheStaleAge(c.Header())
+		ttl := time.Duration(cacheAge+max(staleWhileRevalidate, staleIfError)) * time.Second
+		cacheKey := key
+		// 有vary的响应，主key保存vary的请求头列表，
+		// 响应数据则保存在根据请求头生成的二级key中
+		if len(vary) != 0 {
+			cacheKey = getVaryKey(key, c.Request.Header, vary)
+			varyResp := &CacheResponse{
+				Status: StatusVary,
+				Vary:   vary,
+			}
+			err = counter.storeError(store.Set(ctx, key, varyResp.Bytes(), ttl))
+			if err != nil {
+				return err
+			}
 		}
-		data = cacheResp.Bytes(ignoreHeaders...)
 		// 如果想忽略store的错误，则自定义store时，
 		// 不要返回出错则可
-		err = store.Set(ctx, key, data, time.Duration(cacheAge)*time.Second)
+		err = counter.storeError(store.Set(ctx, cacheKey, data, ttl))
 		if err != nil {
 			return err
 		}
+		counter.stored(len(data))
+		if tagger, ok := store.(CacheTagger); ok && len(tags) != 0 {
+			err = tagger.Tag(ctx, cacheKey, tags...)
+			// store不支持标签时忽略
+			if errors.Is(err, ErrCachePurgeNotSupported) {
+				err = nil
+			}
+			if err = counter.storeError(err); err != nil {
+				return err
+			}
+		}
 		return cacheResp.SetBody(c, compressor)
 	}
+
+	return func(c *elton.Context) error {
+		if skipper(c) {
+			return c.Next()
+		}
+		if IsPassCacheMethod(c.Request.Method) {
+			return c.Next()
+		}
+		ctx := c.Context()
+		key := getKey(c)
+		coalesced := false
+		for {
+			data, err := store.Get(ctx, key)
+			if err = counter.storeError(err); err != nil {
+				return err
+			}
+			var stale *CacheResponse
+			cacheKey := key
+			cacheResp := NewCacheResponse(data)
+			// 响应有vary，根据请求头获取二级key的缓存
+			if cacheResp.Status == StatusVary {
+				cacheKey = getVaryKey(key, c.Request.Header, cacheResp.Vary)
+				data, err = store.Get(ctx, cacheKey)
+				if err = counter.storeError(err); err != nil {
+					return err
+				}
+				cacheResp = NewCacheResponse(data)
+			}
+			switch cacheResp.Status {
+			// 如果是hit for pass，直接转至后续中间件
+			case StatusHitForPass:
+				c.SetHeader(HeaderXCache, "hit-for-pass")
+				counter.hitForPass()
+				return c.Next()
+			// 如果获取到数据，则直接响应，不需要next转至后续中间件
+			case StatusHit:
+				switch cacheResp.freshness() {
+				case cacheFresh:
+					counter.hit()
+					return cacheResp.respond(c, "hit", compressor)
+				case cacheStaleWhileRevalidate:
+					// 已有请求在fetch时无需再刷新
+					if _, ok := flights.acquire(cacheKey); ok {
+						fc := forkRevalidateContext(c)
+						// 非路由创建的context无法后台刷新，按stale-if-error处理
+						if fc == nil {
+							flights.release(cacheKey)
+							stale = cacheResp
+							break
+						}
+						flights.revalidate(cacheKey, func() {
+							_ = fetch(fc, key, nil)
+						})
+					}
+					counter.stale()
+					return cacheResp.respond(c, "stale", compressor)
+				case cacheStaleIfError:
+					stale = cacheResp
+				}
+			}
+			// 等待过一次的请求不再合并，避免fetch结果不可缓存时反复等待
+			if !coalesced {
+				done, ok := flights.acquire(cacheKey)
+				if !ok {
+					select {
+					case <-done:
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+					coalesced = true
+					continue
+				}
+				defer flights.release(cacheKey)
+			}
+			counter.miss()
+			return fetch(c, key, stale)
+		}
+	}
 }
@@ -0,0 +1,174 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/vicanso/elton/v2"
+	"github.com/vicanso/hes"
+)
+
+// HeaderSurrogateKey the tags of response, separated by space,
+// the cache middleware indexes them and removes the header
+const HeaderSurrogateKey = "Surrogate-Key"
+
+const (
+	// ErrCacheCategory cache error category
+	ErrCacheCategory = "elton-cache"
+)
+
+var (
+	// ErrCachePurgeInvalid purge params is invalid
+	ErrCachePurgeInvalid = &hes.Error{
+		StatusCode: http.StatusBadRequest,
+		Message:    "key, prefix or tag is required",
+		Category:   ErrCacheCategory,
+	}
+	// ErrCachePurgeNotSupported purge is not supported by store
+	ErrCachePurgeNotSupported = &hes.Error{
+		StatusCode: http.StatusNotImplemented,
+		Message:    "purge is not supported by the cache store",
+		Category:   ErrCacheCategory,
+	}
+)
+
+// CacheDeleter is the optional interface of cache store,
+// which removes the data of key
+type CacheDeleter interface {
+	Delete(ctx context.Context, key string) error
+}
+
+// CacheKeyPurger is the optional interface of cache store, which removes
+// the data of key and reports whether the key exists. It is preferred to
+// CacheDeleter by the purge handler for the accurate count of purged caches.
+type CacheKeyPurger interface {
+	PurgeKey(ctx context.Context, key string) (bool, error)
+}
+
+// CachePrefixPurger is the optional interface of cache store,
+// which removes the data whose key has the prefix
+type CachePrefixPurger interface {
+	PurgePrefix(ctx context.Context, prefix string) (int, error)
+}
+
+// CacheTagger is the optional interface of cache store,
+// which indexes the key by surrogate keys and removes the data by tag.
+// Tag can return ErrCachePurgeNotSupported if the store does not support
+// tag at runtime(e.g. the back store of TieredStore), the cache middleware ignores it.
+type CacheTagger interface {
+	Tag(ctx context.Context, key string, tags ...string) error
+	PurgeTag(ctx context.Context, tag string) (int, error)
+}
+
+// getSurrogateKeys returns the tags of Surrogate-Key header
+func getSurrogateKeys(header http.Header) []string {
+	return strings.Fields(strings.Join(header.Values(HeaderSurrogateKey), " "))
+}
+
+// NewCachePurgeHandler returns an admin handler which purges the cache of store,
+// the query params are:
+//   - key: remove the cache of key(include the caches of vary), e.g. "GET /users/1"
+//   - prefix: remove the caches whose key has the prefix
+//   - tag: remove the caches tagged by Surrogate-Key
+//
+// Each param can be set multiple times, and the response is the count of purged caches,
+// e.g. {"count": 2}. The cache of key is counted only if the store implements CacheKeyPurger,
+// otherwise only the caches of vary are counted. It returns ErrCachePurgeNotSupported
+// if the store does not support.
+func NewCachePurgeHandler(store CacheStore) elton.Handler {
+	if store == nil {
+		panic("require store for cache purge")
+	}
+	return func(c *elton.Context) error {
+		query := c.Request.URL.Query()
+		keys := query["key"]
+		prefixes := query["prefix"]
+		tags := query["tag"]
+		if len(keys) == 0 && len(prefixes) == 0 && len(tags) == 0 {
+			return ErrCachePurgeInvalid
+		}
+		deleter, deleterOK := store.(CacheDeleter)
+		keyPurger, keyPurgerOK := store.(CacheKeyPurger)
+		purger, purgerOK := store.(CachePrefixPurger)
+		tagger, taggerOK := store.(CacheTagger)
+		if (len(keys) != 0 && !deleterOK && !keyPurgerOK) ||
+			(len(prefixes) != 0 && !purgerOK) ||
+			(len(tags) != 0 && !taggerOK) {
+			return ErrCachePurgeNotSupported
+		}
+
+		ctx := c.Context()
+		count := 0
+		for _, key := range keys {
+			// 仅可判断key是否存在时才计数
+			if keyPurgerOK {
+				existed, err := keyPurger.PurgeKey(ctx, key)
+				if err != nil {
+					return err
+				}
+				if existed {
+					count++
+				}
+			} else if err := deleter.Delete(ctx, key); err != nil {
+				return err
+			}
+			// 同时清除vary的二级key缓存
+			if purgerOK {
+				n, err := purger.PurgePrefix(ctx, key+varyKeySeparator)
+				if err != nil {
+					return err
+				}
+				count += n
+			}
+		}
+		for _, prefix := range prefixes {
+			n, err := purger.PurgePrefix(ctx, prefix)
+			if err != nil {
+				return err
+			}
+			count += n
+		}
+		for _, tag := range tags {
+			n, err := tagger.PurgeTag(ctx, tag)
+			if err != nil {
+				return err
+			}
+			count += n
+		}
+		buf, err := json.Marshal(map[string]int{
+			"count": count,
+		})
+		if err != nil {
+			return err
+		}
+		c.SetHeader(elton.HeaderContentType, elton.MIMEApplicationJSON)
+		c.NoCache()
+		c.BodyBuffer = bytes.NewBuffer(buf)
+		return nil
+	}
+}
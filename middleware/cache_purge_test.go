@@ -0,0 +1,150 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton/v2"
+)
+
+func TestGetSurrogateKeys(t *testing.T) {
+	assert := assert.New(t)
+	c := elton.NewContext(httptest.NewRecorder(), nil)
+	assert.Empty(getSurrogateKeys(c.Header()))
+
+	c.AddHeader(HeaderSurrogateKey, "user  user-1")
+	c.AddHeader(HeaderSurrogateKey, "book")
+	assert.Equal([]string{
+		"user",
+		"user-1",
+		"book",
+	}, getSurrogateKeys(c.Header()))
+}
+
+func TestCacheSurrogateKey(t *testing.T) {
+	assert := assert.New(t)
+
+	store := NewLRUStore(10)
+	e := elton.New()
+	e.Use(NewCache(CacheConfig{
+		Store: store,
+	}))
+	e.GET("/users/{id}", func(c *elton.Context) error {
+		c.CacheMaxAge(time.Minute)
+		c.SetHeader(HeaderSurrogateKey, "user user-"+c.Param("id"))
+		c.BodyBuffer = bytes.NewBufferString(c.Param("id"))
+		return nil
+	})
+	e.DELETE("/cache", NewCachePurgeHandler(store))
+
+	for _, id := range []string{"1", "2"} {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/users/"+id, nil))
+		assert.Equal("fetch", resp.Header().Get(HeaderXCache))
+		// surrogate key不响应给客户端
+		assert.Empty(resp.Header().Get(HeaderSurrogateKey))
+	}
+
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("DELETE", "/cache?tag=user-1", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal(`{"count":1}`, resp.Body.String())
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/users/1", nil))
+	assert.Equal("fetch", resp.Header().Get(HeaderXCache))
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/users/2", nil))
+	assert.Equal("hit", resp.Header().Get(HeaderXCache))
+	assert.Empty(resp.Header().Get(HeaderSurrogateKey))
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("DELETE", "/cache?tag=user", nil))
+	assert.Equal(`{"count":2}`, resp.Body.String())
+}
+
+type testDeleteStore struct {
+	testStore
+}
+
+func (ts *testDeleteStore) Delete(ctx context.Context, key string) error {
+	ts.data.Delete(key)
+	return nil
+}
+
+func TestNewCachePurgeHandler(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Panics(func() {
+		NewCachePurgeHandler(nil)
+	})
+
+	store := &testDeleteStore{}
+	ctx := context.Background()
+	_ = store.Set(ctx, "GET /", []byte("a"), time.Minute)
+	fn := NewCachePurgeHandler(store)
+
+	// 未指定参数
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/", nil))
+	err := fn(c)
+	assert.True(errors.Is(err, ErrCachePurgeInvalid))
+
+	// 不支持prefix
+	c = elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/?prefix=GET", nil))
+	err = fn(c)
+	assert.True(errors.Is(err, ErrCachePurgeNotSupported))
+
+	// 删除key，store无法判断key是否存在，不计数
+	c = elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/?key=GET+/", nil))
+	err = fn(c)
+	assert.Nil(err)
+	assert.Equal(`{"count":0}`, c.BodyBuffer.String())
+	assert.Equal("no-cache", c.GetHeader(elton.HeaderCacheControl))
+	buf, _ := store.Get(ctx, "GET /")
+	assert.Empty(buf)
+}
+
+func TestNewCachePurgeHandlerCount(t *testing.T) {
+	assert := assert.New(t)
+
+	store := NewLRUStore(10)
+	ctx := context.Background()
+	_ = store.Set(ctx, "GET /", []byte("a"), time.Minute)
+	fn := NewCachePurgeHandler(store)
+
+	// 仅计数存在的key
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/?key=GET+/&key=GET+/users", nil))
+	assert.Nil(fn(c))
+	assert.Equal(`{"count":1}`, c.BodyBuffer.String())
+
+	c = elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/?key=GET+/", nil))
+	assert.Nil(fn(c))
+	assert.Equal(`{"count":0}`, c.BodyBuffer.String())
+}
@@ -0,0 +1,177 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync/atomic"
+
+	"github.com/vicanso/elton/v2"
+)
+
+// CacheEntry the entry of cache store
+type CacheEntry struct {
+	Key string `json:"key"`
+	// Age the age(seconds) of entry
+	Age int `json:"age"`
+	// TTL the remaining ttl(seconds) of entry
+	TTL int `json:"ttl"`
+	// Size the size of data
+	Size int `json:"size"`
+}
+
+// CacheEntryLister is the optional interface of cache store,
+// which lists the entries of store
+type CacheEntryLister interface {
+	Entries() []CacheEntry
+}
+
+// CacheStatsProvider is the optional interface of cache store,
+// which returns the stats of store, the result should be marshaled as json
+type CacheStatsProvider interface {
+	StoreStats() any
+}
+
+// CacheStats the stats of cache middleware
+type CacheStats struct {
+	Hits         uint64 `json:"hits"`
+	Misses       uint64 `json:"misses"`
+	HitForPasses uint64 `json:"hitForPasses"`
+	Stales       uint64 `json:"stales"`
+	StoreErrors  uint64 `json:"storeErrors"`
+	// BytesStored the total bytes of responses saved to store
+	BytesStored uint64 `json:"bytesStored"`
+}
+
+// CacheCounter counts the stats of cache middleware,
+// set it to CacheConfig.Counter to enable.
+type CacheCounter struct {
+	hits         atomic.Uint64
+	misses       atomic.Uint64
+	hitForPasses atomic.Uint64
+	stales       atomic.Uint64
+	storeErrors  atomic.Uint64
+	bytesStored  atomic.Uint64
+}
+
+// NewCacheCounter creates a cache counter
+func NewCacheCounter() *CacheCounter {
+	return &CacheCounter{}
+}
+
+// 以下方法均支持nil，未设置counter时不统计
+
+func (cc *CacheCounter) hit() {
+	if cc != nil {
+		cc.hits.Add(1)
+	}
+}
+
+func (cc *CacheCounter) miss() {
+	if cc != nil {
+		cc.misses.Add(1)
+	}
+}
+
+func (cc *CacheCounter) hitForPass() {
+	if cc != nil {
+		cc.hitForPasses.Add(1)
+	}
+}
+
+func (cc *CacheCounter) stale() {
+	if cc != nil {
+		cc.stales.Add(1)
+	}
+}
+
+func (cc *CacheCounter) stored(size int) {
+	if cc != nil {
+		cc.bytesStored.Add(uint64(size))
+	}
+}
+
+// storeError counts the error of store and returns it
+func (cc *CacheCounter) storeError(err error) error {
+	if cc != nil && err != nil {
+		cc.storeErrors.Add(1)
+	}
+	return err
+}
+
+// Stats returns the stats of cache middleware
+func (cc *CacheCounter) Stats() CacheStats {
+	return CacheStats{
+		Hits:         cc.hits.Load(),
+		Misses:       cc.misses.Load(),
+		HitForPasses: cc.hitForPasses.Load(),
+		Stales:       cc.stales.Load(),
+		StoreErrors:  cc.storeErrors.Load(),
+		BytesStored:  cc.bytesStored.Load(),
+	}
+}
+
+// CacheStatsHandlerConfig cache stats handler config, at least one of counter and store is required
+type CacheStatsHandlerConfig struct {
+	// Counter the counter of cache middleware
+	Counter *CacheCounter
+	// Store the store of cache middleware, the stats of store are rendered if it implements
+	// CacheStatsProvider, and the entries are listed if it implements CacheEntryLister
+	Store CacheStore
+}
+
+// NewCacheStatsHandler returns an admin handler which renders the stats
+// of cache as json, e.g. {"cache": {...}, "store": {...}, "entries": [...]}.
+// Listing entries may be slow for large store, set the query param "entries=false" to skip.
+func NewCacheStatsHandler(config CacheStatsHandlerConfig) elton.Handler {
+	if config.Counter == nil && config.Store == nil {
+		panic("require counter or store for cache stats")
+	}
+	type statsResult struct {
+		Cache   *CacheStats  `json:"cache,omitempty"`
+		Store   any          `json:"store,omitempty"`
+		Entries []CacheEntry `json:"entries,omitempty"`
+	}
+	return func(c *elton.Context) error {
+		result := statsResult{}
+		if config.Counter != nil {
+			stats := config.Counter.Stats()
+			result.Cache = &stats
+		}
+		if provider, ok := config.Store.(CacheStatsProvider); ok {
+			result.Store = provider.StoreStats()
+		}
+		if lister, ok := config.Store.(CacheEntryLister); ok && c.QueryParam("entries") != "false" {
+			result.Entries = lister.Entries()
+		}
+		buf, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		c.SetHeader(elton.HeaderContentType, elton.MIMEApplicationJSON)
+		c.NoCache()
+		c.BodyBuffer = bytes.NewBuffer(buf)
+		return nil
+	}
+}
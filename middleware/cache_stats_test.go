@@ -0,0 +1,130 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton/v2"
+)
+
+type testErrorStore struct{}
+
+func (*testErrorStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return nil, errors.New("get error")
+}
+
+func (*testErrorStore) Set(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	return nil
+}
+
+func TestCacheCounter(t *testing.T) {
+	assert := assert.New(t)
+
+	// 未设置counter时不统计
+	var counter *CacheCounter
+	counter.hit()
+	assert.Nil(counter.storeError(nil))
+
+	counter = NewCacheCounter()
+	store := NewLRUStore(10)
+	e := elton.New()
+	e.Use(NewCache(CacheConfig{
+		Store:   store,
+		Counter: counter,
+	}))
+	e.GET("/", func(c *elton.Context) error {
+		c.CacheMaxAge(time.Minute)
+		c.BodyBuffer = bytes.NewBufferString("hello world")
+		return nil
+	})
+	e.GET("/pass", func(c *elton.Context) error {
+		c.BodyBuffer = bytes.NewBufferString("pass")
+		return nil
+	})
+	for _, url := range []string{"/", "/", "/pass", "/pass"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", url, nil))
+	}
+	data, _ := store.Get(context.Background(), "GET /")
+	assert.Equal(CacheStats{
+		Hits:         1,
+		Misses:       2,
+		HitForPasses: 1,
+		BytesStored:  uint64(len(data)),
+	}, counter.Stats())
+
+	// store出错
+	errCounter := NewCacheCounter()
+	fn := NewCache(CacheConfig{
+		Store:   &testErrorStore{},
+		Counter: errCounter,
+	})
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.NotNil(fn(c))
+	assert.Equal(uint64(1), errCounter.Stats().StoreErrors)
+}
+
+func TestNewCacheStatsHandler(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Panics(func() {
+		NewCacheStatsHandler(CacheStatsHandlerConfig{})
+	})
+
+	counter := NewCacheCounter()
+	counter.hit()
+	store := NewLRUStore(10)
+	_ = store.Set(context.Background(), "GET /", []byte("abc"), time.Minute)
+	fn := NewCacheStatsHandler(CacheStatsHandlerConfig{
+		Counter: counter,
+		Store:   store,
+	})
+
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Nil(fn(c))
+	assert.Equal(elton.MIMEApplicationJSON, c.GetHeader(elton.HeaderContentType))
+	result := struct {
+		Cache   CacheStats    `json:"cache"`
+		Store   LRUStoreStats `json:"store"`
+		Entries []CacheEntry  `json:"entries"`
+	}{}
+	assert.Nil(json.Unmarshal(c.BodyBuffer.Bytes(), &result))
+	assert.Equal(uint64(1), result.Cache.Hits)
+	assert.Equal(1, result.Store.Keys)
+	assert.Len(result.Entries, 1)
+	assert.Equal("GET /", result.Entries[0].Key)
+	assert.Equal(3, result.Entries[0].Size)
+	assert.InDelta(60, result.Entries[0].TTL, 1)
+
+	// 不获取entries
+	c = elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/?entries=false", nil))
+	assert.Nil(fn(c))
+	assert.NotContains(c.BodyBuffer.String(), "entries")
+}
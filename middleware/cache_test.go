@@ -31,6 +31,7 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -107,6 +108,62 @@ func TestGetCacheMaxAge(t *testing.T) {
 	}
 }
 
+func TestGetCacheStaleAge(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		value                string
+		staleWhileRevalidate int
+		staleIfError         int
+	}{
+		{
+			value: "",
+		},
+		{
+			value: "public, max-age=10",
+		},
+		{
+			value:                "public, max-age=10, stale-while-revalidate=30",
+			staleWhileRevalidate: 30,
+		},
+		{
+			value:                "max-age=10, stale-while-revalidate=30, stale-if-error=60",
+			staleWhileRevalidate: 30,
+			staleIfError:         60,
+		},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		h.Set(elton.HeaderCacheControl, tt.value)
+		staleWhileRevalidate, staleIfError := GetCacheStaleAge(h)
+		assert.Equal(tt.staleWhileRevalidate, staleWhileRevalidate)
+		assert.Equal(tt.staleIfError, staleIfError)
+	}
+}
+
+func TestCacheResponseFreshness(t *testing.T) {
+	assert := assert.New(t)
+
+	now := uint32(time.Now().Unix())
+	newResp := func(age uint32, cacheControl string) *CacheResponse {
+		return &CacheResponse{
+			Status:    StatusHit,
+			CreatedAt: now - age,
+			Header: http.Header{
+				elton.HeaderCacheControl: []string{
+					cacheControl,
+				},
+			},
+		}
+	}
+	cacheControl := "public, max-age=60, stale-while-revalidate=30, stale-if-error=120"
+	assert.Equal(cacheFresh, newResp(10, cacheControl).freshness())
+	assert.Equal(cacheStaleWhileRevalidate, newResp(70, cacheControl).freshness())
+	assert.Equal(cacheStaleIfError, newResp(100, cacheControl).freshness())
+	assert.Equal(cacheExpired, newResp(200, cacheControl).freshness())
+	assert.Equal(cacheExpired, newResp(70, "public, max-age=60").freshness())
+}
+
 func TestNewCacheResponseCorrupted(t *testing.T) {
 	assert := assert.New(t)
 
@@ -439,3 +496,325 @@ func TestNewCache(t *testing.T) {
 	assert.Equal(buffer, c.BodyBuffer)
 	assert.Equal("hit", c.GetHeader(HeaderXCache))
 }
+
+func TestCacheCoalesce(t *testing.T) {
+	assert := assert.New(t)
+
+	e := elton.New()
+	e.Use(NewCache(CacheConfig{
+		Store: &testStore{},
+	}))
+	var count atomic.Int32
+	e.GET("/", func(c *elton.Context) error {
+		count.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		c.CacheMaxAge(time.Minute)
+		c.BodyBuffer = bytes.NewBufferString("hello world")
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	xCaches := make([]string, 10)
+	for i := range xCaches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp := httptest.NewRecorder()
+			e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+			assert.Equal("hello world", resp.Body.String())
+			xCaches[i] = resp.Header().Get(HeaderXCache)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(int32(1), count.Load())
+	fetchCount := 0
+	for _, xCache := range xCaches {
+		if xCache == "fetch" {
+			fetchCount++
+		} else {
+			assert.Equal("hit", xCache)
+		}
+	}
+	assert.Equal(1, fetchCount)
+}
+
+func TestCacheStale(t *testing.T) {
+	assert := assert.New(t)
+
+	newStaleData := func(cacheControl string) []byte {
+		return (&CacheResponse{
+			Status:     StatusHit,
+			CreatedAt:  uint32(time.Now().Unix()) - 90,
+			StatusCode: 200,
+			Header: http.Header{
+				elton.HeaderCacheControl: []string{
+					cacheControl,
+				},
+			},
+			Body: bytes.NewBufferString("stale"),
+		}).Bytes()
+	}
+
+	t.Run("stale-while-revalidate", func(t *testing.T) {
+		store := &testStore{}
+		store.data.Store("GET /", newStaleData("public, max-age=60, stale-while-revalidate=60"))
+		e := elton.New()
+		e.Use(NewCache(CacheConfig{
+			Store: store,
+		}))
+		var count atomic.Int32
+		e.GET("/", func(c *elton.Context) error {
+			count.Add(1)
+			c.SetHeader(elton.HeaderCacheControl, "public, max-age=60, stale-while-revalidate=60")
+			c.BodyBuffer = bytes.NewBufferString("fresh")
+			return nil
+		})
+
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+		assert.Equal("stale", resp.Body.String())
+		assert.Equal("stale", resp.Header().Get(HeaderXCache))
+		assert.Equal("90", resp.Header().Get(HeaderAge))
+
+		// 等待后台刷新完成
+		assert.Eventually(func() bool {
+			data, _ := store.Get(context.Background(), "GET /")
+			return NewCacheResponse(data).Body.String() == "fresh"
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(int32(1), count.Load())
+
+		resp = httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+		assert.Equal("fresh", resp.Body.String())
+		assert.Equal("hit", resp.Header().Get(HeaderXCache))
+	})
+
+	t.Run("revalidate downstream only", func(t *testing.T) {
+		store := &testStore{}
+		store.data.Store("GET /users/1", newStaleData("public, max-age=60, stale-while-revalidate=60"))
+		e := elton.New()
+		var preCount, globalCount, handlerCount atomic.Int32
+		e.Pre(func(_ *http.Request) {
+			preCount.Add(1)
+		})
+		e.Use(func(c *elton.Context) error {
+			globalCount.Add(1)
+			c.Set("account", "tree")
+			return c.Next()
+		})
+		e.Use(NewCache(CacheConfig{
+			Store: store,
+		}))
+		e.GET("/users/{id}", func(c *elton.Context) error {
+			handlerCount.Add(1)
+			c.SetHeader(elton.HeaderCacheControl, "public, max-age=60, stale-while-revalidate=60")
+			// 后台刷新时保留路由参数与前置中间件设置的数据
+			account, _ := c.Get("account")
+			c.BodyBuffer = bytes.NewBufferString(c.Param("id") + ":" + account.(string))
+			return nil
+		})
+
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/users/1", nil))
+		assert.Equal("stale", resp.Body.String())
+		assert.Eventually(func() bool {
+			data, _ := store.Get(context.Background(), "GET /users/1")
+			return NewCacheResponse(data).Body.String() == "1:tree"
+		}, time.Second, 10*time.Millisecond)
+		// 前置与全局中间件仅执行一次
+		assert.Equal(int32(1), preCount.Load())
+		assert.Equal(int32(1), globalCount.Load())
+		assert.Equal(int32(1), handlerCount.Load())
+	})
+
+	t.Run("upstream age", func(t *testing.T) {
+		store := &testStore{}
+		e := elton.New()
+		e.Use(NewCache(CacheConfig{
+			Store: store,
+		}))
+		e.GET("/", func(c *elton.Context) error {
+			c.SetHeader(elton.HeaderCacheControl, "public, max-age=60")
+			c.SetHeader(HeaderAge, "50")
+			c.BodyBuffer = bytes.NewBufferString("abc")
+			return nil
+		})
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+		assert.Equal("fetch", resp.Header().Get(HeaderXCache))
+		data, _ := store.Get(context.Background(), "GET /")
+		cacheResp := NewCacheResponse(data)
+		// 上游的Age计入创建时间，过期判断与TTL一致
+		assert.InDelta(50, cacheResp.Age(), 1)
+		assert.Equal(cacheFresh, cacheResp.freshness())
+		cacheResp.CreatedAt -= 10
+		assert.Equal(cacheExpired, cacheResp.freshness())
+
+		resp = httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+		assert.Equal("hit", resp.Header().Get(HeaderXCache))
+		age, _ := strconv.Atoi(resp.Header().Get(HeaderAge))
+		assert.InDelta(50, age, 1)
+	})
+
+	t.Run("stale-if-error", func(t *testing.T) {
+		store := &testStore{}
+		store.data.Store("GET /", newStaleData("public, max-age=60, stale-if-error=60"))
+		e := elton.New()
+		e.Use(NewCache(CacheConfig{
+			Store: store,
+		}))
+		e.GET("/", func(c *elton.Context) error {
+			return errors.New("backend error")
+		})
+
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(200, resp.Code)
+		assert.Equal("stale", resp.Body.String())
+		assert.Equal("stale", resp.Header().Get(HeaderXCache))
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		store := &testStore{}
+		store.data.Store("GET /", newStaleData("public, max-age=60"))
+		e := elton.New()
+		e.Use(NewCache(CacheConfig{
+			Store: store,
+		}))
+		e.GET("/", func(c *elton.Context) error {
+			c.CacheMaxAge(time.Minute)
+			c.BodyBuffer = bytes.NewBufferString("fresh")
+			return nil
+		})
+
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+		assert.Equal("fresh", resp.Body.String())
+		assert.Equal("fetch", resp.Header().Get(HeaderXCache))
+	})
+}
+
+func TestParseVary(t *testing.T) {
+	assert := assert.New(t)
+
+	h := http.Header{}
+	assert.Empty(parseVary(h))
+
+	h.Add(headerVary, "accept-language, Accept")
+	h.Add(headerVary, "Accept-Language")
+	assert.Equal([]string{
+		"Accept-Language",
+		"Accept",
+	}, parseVary(h))
+
+	h.Set(headerVary, "*")
+	assert.Equal([]string{
+		"*",
+	}, parseVary(h))
+}
+
+func TestGetVaryKey(t *testing.T) {
+	assert := assert.New(t)
+
+	vary := []string{
+		"Accept-Language",
+		elton.HeaderAcceptEncoding,
+	}
+	h := http.Header{}
+	h.Set("Accept-Language", "zh-CN, en;q=0.8")
+	h.Set(elton.HeaderAcceptEncoding, "gzip, br")
+	key := getVaryKey("GET /", h, vary)
+	assert.Equal("GET /|Accept-Language=zh-cn,en;q=0.8&Accept-Encoding=", key)
+
+	// 大小写、空格及Accept-Encoding不同的请求使用相同的key
+	h = http.Header{}
+	h.Set("Accept-Language", "zh-cn,en;q=0.8")
+	assert.Equal(key, getVaryKey("GET /", h, vary))
+}
+
+func TestCacheResponseVary(t *testing.T) {
+	assert := assert.New(t)
+
+	cp := &CacheResponse{
+		Status: StatusVary,
+		Vary: []string{
+			"Accept",
+			"Accept-Language",
+		},
+	}
+	assert.Equal(cp, NewCacheResponse(cp.Bytes()))
+
+	cp = &CacheResponse{
+		Status:     StatusHit,
+		StatusCode: 200,
+		Header: http.Header{
+			headerVary: []string{
+				"Accept",
+			},
+		},
+		Body: bytes.NewBufferString("abc"),
+	}
+	assert.Equal([]string{
+		"Accept",
+	}, NewCacheResponse(cp.Bytes()).Vary)
+}
+
+func TestCacheVary(t *testing.T) {
+	assert := assert.New(t)
+
+	store := NewLRUStore(10)
+	e := elton.New()
+	e.Use(NewCache(CacheConfig{
+		Store: store,
+	}))
+	e.GET("/", func(c *elton.Context) error {
+		c.CacheMaxAge(time.Minute)
+		c.SetHeader(headerVary, "Accept-Language")
+		c.BodyBuffer = bytes.NewBufferString(c.GetRequestHeader("Accept-Language"))
+		return nil
+	})
+	e.GET("/any", func(c *elton.Context) error {
+		c.CacheMaxAge(time.Minute)
+		c.SetHeader(headerVary, "*")
+		c.BodyBuffer = bytes.NewBufferString("any")
+		return nil
+	})
+
+	doRequest := func(url, lang string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", url, nil)
+		req.Header.Set("Accept-Language", lang)
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := doRequest("/", "en")
+	assert.Equal("fetch", resp.Header().Get(HeaderXCache))
+	assert.Equal("en", resp.Body.String())
+
+	resp = doRequest("/", "zh")
+	assert.Equal("fetch", resp.Header().Get(HeaderXCache))
+	assert.Equal("zh", resp.Body.String())
+
+	resp = doRequest("/", "EN")
+	assert.Equal("hit", resp.Header().Get(HeaderXCache))
+	assert.Equal("en", resp.Body.String())
+	assert.Equal("Accept-Language", resp.Header().Get(headerVary))
+
+	resp = doRequest("/", "zh")
+	assert.Equal("hit", resp.Header().Get(HeaderXCache))
+	assert.Equal("zh", resp.Body.String())
+
+	// Vary: * 不缓存
+	resp = doRequest("/any", "en")
+	assert.Equal("fetch", resp.Header().Get(HeaderXCache))
+	resp = doRequest("/any", "en")
+	assert.Equal("hit-for-pass", resp.Header().Get(HeaderXCache))
+
+	// 清除key时同时清除vary的缓存
+	purge := NewCachePurgeHandler(store)
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/?key=GET+/", nil))
+	assert.Nil(purge(c))
+	assert.Equal(`{"count":3}`, c.BodyBuffer.String())
+}
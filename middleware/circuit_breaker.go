@@ -0,0 +1,281 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/vicanso/hes"
+)
+
+// CircuitState the state of circuit
+type CircuitState int
+
+const (
+	// CircuitClosed the requests are allowed
+	CircuitClosed CircuitState = iota
+	// CircuitOpen the requests are rejected until the open timeout
+	CircuitOpen
+	// CircuitHalfOpen the trial requests are allowed
+	CircuitHalfOpen
+)
+
+const circuitBuckets = 10
+
+var (
+	// ErrCircuitOpen the circuit of target is open
+	ErrCircuitOpen = &hes.Error{
+		StatusCode: http.StatusServiceUnavailable,
+		Message:    "circuit breaker is open",
+		Category:   ErrProxyCategory,
+	}
+)
+
+type (
+	// CircuitBreakerConfig circuit breaker config
+	CircuitBreakerConfig struct {
+		// Window the duration of the rolling window for failure ratio, default is 10s
+		Window time.Duration
+		// MinRequests the min requests of window to open the circuit, default is 10
+		MinRequests int
+		// FailureRatio the failure ratio to open the circuit, default is 0.5
+		FailureRatio float64
+		// OpenTimeout the duration of open state before half-open, default is 30s
+		OpenTimeout time.Duration
+		// HalfOpenRequests the trial requests of half-open state, the circuit is
+		// closed if all of them succeed, default is 1
+		HalfOpenRequests int
+		// OnStateChange the hook of state change, it is called synchronously
+		OnStateChange func(target string, from, to CircuitState)
+	}
+	// CircuitBreaker the circuit breakers of targets, each target
+	// has its own circuit(closed, open and half-open).
+	CircuitBreaker struct {
+		config   CircuitBreakerConfig
+		mu       sync.Mutex
+		circuits map[string]*circuit
+	}
+	circuitBucket struct {
+		start    time.Time
+		requests int
+		failures int
+	}
+	circuit struct {
+		state CircuitState
+		// openedAt the time of open(or half-open) state
+		openedAt time.Time
+		buckets  [circuitBuckets]circuitBucket
+		// halfOpenInflight the trial requests in flight
+		halfOpenInflight int
+		// halfOpenSuccesses the succeed trial requests
+		halfOpenSuccesses int
+	}
+	circuitStateChange struct {
+		from CircuitState
+		to   CircuitState
+	}
+)
+
+// String returns the name of state
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// NewCircuitBreaker returns a new circuit breaker
+func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
+	if config.Window <= 0 {
+		config.Window = 10 * time.Second
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = 10
+	}
+	if config.FailureRatio <= 0 {
+		config.FailureRatio = 0.5
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = 30 * time.Second
+	}
+	if config.HalfOpenRequests <= 0 {
+		config.HalfOpenRequests = 1
+	}
+	return &CircuitBreaker{
+		config:   config,
+		circuits: make(map[string]*circuit),
+	}
+}
+
+func (cb *CircuitBreaker) getCircuit(target string) *circuit {
+	ci, ok := cb.circuits[target]
+	if !ok {
+		ci = &circuit{}
+		cb.circuits[target] = ci
+	}
+	return ci
+}
+
+func (cb *CircuitBreaker) emit(target string, change *circuitStateChange) {
+	if change != nil && cb.config.OnStateChange != nil {
+		cb.config.OnStateChange(target, change.from, change.to)
+	}
+}
+
+func (ci *circuit) setState(state CircuitState, now time.Time) *circuitStateChange {
+	if ci.state == state {
+		return nil
+	}
+	change := &circuitStateChange{
+		from: ci.state,
+		to:   state,
+	}
+	ci.state = state
+	ci.halfOpenInflight = 0
+	ci.halfOpenSuccesses = 0
+	switch state {
+	case CircuitOpen, CircuitHalfOpen:
+		ci.openedAt = now
+	case CircuitClosed:
+		ci.buckets = [circuitBuckets]circuitBucket{}
+	}
+	return change
+}
+
+// bucket returns the bucket of now, the expired bucket is reset
+func (ci *circuit) bucket(now time.Time, window time.Duration) *circuitBucket {
+	// window过小时bucket至少为1ns，避免除0
+	size := max(window/circuitBuckets, time.Nanosecond)
+	start := now.Truncate(size)
+	b := &ci.buckets[(start.UnixNano()/int64(size))%circuitBuckets]
+	if !b.start.Equal(start) {
+		*b = circuitBucket{
+			start: start,
+		}
+	}
+	return b
+}
+
+// counts returns the requests and failures of window
+func (ci *circuit) counts(now time.Time, window time.Duration) (int, int) {
+	requests := 0
+	failures := 0
+	for _, b := range ci.buckets {
+		if now.Sub(b.start) < window {
+			requests += b.requests
+			failures += b.failures
+		}
+	}
+	return requests, failures
+}
+
+// Allow returns ErrCircuitOpen if the circuit of target is open or the
+// trial requests of half-open are exhausted. Report should be called
+// with the result of request if it is allowed, the trial requests which
+// are not reported are released after the open timeout.
+func (cb *CircuitBreaker) Allow(target string) error {
+	now := time.Now()
+	cb.mu.Lock()
+	ci := cb.getCircuit(target)
+	var change *circuitStateChange
+	if ci.state == CircuitOpen && now.Sub(ci.openedAt) >= cb.config.OpenTimeout {
+		change = ci.setState(CircuitHalfOpen, now)
+	}
+	var err error
+	switch ci.state {
+	case CircuitOpen:
+		err = ErrCircuitOpen
+	case CircuitHalfOpen:
+		// 试探请求未Report(如panic)时，超时后释放，避免一直处于half-open
+		if ci.halfOpenInflight != 0 && now.Sub(ci.openedAt) >= cb.config.OpenTimeout {
+			ci.halfOpenInflight = 0
+			ci.openedAt = now
+		}
+		if ci.halfOpenInflight+ci.halfOpenSuccesses >= cb.config.HalfOpenRequests {
+			err = ErrCircuitOpen
+		} else {
+			ci.halfOpenInflight++
+		}
+	}
+	cb.mu.Unlock()
+	cb.emit(target, change)
+	return err
+}
+
+// Report reports the result of request which is allowed,
+// the state of circuit is changed by the result.
+func (cb *CircuitBreaker) Report(target string, success bool) {
+	now := time.Now()
+	cb.mu.Lock()
+	ci := cb.getCircuit(target)
+	var change *circuitStateChange
+	switch ci.state {
+	case CircuitHalfOpen:
+		if ci.halfOpenInflight > 0 {
+			ci.halfOpenInflight--
+		}
+		if !success {
+			// 试探请求失败，重新打开
+			change = ci.setState(CircuitOpen, now)
+			break
+		}
+		ci.halfOpenSuccesses++
+		if ci.halfOpenSuccesses >= cb.config.HalfOpenRequests {
+			change = ci.setState(CircuitClosed, now)
+		}
+	case CircuitClosed:
+		b := ci.bucket(now, cb.config.Window)
+		b.requests++
+		if !success {
+			b.failures++
+			requests, failures := ci.counts(now, cb.config.Window)
+			if requests >= cb.config.MinRequests &&
+				float64(failures)/float64(requests) >= cb.config.FailureRatio {
+				change = ci.setState(CircuitOpen, now)
+			}
+		}
+	}
+	cb.mu.Unlock()
+	cb.emit(target, change)
+}
+
+// State returns the state of target's circuit
+func (cb *CircuitBreaker) State(target string) CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	ci, ok := cb.circuits[target]
+	if !ok {
+		return CircuitClosed
+	}
+	// 超时后的open状态在下一次Allow时转为half-open
+	if ci.state == CircuitOpen && time.Since(ci.openedAt) >= cb.config.OpenTimeout {
+		return CircuitHalfOpen
+	}
+	return ci.state
+}
@@ -0,0 +1,146 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	assert := assert.New(t)
+
+	var changes []string
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		MinRequests:      4,
+		FailureRatio:     0.5,
+		OpenTimeout:      20 * time.Millisecond,
+		HalfOpenRequests: 2,
+		OnStateChange: func(target string, from, to CircuitState) {
+			changes = append(changes, target+":"+from.String()+"->"+to.String())
+		},
+	})
+	target := "http://127.0.0.1:3001"
+	assert.Equal(CircuitClosed, cb.State(target))
+
+	// 请求数未达到最小值不打开
+	for _, success := range []bool{false, true, false} {
+		assert.Nil(cb.Allow(target))
+		cb.Report(target, success)
+	}
+	assert.Equal(CircuitClosed, cb.State(target))
+	assert.Nil(cb.Allow(target))
+	cb.Report(target, false)
+	assert.Equal(CircuitOpen, cb.State(target))
+	assert.Equal(ErrCircuitOpen, cb.Allow(target))
+	// 其它target不受影响
+	assert.Nil(cb.Allow("http://127.0.0.1:3002"))
+
+	// half-open只允许指定数量的试探请求
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(CircuitHalfOpen, cb.State(target))
+	assert.Nil(cb.Allow(target))
+	assert.Nil(cb.Allow(target))
+	assert.Equal(ErrCircuitOpen, cb.Allow(target))
+	cb.Report(target, true)
+	// 试探请求失败则重新打开
+	cb.Report(target, false)
+	assert.Equal(CircuitOpen, cb.State(target))
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Nil(cb.Allow(target))
+	cb.Report(target, true)
+	assert.Equal(CircuitHalfOpen, cb.State(target))
+	assert.Nil(cb.Allow(target))
+	cb.Report(target, true)
+	assert.Equal(CircuitClosed, cb.State(target))
+
+	assert.Equal([]string{
+		target + ":closed->open",
+		target + ":open->half-open",
+		target + ":half-open->open",
+		target + ":open->half-open",
+		target + ":half-open->closed",
+	}, changes)
+}
+
+func TestCircuitBreakerWindow(t *testing.T) {
+	assert := assert.New(t)
+
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		Window:      50 * time.Millisecond,
+		MinRequests: 2,
+	})
+	target := "a"
+	assert.Nil(cb.Allow(target))
+	cb.Report(target, false)
+	// 窗口外的统计不计入
+	time.Sleep(60 * time.Millisecond)
+	for range 3 {
+		assert.Nil(cb.Allow(target))
+		cb.Report(target, true)
+	}
+	assert.Nil(cb.Allow(target))
+	cb.Report(target, false)
+	assert.Equal(CircuitClosed, cb.State(target))
+}
+
+func TestCircuitBreakerHalfOpenTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		MinRequests: 1,
+		OpenTimeout: 20 * time.Millisecond,
+	})
+	target := "a"
+	assert.Nil(cb.Allow(target))
+	cb.Report(target, false)
+	assert.Equal(CircuitOpen, cb.State(target))
+
+	time.Sleep(30 * time.Millisecond)
+	// 试探请求未Report
+	assert.Nil(cb.Allow(target))
+	assert.Equal(ErrCircuitOpen, cb.Allow(target))
+	// 超时后释放试探请求
+	time.Sleep(30 * time.Millisecond)
+	assert.Nil(cb.Allow(target))
+	cb.Report(target, true)
+	assert.Equal(CircuitClosed, cb.State(target))
+}
+
+func TestCircuitBreakerSmallWindow(t *testing.T) {
+	assert := assert.New(t)
+
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		Window:      time.Nanosecond,
+		MinRequests: 1,
+	})
+	target := "a"
+	assert.Nil(cb.Allow(target))
+	assert.NotPanics(func() {
+		cb.Report(target, true)
+	})
+	assert.Equal(CircuitClosed, cb.State(target))
+}
@@ -133,8 +133,9 @@ func NewCompress(config CompressConfig) elton.Handler {
 			return nil
 		}
 		contentType := c.GetHeader(elton.HeaderContentType)
-		// 数据类型为非可压缩，则返回
-		if !checker.MatchString(contentType) {
+		// 数据类型为非可压缩（event stream需要按事件实时输出，也不压缩），则返回
+		if strings.HasPrefix(contentType, elton.MIMETextEventStream) ||
+			!checker.MatchString(contentType) {
 			return nil
 		}
 		if config.OnBeforeCompress != nil {
@@ -287,6 +287,21 @@ func TestCompressMiddleware(t *testing.T) {
 			encoding: "gzip",
 			result:   htmlGzip.Bytes(),
 		},
+		// event stream
+		{
+			newContext: func() *elton.Context {
+				req := httptest.NewRequest("GET", "/users/me", nil)
+				req.Header.Set(elton.HeaderAcceptEncoding, "gzip")
+				resp := httptest.NewRecorder()
+				c := elton.NewContext(resp, req)
+				c.SetHeader(elton.HeaderContentType, elton.MIMETextEventStream)
+				c.BodyBuffer = bytes.NewBufferString(htmlData)
+				c.Next = next
+				return c
+			},
+			fn:     defaultCompress,
+			result: []byte(htmlData),
+		},
 	}
 	for _, tt := range tests {
 		c := tt.newContext()
@@ -111,16 +111,10 @@ type (
 	}
 )
 
-// NewConcurrentLimiter returns a new concurrent limiter middleware.
-// It will throw a panic if Lock function is nil.
-func NewConcurrentLimiter(config ConcurrentLimiterConfig) elton.Handler {
-
-	if config.Lock == nil {
-		panic(ErrRequireLockFunction)
-	}
-	keys := make([]*concurrentLimiterKeyInfo, 0)
-	// 根据配置生成key的处理
-	for _, key := range config.Keys {
+// parseLimiterKeys parses the key list of limiter(:ip, h:, q:, p: and body field)
+func parseLimiterKeys(keyList []string) []*concurrentLimiterKeyInfo {
+	keys := make([]*concurrentLimiterKeyInfo, 0, len(keyList))
+	for _, key := range keyList {
 		if key == ipKey {
 			keys = append(keys, &concurrentLimiterKeyInfo{
 				IP: true,
@@ -153,51 +147,83 @@ func NewConcurrentLimiter(config ConcurrentLimiterConfig) elton.Handler {
 			Body: true,
 		})
 	}
+	return keys
+}
+
+// limiterKeyBuilder builds the limiter key of request from the parsed keys
+type limiterKeyBuilder struct {
+	keys          []*concurrentLimiterKeyInfo
+	bodyValue     ConcurrentLimiterBodyValue
+	keyGenerator  KeyGenerator
+	notAllowEmpty bool
+}
+
+// Build returns the key of request, each value is joined by comma
+func (kb *limiterKeyBuilder) Build(c *elton.Context) (string, error) {
+	keyLength := len(kb.keys)
+	sb := new(strings.Builder)
+	// 先申请假定每个value的长度
+	sb.Grow(8 * keyLength)
+	for i, key := range kb.keys {
+		v := ""
+		name := key.Name
+		if key.IP {
+			v = c.RealIP()
+		} else if key.Header {
+			v = c.GetRequestHeader(name)
+		} else if key.Query {
+			v = c.QueryParam(name)
+		} else if key.Params {
+			v = c.Param(name)
+		} else if kb.bodyValue != nil {
+			v = kb.bodyValue(c, name)
+		}
+		if kb.notAllowEmpty && len(v) == 0 {
+			return "", ErrNotAllowEmpty
+		}
+		sb.WriteString(v)
+		if i < keyLength-1 {
+			sb.WriteByte(',')
+		}
+	}
+	// 如果有指定key的生成函数
+	if kb.keyGenerator != nil {
+		customKey, err := kb.keyGenerator(c)
+		if err != nil {
+			return "", err
+		}
+		if sb.Len() != 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(customKey)
+	}
+	return sb.String(), nil
+}
+
+// NewConcurrentLimiter returns a new concurrent limiter middleware.
+// It will throw a panic if Lock function is nil.
+func NewConcurrentLimiter(config ConcurrentLimiterConfig) elton.Handler {
+
+	if config.Lock == nil {
+		panic(ErrRequireLockFunction)
+	}
+	// 根据配置生成key的处理
+	keyBuilder := &limiterKeyBuilder{
+		keys:          parseLimiterKeys(config.Keys),
+		bodyValue:     config.BodyValue,
+		keyGenerator:  config.KeyGenerator,
+		notAllowEmpty: config.NotAllowEmpty,
+	}
 	skipper := getSkipper(config.Skipper)
-	bodyValue := config.BodyValue
-	keyLength := len(keys)
 	return func(c *elton.Context) error {
 		if skipper(c) {
 			return c.Next()
 		}
-		sb := new(strings.Builder)
-		// 先申请假定每个value的长度
-		sb.Grow(8 * keyLength)
 		// 获取 lock 的key
-		for i, key := range keys {
-			v := ""
-			name := key.Name
-			if key.IP {
-				v = c.RealIP()
-			} else if key.Header {
-				v = c.GetRequestHeader(name)
-			} else if key.Query {
-				v = c.QueryParam(name)
-			} else if key.Params {
-				v = c.Param(name)
-			} else if bodyValue != nil {
-				v = bodyValue(c, name)
-			}
-			if config.NotAllowEmpty && len(v) == 0 {
-				return ErrNotAllowEmpty
-			}
-			sb.WriteString(v)
-			if i < keyLength-1 {
-				sb.WriteByte(',')
-			}
-		}
-		// 如果有指定key的生成函数
-		if config.KeyGenerator != nil {
-			customKey, err := config.KeyGenerator(c)
-			if err != nil {
-				return err
-			}
-			if sb.Len() != 0 {
-				sb.WriteByte(',')
-			}
-			sb.WriteString(customKey)
-		}
-		lockKey := sb.String()
+		lockKey, err := keyBuilder.Build(c)
+		if err != nil {
+			return err
+		}
 
 		success, unlock, err := config.Lock(lockKey, c)
 		if err != nil {
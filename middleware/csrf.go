@@ -0,0 +1,218 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vicanso/elton/v2"
+	"github.com/vicanso/hes"
+)
+
+const (
+	// ErrCSRFCategory csrf error category
+	ErrCSRFCategory = "elton-csrf"
+	// HeaderXCSRFToken default csrf token header
+	HeaderXCSRFToken = "X-CSRF-Token"
+	// ContextKeyCSRFToken context store key for csrf token
+	ContextKeyCSRFToken = "csrfToken"
+	// defaultCSRFCookieName default cookie name of csrf token
+	defaultCSRFCookieName = "elton.csrf"
+	// defaultCSRFField default form(json) field of csrf token
+	defaultCSRFField = "_csrf"
+)
+
+var (
+	// ErrCSRFTokenMissing csrf token is missing
+	ErrCSRFTokenMissing = &hes.Error{
+		StatusCode: http.StatusForbidden,
+		Message:    "csrf token is missing",
+		Category:   ErrCSRFCategory,
+	}
+	// ErrCSRFTokenInvalid csrf token is invalid
+	ErrCSRFTokenInvalid = &hes.Error{
+		StatusCode: http.StatusForbidden,
+		Message:    "csrf token is invalid",
+		Category:   ErrCSRFCategory,
+	}
+)
+
+// CSRFConfig csrf config
+type CSRFConfig struct {
+	// CookieName the cookie name of token, default is "elton.csrf"
+	CookieName string
+	// CookiePath the cookie path, default is "/"
+	CookiePath string
+	// CookieDomain the cookie domain
+	CookieDomain string
+	// CookieMaxAge the max age of cookie, default is session cookie
+	CookieMaxAge time.Duration
+	// Secure the cookie secure
+	Secure bool
+	// SameSite the cookie same site, default is lax
+	SameSite http.SameSite
+	// HTTPOnly the cookie http only, it should be false if the
+	// token is read from cookie by javascript
+	HTTPOnly bool
+	// Header the request header of token, default is "X-CSRF-Token"
+	Header string
+	// Field the form or json field of token, default is "_csrf"
+	Field string
+	// ContextKey c.Set key; default "csrfToken"
+	ContextKey string
+	Skipper    elton.Skipper
+}
+
+// isSafeMethod returns whether the method is safe(no need to check csrf)
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+func generateCSRFToken() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b[:]), nil
+}
+
+// getCSRFTokenFromBody gets the token of field from request body,
+// the form data is converted to json by body parser.
+func getCSRFTokenFromBody(c *elton.Context, field string) string {
+	if len(c.RequestBody) != 0 {
+		var data map[string]json.RawMessage
+		if err := json.Unmarshal(c.RequestBody, &data); err != nil {
+			return ""
+		}
+		var token string
+		_ = json.Unmarshal(data[field], &token)
+		return token
+	}
+	// 未使用body parser时从form中获取
+	contentType := c.GetRequestHeader(elton.HeaderContentType)
+	if strings.HasPrefix(contentType, formURLEncodedContentType) ||
+		strings.HasPrefix(contentType, "multipart/form-data") {
+		return c.Request.PostFormValue(field)
+	}
+	return ""
+}
+
+// NewDefaultCSRF returns a new csrf middleware with default config
+func NewDefaultCSRF() elton.Handler {
+	return NewCSRF(CSRFConfig{})
+}
+
+// NewCSRF returns a new csrf middleware using double-submit token,
+// the token is saved in signed cookie(so the SignedKeys of elton should be set),
+// and should be submitted by header, form field or json field for unsafe methods.
+// The token is set to context, so it can be used in templates.
+// It should be used after body parser for getting token from body.
+func NewCSRF(config CSRFConfig) elton.Handler {
+	skipper := getSkipper(config.Skipper)
+	cookieName := config.CookieName
+	if cookieName == "" {
+		cookieName = defaultCSRFCookieName
+	}
+	cookiePath := config.CookiePath
+	if cookiePath == "" {
+		cookiePath = "/"
+	}
+	sameSite := config.SameSite
+	if sameSite == 0 {
+		sameSite = http.SameSiteLaxMode
+	}
+	header := config.Header
+	if header == "" {
+		header = HeaderXCSRFToken
+	}
+	field := config.Field
+	if field == "" {
+		field = defaultCSRFField
+	}
+	ctxKey := config.ContextKey
+	if ctxKey == "" {
+		ctxKey = ContextKeyCSRFToken
+	}
+	return func(c *elton.Context) error {
+		if skipper(c) {
+			return c.Next()
+		}
+		cookie, err := c.SignedCookie(cookieName)
+		if err != nil && err != http.ErrNoCookie {
+			return wrapAsHesError(err, ErrCSRFCategory)
+		}
+		token := ""
+		if cookie != nil {
+			token = cookie.Value
+		}
+
+		if !isSafeMethod(c.Request.Method) {
+			value := c.GetRequestHeader(header)
+			if value == "" {
+				value = getCSRFTokenFromBody(c, field)
+			}
+			if value == "" {
+				return ErrCSRFTokenMissing
+			}
+			if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(value)) != 1 {
+				return ErrCSRFTokenInvalid
+			}
+		}
+
+		// 无token时生成新的token
+		if token == "" {
+			token, err = generateCSRFToken()
+			if err != nil {
+				return wrapAsHesError(err, ErrCSRFCategory)
+			}
+			c.AddSignedCookie(&http.Cookie{
+				Name:     cookieName,
+				Value:    token,
+				Path:     cookiePath,
+				Domain:   config.CookieDomain,
+				MaxAge:   int(config.CookieMaxAge.Seconds()),
+				Secure:   config.Secure,
+				HttpOnly: config.HTTPOnly,
+				SameSite: sameSite,
+			})
+		}
+		c.Set(ctxKey, token)
+		return c.Next()
+	}
+}
+
+// GetCSRFToken returns the csrf token from context store (default key).
+func GetCSRFToken(c *elton.Context) string {
+	return elton.GetContextValue[string](c, ContextKeyCSRFToken)
+}
@@ -0,0 +1,218 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton/v2"
+)
+
+func newCSRFTestServer() *elton.Elton {
+	e := elton.New()
+	e.SignedKeys = &elton.SimpleSignedKeys{}
+	e.SignedKeys.SetKeys([]string{"secret"})
+	e.Use(NewDefaultError())
+	e.Use(NewBodyParser(BodyParserConfig{
+		ContentTypeValidate: DefaultJSONAndFormContentTypeValidate,
+		Decoders: []BodyDecoder{
+			NewJSONDecoder(),
+			NewFormURLEncodedDecoder(),
+		},
+	}))
+	e.Use(NewDefaultCSRF())
+	e.Use(NewDefaultResponder())
+	e.GET("/form", func(c *elton.Context) error {
+		c.Body = GetCSRFToken(c)
+		return nil
+	})
+	e.POST("/submit", func(c *elton.Context) error {
+		c.Body = "ok"
+		return nil
+	})
+	return e
+}
+
+func TestCSRF(t *testing.T) {
+	assert := assert.New(t)
+	e := newCSRFTestServer()
+
+	// issue token
+	req := httptest.NewRequest("GET", "/form", nil)
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	cookies := resp.Result().Cookies()
+	assert.Equal(2, len(cookies))
+	assert.Equal(defaultCSRFCookieName, cookies[0].Name)
+	assert.Equal(http.SameSiteLaxMode, cookies[0].SameSite)
+	token := cookies[0].Value
+	assert.NotEmpty(token)
+	assert.Equal(token, resp.Body.String())
+
+	// the token is reused
+	req = httptest.NewRequest("GET", "/form", nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Empty(resp.Result().Cookies())
+
+	tests := []struct {
+		name        string
+		contentType string
+		header      string
+		body        string
+		cookies     []*http.Cookie
+		status      int
+		result      string
+	}{
+		{
+			name:    "missing token",
+			cookies: cookies,
+			status:  403,
+			result:  "csrf token is missing",
+		},
+		{
+			name:    "header token",
+			header:  token,
+			cookies: cookies,
+			status:  200,
+			result:  "ok",
+		},
+		{
+			name:    "invalid header token",
+			header:  token + "a",
+			cookies: cookies,
+			status:  403,
+			result:  "csrf token is invalid",
+		},
+		{
+			name:   "without cookie",
+			header: token,
+			status: 403,
+			result: "csrf token is invalid",
+		},
+		{
+			name:   "tampered cookie",
+			header: "abc",
+			cookies: []*http.Cookie{
+				{
+					Name:  defaultCSRFCookieName,
+					Value: "abc",
+				},
+				cookies[1],
+			},
+			status: 403,
+			result: "csrf token is invalid",
+		},
+		{
+			name:        "form token",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "name=tree&_csrf=" + token,
+			cookies:     cookies,
+			status:      200,
+			result:      "ok",
+		},
+		{
+			name:        "json token",
+			contentType: "application/json",
+			body:        `{"_csrf":"` + token + `"}`,
+			cookies:     cookies,
+			status:      200,
+			result:      "ok",
+		},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/submit", strings.NewReader(tt.body))
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		if tt.header != "" {
+			req.Header.Set(HeaderXCSRFToken, tt.header)
+		}
+		for _, cookie := range tt.cookies {
+			req.AddCookie(cookie)
+		}
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(tt.status, resp.Code, tt.name)
+		assert.Contains(resp.Body.String(), tt.result, tt.name)
+	}
+}
+
+func TestCSRFRender(t *testing.T) {
+	assert := assert.New(t)
+	e := newCSRFTestServer()
+	e.Use(NewRenderer(RendererConfig{}))
+	e.GET("/render", func(c *elton.Context) error {
+		c.Body = &RenderData{
+			Text: `<input name="_csrf" value="{{.}}">`,
+			Data: GetCSRFToken(c),
+		}
+		return nil
+	})
+	req := httptest.NewRequest("GET", "/render", nil)
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	token := resp.Result().Cookies()[0].Value
+	assert.Equal(`<input name="_csrf" value="`+token+`">`, resp.Body.String())
+}
+
+func TestCSRFWithoutBodyParser(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.SignedKeys = &elton.SimpleSignedKeys{}
+	e.SignedKeys.SetKeys([]string{"secret"})
+	e.Use(NewDefaultCSRF())
+	e.Use(NewDefaultResponder())
+	e.GET("/", func(c *elton.Context) error {
+		c.Body = GetCSRFToken(c)
+		return nil
+	})
+	e.POST("/", func(c *elton.Context) error {
+		c.Body = "ok"
+		return nil
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	cookies := resp.Result().Cookies()
+	token := resp.Body.String()
+
+	req = httptest.NewRequest("POST", "/", strings.NewReader("_csrf="+token))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal("ok", resp.Body.String())
+}
@@ -24,6 +24,7 @@ package middleware
 
 import (
 	"bytes"
+	"encoding/json"
 	"strings"
 
 	"github.com/vicanso/elton/v2"
@@ -34,6 +35,12 @@ type (
 	ErrorConfig struct {
 		Skipper      elton.Skipper
 		ResponseType string
+		// Negotiator selects the encoder by Accept header,
+		// text is used if none is acceptable
+		Negotiator *Negotiator
+		// Problem responses the error as problem details(application/problem+json),
+		// ResponseType and Negotiator are ignored if it is set
+		Problem *ProblemConfig
 	}
 )
 
@@ -63,7 +70,22 @@ func NewError(config ErrorConfig) elton.Handler {
 		he := wrapAsHesError(err, ErrErrorCategory)
 		// 自定义 hes.Error 未设置 StatusCode 时兜底为 500
 		c.StatusCode = he.StatusOrInternal()
-		if config.ResponseType == "json" ||
+		if config.Problem != nil {
+			// 序列化失败时降级为 text 输出
+			if buf, e := json.Marshal(NewProblem(c, he, *config.Problem)); e == nil {
+				c.BodyBuffer = bytes.NewBuffer(buf)
+				c.SetHeader(elton.HeaderContentType, MIMEApplicationProblemJSON)
+				return nil
+			}
+		} else if negotiator := config.Negotiator; negotiator != nil {
+			addVary(c, headerAccept)
+			// 无可接受的encoder或序列化失败时降级为 text 输出
+			if buf, contentType, e := negotiator.Marshal(c.GetRequestHeader(headerAccept), he); e == nil {
+				c.BodyBuffer = bytes.NewBuffer(buf)
+				c.SetHeader(elton.HeaderContentType, contentType)
+				return nil
+			}
+		} else if config.ResponseType == "json" ||
 			strings.Contains(c.GetRequestHeader("Accept"), "application/json") {
 			// 序列化失败时降级为 text 输出
 			if buf, e := he.ToJSON(); e == nil {
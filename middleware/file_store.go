@@ -0,0 +1,124 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var (
+	_ CacheStore   = (*FileStore)(nil)
+	_ CacheDeleter = (*FileStore)(nil)
+)
+
+// FileStore is the cache store which saves the data as files of directory,
+// it's useful for local second tier cache or tests.
+// 文件名为key的sha256，文件内容布局为：[过期时间戳(4字节,大端,单位秒)][payload]
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a file store, the directory will be created when
+// the data is set
+func NewFileStore(dir string) *FileStore {
+	if dir == "" {
+		panic("require dir for file store")
+	}
+	return &FileStore{
+		dir: dir,
+	}
+}
+
+func (s *FileStore) getFile(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
+
+func (s *FileStore) Get(ctx context.Context, key string) ([]byte, error) {
+	file := s.getFile(key)
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(buf) < expiredByteSize {
+		return nil, nil
+	}
+	expired := binary.BigEndian.Uint32(buf)
+	if nowSeconds() > expired {
+		// 过期数据直接删除，忽略删除失败
+		_ = os.Remove(file)
+		return nil, nil
+	}
+	return buf[expiredByteSize:], nil
+}
+
+func (s *FileStore) Set(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	err := os.MkdirAll(s.dir, 0o755)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, len(data)+expiredByteSize)
+	expired := nowSeconds() + uint32(ttl/time.Second)
+	binary.BigEndian.PutUint32(buf, expired)
+	copy(buf[expiredByteSize:], data)
+
+	// 先写入临时文件再重命名，避免读取到不完整的数据
+	f, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(buf)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	err = os.Rename(f.Name(), s.getFile(key))
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// Delete removes the data of key from store
+func (s *FileStore) Delete(ctx context.Context, key string) error {
+	err := os.Remove(s.getFile(key))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
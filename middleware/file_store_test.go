@@ -0,0 +1,74 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Panics(func() {
+		NewFileStore("")
+	})
+
+	dir := filepath.Join(t.TempDir(), "cache")
+	store := NewFileStore(dir)
+	ctx := context.Background()
+
+	// 目录不存在
+	buf, err := store.Get(ctx, "GET /")
+	assert.Nil(err)
+	assert.Nil(buf)
+
+	assert.Nil(store.Set(ctx, "GET /", []byte("Hello world!"), time.Minute))
+	buf, err = store.Get(ctx, "GET /")
+	assert.Nil(err)
+	assert.Equal([]byte("Hello world!"), buf)
+	entries, _ := os.ReadDir(dir)
+	assert.Len(entries, 1)
+
+	assert.Nil(store.Delete(ctx, "GET /"))
+	buf, _ = store.Get(ctx, "GET /")
+	assert.Nil(buf)
+	// 删除不存在的数据
+	assert.Nil(store.Delete(ctx, "GET /"))
+
+	// 过期数据读取时删除
+	assert.Nil(store.Set(ctx, "GET /", []byte("a"), time.Minute))
+	file := store.getFile("GET /")
+	data, _ := os.ReadFile(file)
+	data[0], data[1], data[2], data[3] = 0, 0, 0, 1
+	_ = os.WriteFile(file, data, 0o600)
+	buf, _ = store.Get(ctx, "GET /")
+	assert.Nil(buf)
+	_, err = os.Stat(file)
+	assert.True(os.IsNotExist(err))
+}
@@ -0,0 +1,465 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vicanso/elton/v2"
+	"github.com/vicanso/hes"
+)
+
+const (
+	// ErrJWTCategory jwt error category
+	ErrJWTCategory = "elton-jwt"
+	// ContextKeyJWTClaims context store key for jwt claims
+	ContextKeyJWTClaims = "jwtClaims"
+	// JWTAlgHS256 HMAC using SHA-256
+	JWTAlgHS256 = "HS256"
+	// JWTAlgRS256 RSASSA-PKCS1-v1_5 using SHA-256
+	JWTAlgRS256 = "RS256"
+	// JWTAlgES256 ECDSA using P-256 and SHA-256
+	JWTAlgES256 = "ES256"
+
+	cookieKey    = "c:"
+	bearerPrefix = "bearer "
+)
+
+var (
+	ErrJWTRequireKeyProvider = errors.New("require key provider for jwt")
+	// ErrJWTMissing jwt is missing
+	ErrJWTMissing = newJWTError("token is missing")
+	// ErrJWTMalformed jwt is malformed
+	ErrJWTMalformed = newJWTError("token is malformed")
+	// ErrJWTUnsupportedAlg the algorithm of jwt is not supported
+	ErrJWTUnsupportedAlg = newJWTError("token algorithm is not supported")
+	// ErrJWTKeyNotFound the key of jwt is not found
+	ErrJWTKeyNotFound = newJWTError("token key is not found")
+	// ErrJWTInvalidSignature the signature of jwt is invalid
+	ErrJWTInvalidSignature = newJWTError("token signature is invalid")
+	// ErrJWTExpired jwt is expired
+	ErrJWTExpired = newJWTError("token is expired")
+	// ErrJWTMissingExpiration the exp claim of jwt is missing
+	ErrJWTMissingExpiration = newJWTError("token expiration is missing")
+	// ErrJWTNotValidYet jwt is not valid yet
+	ErrJWTNotValidYet = newJWTError("token is not valid yet")
+	// ErrJWTInvalidIssuer the issuer of jwt is invalid
+	ErrJWTInvalidIssuer = newJWTError("token issuer is invalid")
+	// ErrJWTInvalidAudience the audience of jwt is invalid
+	ErrJWTInvalidAudience = newJWTError("token audience is invalid")
+)
+
+func newJWTError(message string) *hes.Error {
+	return &hes.Error{
+		StatusCode: http.StatusUnauthorized,
+		Message:    message,
+		Category:   ErrJWTCategory,
+	}
+}
+
+type (
+	// JWTKeyProvider returns the key for verifying the signature of jwt.
+	// The key should be []byte for HS256, *rsa.PublicKey for RS256
+	// and *ecdsa.PublicKey for ES256.
+	JWTKeyProvider interface {
+		GetKey(ctx context.Context, kid, alg string) (any, error)
+	}
+	// JWTKeyProviderFunc the function adapter of key provider
+	JWTKeyProviderFunc func(ctx context.Context, kid, alg string) (any, error)
+	// JWTKeySet the key set for verifying jwt, keys can be added
+	// or removed at runtime for rotation
+	JWTKeySet struct {
+		mu   sync.RWMutex
+		keys map[string]any
+	}
+	// JWTClaims the claims of jwt
+	JWTClaims struct {
+		// Issuer iss claim
+		Issuer string
+		// Subject sub claim
+		Subject string
+		// Audience aud claim
+		Audience []string
+		// ID jti claim
+		ID string
+		// ExpiresAt exp claim, zero if not set
+		ExpiresAt time.Time
+		// NotBefore nbf claim, zero if not set
+		NotBefore time.Time
+		// IssuedAt iat claim, zero if not set
+		IssuedAt time.Time
+		// Raw all claims of jwt
+		Raw map[string]any
+	}
+	// JWTConfig jwt config
+	JWTConfig struct {
+		// KeyProvider key provider
+		KeyProvider JWTKeyProvider
+		// Algorithms allowed algorithms, default is HS256, RS256 and ES256
+		Algorithms []string
+		// TokenLookup 获取token的位置，按顺序查找，语法如下：
+		//   - "h:name" : 请求头name的值，若有Bearer前缀则去除
+		//   - "c:name" : cookie name的值
+		//   - "q:name" : query参数name的值
+		// 默认为 "h:Authorization"
+		TokenLookup []string
+		// Issuer the expected issuer, it won't be checked if empty
+		Issuer string
+		// Audience the expected audience, the token should contain one of them,
+		// it won't be checked if empty
+		Audience []string
+		// Leeway the leeway for validating exp and nbf
+		Leeway time.Duration
+		// RequireExpiration the exp claim is required
+		RequireExpiration bool
+		// Validate custom validate function for claims, the error
+		// which is not hes.Error will be converted to 401
+		Validate func(*elton.Context, *JWTClaims) error
+		// Optional pass the request if token is missing
+		Optional bool
+		// ContextKey c.Set key; default "jwtClaims"
+		ContextKey string
+		Skipper    elton.Skipper
+	}
+	// jwtHeader the header of jwt
+	jwtHeader struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+		Typ string `json:"typ"`
+	}
+)
+
+// GetKey returns the key of kid
+func (fn JWTKeyProviderFunc) GetKey(ctx context.Context, kid, alg string) (any, error) {
+	return fn(ctx, kid, alg)
+}
+
+// NewJWTKeySet returns a new key set
+func NewJWTKeySet() *JWTKeySet {
+	return &JWTKeySet{
+		keys: make(map[string]any),
+	}
+}
+
+// Add adds the key of kid, the empty kid is used for the token without kid
+func (ks *JWTKeySet) Add(kid string, key any) *JWTKeySet {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys[kid] = key
+	return ks
+}
+
+// Remove removes the key of kid
+func (ks *JWTKeySet) Remove(kid string) *JWTKeySet {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	delete(ks.keys, kid)
+	return ks
+}
+
+// GetKey returns the key of kid, nil will be returned if not exists
+func (ks *JWTKeySet) GetKey(_ context.Context, kid, _ string) (any, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.keys[kid], nil
+}
+
+// HasAudience returns whether the audience of claims contains one of audiences
+func (claims *JWTClaims) HasAudience(audiences ...string) bool {
+	for _, aud := range audiences {
+		if slices.Contains(claims.Audience, aud) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the string value of claim
+func (claims *JWTClaims) String(key string) string {
+	v, _ := claims.Raw[key].(string)
+	return v
+}
+
+func jwtNumericDate(value any) (time.Time, bool) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	sec, dec := math.Modf(f)
+	return time.Unix(int64(sec), int64(dec*1e9)), true
+}
+
+// parseJWTClaims parses the claims from payload
+func parseJWTClaims(payload []byte) (*JWTClaims, error) {
+	raw := make(map[string]any)
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, ErrJWTMalformed
+	}
+	claims := &JWTClaims{
+		Raw: raw,
+	}
+	claims.Issuer, _ = raw["iss"].(string)
+	claims.Subject, _ = raw["sub"].(string)
+	claims.ID, _ = raw["jti"].(string)
+	// aud 可以为字符串或字符串数组
+	switch aud := raw["aud"].(type) {
+	case string:
+		claims.Audience = []string{aud}
+	case []any:
+		for _, item := range aud {
+			if v, ok := item.(string); ok {
+				claims.Audience = append(claims.Audience, v)
+			}
+		}
+	}
+	for key, field := range map[string]*time.Time{
+		"exp": &claims.ExpiresAt,
+		"nbf": &claims.NotBefore,
+		"iat": &claims.IssuedAt,
+	} {
+		value, exists := raw[key]
+		if !exists {
+			continue
+		}
+		t, ok := jwtNumericDate(value)
+		if !ok {
+			return nil, ErrJWTMalformed
+		}
+		*field = t
+	}
+	return claims, nil
+}
+
+// verifyJWTSignature verifies the signature of signing input
+func verifyJWTSignature(alg string, key any, signingInput, signature []byte) error {
+	switch alg {
+	case JWTAlgHS256:
+		secret, ok := key.([]byte)
+		if !ok {
+			return ErrJWTKeyNotFound
+		}
+		mac := hmac.New(sha256.New, secret)
+		_, _ = mac.Write(signingInput)
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return ErrJWTInvalidSignature
+		}
+	case JWTAlgRS256:
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrJWTKeyNotFound
+		}
+		hash := sha256.Sum256(signingInput)
+		if rsa.VerifyPKCS1v15(pub, crypto.SHA256, hash[:], signature) != nil {
+			return ErrJWTInvalidSignature
+		}
+	case JWTAlgES256:
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || pub.Curve != elliptic.P256() {
+			return ErrJWTKeyNotFound
+		}
+		// ES256签名为 r||s，各32字节
+		if len(signature) != 64 {
+			return ErrJWTInvalidSignature
+		}
+		hash := sha256.Sum256(signingInput)
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(pub, hash[:], r, s) {
+			return ErrJWTInvalidSignature
+		}
+	default:
+		return ErrJWTUnsupportedAlg
+	}
+	return nil
+}
+
+func lookupJWT(c *elton.Context, lookups []string) string {
+	for _, lookup := range lookups {
+		token := ""
+		if name, ok := strings.CutPrefix(lookup, headerKey); ok {
+			token = c.GetRequestHeader(name)
+			if len(token) > len(bearerPrefix) && strings.EqualFold(token[:len(bearerPrefix)], bearerPrefix) {
+				token = token[len(bearerPrefix):]
+			}
+		} else if name, ok := strings.CutPrefix(lookup, cookieKey); ok {
+			if cookie, err := c.Cookie(name); err == nil {
+				token = cookie.Value
+			}
+		} else if name, ok := strings.CutPrefix(lookup, queryKey); ok {
+			token = c.QueryParam(name)
+		}
+		token = strings.TrimSpace(token)
+		if token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
+// NewJWTAuth returns a new jwt auth middleware, it verifies the signature
+// and validates the claims of token, then sets the claims to context.
+// It will throw a panic if KeyProvider is nil.
+func NewJWTAuth(config JWTConfig) elton.Handler {
+	if config.KeyProvider == nil {
+		panic(ErrJWTRequireKeyProvider)
+	}
+	skipper := getSkipper(config.Skipper)
+	algorithms := config.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = []string{
+			JWTAlgHS256,
+			JWTAlgRS256,
+			JWTAlgES256,
+		}
+	}
+	lookups := config.TokenLookup
+	if len(lookups) == 0 {
+		lookups = []string{
+			headerKey + elton.HeaderAuthorization,
+		}
+	}
+	ctxKey := config.ContextKey
+	if ctxKey == "" {
+		ctxKey = ContextKeyJWTClaims
+	}
+	leeway := config.Leeway
+	wwwAuthenticate := `Bearer error="invalid_token"`
+
+	verify := func(c *elton.Context, token string) (*JWTClaims, error) {
+		parts := strings.Split(token, ".")
+		if len(parts) != 3 {
+			return nil, ErrJWTMalformed
+		}
+		headerBuf, err := base64.RawURLEncoding.DecodeString(parts[0])
+		if err != nil {
+			return nil, ErrJWTMalformed
+		}
+		header := jwtHeader{}
+		if err := json.Unmarshal(headerBuf, &header); err != nil {
+			return nil, ErrJWTMalformed
+		}
+		if !slices.Contains(algorithms, header.Alg) {
+			return nil, ErrJWTUnsupportedAlg
+		}
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			return nil, ErrJWTMalformed
+		}
+		key, err := config.KeyProvider.GetKey(c.Context(), header.Kid, header.Alg)
+		if err != nil {
+			return nil, wrapAsHesError(err, ErrJWTCategory)
+		}
+		if key == nil {
+			return nil, ErrJWTKeyNotFound
+		}
+		signingInput := token[:len(parts[0])+1+len(parts[1])]
+		if err := verifyJWTSignature(header.Alg, key, []byte(signingInput), signature); err != nil {
+			return nil, err
+		}
+		payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, ErrJWTMalformed
+		}
+		claims, err := parseJWTClaims(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		if claims.ExpiresAt.IsZero() {
+			if config.RequireExpiration {
+				return nil, ErrJWTMissingExpiration
+			}
+		} else if now.After(claims.ExpiresAt.Add(leeway)) {
+			return nil, ErrJWTExpired
+		}
+		if !claims.NotBefore.IsZero() && now.Add(leeway).Before(claims.NotBefore) {
+			return nil, ErrJWTNotValidYet
+		}
+		if config.Issuer != "" && claims.Issuer != config.Issuer {
+			return nil, ErrJWTInvalidIssuer
+		}
+		if len(config.Audience) != 0 && !claims.HasAudience(config.Audience...) {
+			return nil, ErrJWTInvalidAudience
+		}
+		return claims, nil
+	}
+
+	return func(c *elton.Context) error {
+		if skipper(c) || c.Request.Method == http.MethodOptions {
+			return c.Next()
+		}
+		token := lookupJWT(c, lookups)
+		if token == "" {
+			if config.Optional {
+				return c.Next()
+			}
+			c.SetHeader(elton.HeaderWWWAuthenticate, "Bearer")
+			return ErrJWTMissing
+		}
+		claims, err := verify(c, token)
+		if err != nil {
+			c.SetHeader(elton.HeaderWWWAuthenticate, wwwAuthenticate)
+			return err
+		}
+		if config.Validate != nil {
+			if err := config.Validate(c, claims); err != nil {
+				// 非hes.Error的校验出错均认为是401
+				if he, ok := hes.As(err); ok {
+					return he
+				}
+				return newJWTError(err.Error())
+			}
+		}
+		c.Set(ctxKey, claims)
+		return c.Next()
+	}
+}
+
+// GetJWTClaims returns the jwt claims from context store (default key).
+func GetJWTClaims(c *elton.Context) *JWTClaims {
+	return elton.GetContextValue[*JWTClaims](c, ContextKeyJWTClaims)
+}
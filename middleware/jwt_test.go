@@ -0,0 +1,368 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton/v2"
+)
+
+func signJWT(alg, kid string, key any, claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{
+		"alg": alg,
+		"kid": kid,
+		"typ": "JWT",
+	})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+	hash := sha256.Sum256([]byte(signingInput))
+	var signature []byte
+	switch alg {
+	case JWTAlgHS256:
+		mac := hmac.New(sha256.New, key.([]byte))
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	case JWTAlgRS256:
+		signature, _ = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, hash[:])
+	case JWTAlgES256:
+		r, s, _ := ecdsa.Sign(rand.Reader, key.(*ecdsa.PrivateKey), hash[:])
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestNewJWTAuthPanic(t *testing.T) {
+	assert := assert.New(t)
+	assert.PanicsWithValue(ErrJWTRequireKeyProvider, func() {
+		NewJWTAuth(JWTConfig{})
+	})
+}
+
+func TestJWTAuth(t *testing.T) {
+	assert := assert.New(t)
+	secret := []byte("secret")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(err)
+
+	keySet := NewJWTKeySet().
+		Add("hs", secret).
+		Add("rs", &rsaKey.PublicKey).
+		Add("es", &ecKey.PublicKey)
+
+	e := elton.New()
+	e.Use(NewJWTAuth(JWTConfig{
+		KeyProvider: keySet,
+		TokenLookup: []string{
+			"h:Authorization",
+			"c:jwt",
+			"q:token",
+		},
+		Issuer:   "elton",
+		Audience: []string{"api"},
+		Leeway:   10 * time.Second,
+	}))
+	e.GET("/", func(c *elton.Context) error {
+		claims := elton.GetContextValue[*JWTClaims](c, ContextKeyJWTClaims)
+		c.BodyBuffer = bytes.NewBufferString(claims.Subject)
+		return nil
+	})
+
+	now := time.Now().Unix()
+	newClaims := func() map[string]any {
+		return map[string]any{
+			"iss": "elton",
+			"sub": "tree",
+			"aud": []string{"web", "api"},
+			"exp": now + 60,
+			"nbf": now - 60,
+		}
+	}
+	withClaims := func(fn func(map[string]any)) map[string]any {
+		claims := newClaims()
+		fn(claims)
+		return claims
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		cookie bool
+		query  bool
+		status int
+		result string
+	}{
+		{
+			name:   "missing",
+			status: 401,
+			result: "token is missing",
+		},
+		{
+			name:   "hs256",
+			token:  signJWT(JWTAlgHS256, "hs", secret, newClaims()),
+			status: 200,
+			result: "tree",
+		},
+		{
+			name:   "rs256",
+			token:  signJWT(JWTAlgRS256, "rs", rsaKey, newClaims()),
+			status: 200,
+			result: "tree",
+		},
+		{
+			name:   "es256 from cookie",
+			token:  signJWT(JWTAlgES256, "es", ecKey, newClaims()),
+			cookie: true,
+			status: 200,
+			result: "tree",
+		},
+		{
+			name:   "hs256 from query",
+			token:  signJWT(JWTAlgHS256, "hs", secret, newClaims()),
+			query:  true,
+			status: 200,
+			result: "tree",
+		},
+		{
+			name:   "malformed",
+			token:  "abc.def",
+			status: 401,
+			result: "token is malformed",
+		},
+		{
+			name:   "none algorithm",
+			token:  signJWT("none", "hs", secret, newClaims()),
+			status: 401,
+			result: "token algorithm is not supported",
+		},
+		{
+			name:   "unknown kid",
+			token:  signJWT(JWTAlgHS256, "unknown", secret, newClaims()),
+			status: 401,
+			result: "token key is not found",
+		},
+		{
+			name:   "key type mismatch",
+			token:  signJWT(JWTAlgHS256, "rs", secret, newClaims()),
+			status: 401,
+			result: "token key is not found",
+		},
+		{
+			name:   "invalid signature",
+			token:  signJWT(JWTAlgHS256, "hs", []byte("abc"), newClaims()),
+			status: 401,
+			result: "token signature is invalid",
+		},
+		{
+			name: "expired",
+			token: signJWT(JWTAlgHS256, "hs", secret, withClaims(func(m map[string]any) {
+				m["exp"] = now - 60
+			})),
+			status: 401,
+			result: "token is expired",
+		},
+		{
+			name: "expired within leeway",
+			token: signJWT(JWTAlgHS256, "hs", secret, withClaims(func(m map[string]any) {
+				m["exp"] = now - 5
+			})),
+			status: 200,
+			result: "tree",
+		},
+		{
+			name: "not valid yet",
+			token: signJWT(JWTAlgHS256, "hs", secret, withClaims(func(m map[string]any) {
+				m["nbf"] = now + 60
+			})),
+			status: 401,
+			result: "token is not valid yet",
+		},
+		{
+			name: "invalid issuer",
+			token: signJWT(JWTAlgHS256, "hs", secret, withClaims(func(m map[string]any) {
+				m["iss"] = "abc"
+			})),
+			status: 401,
+			result: "token issuer is invalid",
+		},
+		{
+			name: "invalid audience",
+			token: signJWT(JWTAlgHS256, "hs", secret, withClaims(func(m map[string]any) {
+				m["aud"] = "web"
+			})),
+			status: 401,
+			result: "token audience is invalid",
+		},
+	}
+	for _, tt := range tests {
+		target := "/"
+		if tt.query {
+			target += "?token=" + tt.token
+		}
+		req := httptest.NewRequest("GET", target, nil)
+		if tt.cookie {
+			req.AddCookie(&http.Cookie{
+				Name:  "jwt",
+				Value: tt.token,
+			})
+		} else if !tt.query && tt.token != "" {
+			req.Header.Set(elton.HeaderAuthorization, "Bearer "+tt.token)
+		}
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(tt.status, resp.Code, tt.name)
+		assert.Contains(resp.Body.String(), tt.result, tt.name)
+		if tt.status == 401 {
+			assert.NotEmpty(resp.Header().Get(elton.HeaderWWWAuthenticate), tt.name)
+		}
+	}
+
+	// rotate key
+	keySet.Remove("hs")
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(elton.HeaderAuthorization, "Bearer "+signJWT(JWTAlgHS256, "hs", secret, newClaims()))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(401, resp.Code)
+}
+
+func TestJWTAuthOptions(t *testing.T) {
+	assert := assert.New(t)
+	secret := []byte("secret")
+	e := elton.New()
+	e.Use(NewJWTAuth(JWTConfig{
+		KeyProvider: JWTKeyProviderFunc(func(_ context.Context, kid, alg string) (any, error) {
+			return secret, nil
+		}),
+		Algorithms:        []string{JWTAlgHS256},
+		RequireExpiration: true,
+		Optional:          true,
+		Validate: func(_ *elton.Context, claims *JWTClaims) error {
+			if claims.String("role") != "admin" {
+				return errors.New("role is not allowed")
+			}
+			return nil
+		},
+	}))
+	e.GET("/", func(c *elton.Context) error {
+		claims := GetJWTClaims(c)
+		if claims == nil {
+			c.BodyBuffer = bytes.NewBufferString("anonymous")
+			return nil
+		}
+		c.BodyBuffer = bytes.NewBufferString(claims.Subject)
+		return nil
+	})
+	exp := time.Now().Unix() + 60
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+		result string
+	}{
+		{
+			name:   "optional",
+			status: 200,
+			result: "anonymous",
+		},
+		{
+			name: "require expiration",
+			token: signJWT(JWTAlgHS256, "", secret, map[string]any{
+				"sub":  "tree",
+				"role": "admin",
+			}),
+			status: 401,
+			result: "token expiration is missing",
+		},
+		{
+			name: "custom validate",
+			token: signJWT(JWTAlgHS256, "", secret, map[string]any{
+				"sub":  "tree",
+				"role": "user",
+				"exp":  exp,
+			}),
+			status: 401,
+			result: "role is not allowed",
+		},
+		{
+			name: "success",
+			token: signJWT(JWTAlgHS256, "", secret, map[string]any{
+				"sub":  "tree",
+				"role": "admin",
+				"exp":  exp,
+			}),
+			status: 200,
+			result: "tree",
+		},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		if tt.token != "" {
+			req.Header.Set(elton.HeaderAuthorization, "bearer "+tt.token)
+		}
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(tt.status, resp.Code, tt.name)
+		assert.Contains(resp.Body.String(), tt.result, tt.name)
+	}
+}
+
+func TestParseJWTClaims(t *testing.T) {
+	assert := assert.New(t)
+	claims, err := parseJWTClaims([]byte(`{"iss":"elton","aud":"api","exp":1700000000.5,"iat":1700000000,"jti":"1"}`))
+	assert.Nil(err)
+	assert.Equal("elton", claims.Issuer)
+	assert.Equal([]string{"api"}, claims.Audience)
+	assert.Equal("1", claims.ID)
+	assert.Equal(int64(1700000000), claims.ExpiresAt.Unix())
+	assert.Equal(500*time.Millisecond, time.Duration(claims.ExpiresAt.Nanosecond()))
+	assert.Equal(int64(1700000000), claims.IssuedAt.Unix())
+	assert.True(claims.NotBefore.IsZero())
+
+	_, err = parseJWTClaims([]byte(`{"exp":"abc"}`))
+	assert.Equal(ErrJWTMalformed, err)
+	_, err = parseJWTClaims([]byte(`abc`))
+	assert.Equal(ErrJWTMalformed, err)
+}
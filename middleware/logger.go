@@ -65,6 +65,7 @@ const (
 	requestHeader    = "requestHeader"
 	responseHeader   = "responseHeader"
 	ctx              = "context"
+	route            = "route"
 	httpProto        = "HTTP"
 	httpsProto       = "HTTPS"
 	// get from env
@@ -81,6 +82,8 @@ const (
 	LoggerShort = `{remote} {method} {uri} {proto} {status} {size-human} - {latency-ms} ms`
 	// LoggerTiny tiny log format
 	LoggerTiny = `{method} {uri} {status} {size-human} - {latency-ms} ms`
+	// LoggerStructured structured log format, it is used by NewStructuredLogger
+	LoggerStructured = `{method} {route} {uri} {status} {size} {latency} {client-ip} {userAgent}`
 )
 
 type (
@@ -88,6 +91,8 @@ type (
 	LoggerTag struct {
 		category string
 		data     string
+		// name the name of env tag
+		name string
 	}
 	// OnLog on log function
 	OnLog func(string, *elton.Context)
@@ -147,7 +152,7 @@ func getTimeConsuming(startedAt time.Time) int {
 //   - :name  : context store中的值（c.Set保存的字符串），如 {:userId}
 //   - $name  : 环境变量的值，如 {$HOSTNAME}
 //   - 其它   : 内置tag，全集见 getLoggerTagValue：
-//     host method path proto query remote real-ip client-ip scheme uri
+//     host method path route proto query remote real-ip client-ip scheme uri
 //     referer userAgent when when-iso when-utc-iso when-unix when-iso-ms
 //     when-utc-iso-ms size size-human status latency latency-ms cookie
 //     payload-size payload-size-human
@@ -196,6 +201,7 @@ func parseLoggerTags(desc []byte) []*LoggerTag {
 			arr = append(arr, &LoggerTag{
 				category: env,
 				data:     os.Getenv(string(k[1:])),
+				name:     byteSliceToString(k[1:]),
 			})
 		default:
 			arr = append(arr, &LoggerTag{
@@ -221,6 +227,8 @@ func getLoggerTagValue(c *elton.Context, tag *LoggerTag, startedAt time.Time) st
 		return c.Request.Host
 	case method:
 		return c.Request.Method
+	case route:
+		return c.Route
 	case path:
 		p := c.Request.URL.Path
 		if p == "" {
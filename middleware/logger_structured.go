@@ -0,0 +1,252 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/vicanso/elton/v2"
+)
+
+const redactedValue = "***"
+
+var (
+	defaultLoggerMaskFields = regexp.MustCompile(`(?i)password|token|secret|authorization|cookie`)
+	// ErrStructuredLoggerRequireFormat the format of structured logger is empty
+	ErrStructuredLoggerRequireFormat = errors.New("structured logger require format")
+)
+
+type (
+	// StructuredLoggerConfig structured logger config
+	StructuredLoggerConfig struct {
+		// Format the tag set of log, the syntax is the same as LoggerConfig,
+		// the fill text between tags is ignored. e.g. LoggerStructured
+		Format string
+		// Logger the slog logger, default is slog.Default()
+		Logger *slog.Logger
+		// Message the message of log, default is "access"
+		Message string
+		// Level returns the level of log, default is error for
+		// the error or status >= 500, otherwise info.
+		Level func(c *elton.Context, err error) slog.Level
+		// Mask the value is redacted if the field name matches, it is used for
+		// the name of request/response header, cookie, context and query.
+		// Default is password|token|secret|authorization|cookie(case insensitive).
+		Mask    *regexp.Regexp
+		Skipper elton.Skipper
+	}
+	// structuredLoggerGroup the attrs of the same category, e.g. requestHeader
+	structuredLoggerGroup struct {
+		name  string
+		attrs []any
+	}
+)
+
+func redactRawQuery(rawQuery string, mask *regexp.Regexp) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+	redacted := false
+	for k, v := range values {
+		if mask.MatchString(k) {
+			for i := range v {
+				v[i] = redactedValue
+			}
+			redacted = true
+		}
+	}
+	// 无需脱敏则保留原始的query
+	if !redacted {
+		return rawQuery
+	}
+	return values.Encode()
+}
+
+func redactURI(uri string, mask *regexp.Regexp) string {
+	p, rawQuery, ok := strings.Cut(uri, "?")
+	if !ok {
+		return uri
+	}
+	return p + "?" + redactRawQuery(rawQuery, mask)
+}
+
+func getStatusCode(c *elton.Context) int {
+	if c.StatusCode == 0 {
+		return http.StatusOK
+	}
+	return c.StatusCode
+}
+
+// getLoggerTagAttr returns the slog attr of logger tag, the value keeps
+// its type(e.g. status is int). It returns false if the tag should be ignored.
+func getLoggerTagAttr(c *elton.Context, tag *LoggerTag, startedAt time.Time, mask *regexp.Regexp) (slog.Attr, bool) {
+	switch tag.category {
+	case "fill":
+		return slog.Attr{}, false
+	case status:
+		return slog.Int(tag.category, getStatusCode(c)), true
+	case size:
+		// reader类型的响应数据长度未知
+		if c.IsReaderBody() {
+			return slog.Attr{}, false
+		}
+		bodySize := 0
+		if c.BodyBuffer != nil {
+			bodySize = c.BodyBuffer.Len()
+		}
+		return slog.Int(tag.category, bodySize), true
+	case payloadSize:
+		return slog.Int(tag.category, len(c.RequestBody)), true
+	case latency:
+		return slog.Duration(tag.category, time.Since(startedAt)), true
+	case latencyMs:
+		return slog.Int(tag.category, getTimeConsuming(startedAt)), true
+	case whenUnix:
+		return slog.Int64(tag.category, time.Now().Unix()), true
+	case query:
+		return slog.String(tag.category, redactRawQuery(c.Request.URL.RawQuery, mask)), true
+	case uri:
+		return slog.String(tag.category, redactURI(c.Request.RequestURI, mask)), true
+	case ctx:
+		value, ok := c.Get(tag.data)
+		if !ok {
+			return slog.Attr{}, false
+		}
+		if mask.MatchString(tag.data) {
+			value = redactedValue
+		}
+		return slog.Any(tag.data, value), true
+	case env:
+		return slog.String(tag.name, tag.data), true
+	case cookie, requestHeader, responseHeader:
+		if mask.MatchString(tag.data) {
+			return slog.String(tag.data, redactedValue), true
+		}
+		return slog.String(tag.data, getLoggerTagValue(c, tag, startedAt)), true
+	default:
+		return slog.String(tag.category, getLoggerTagValue(c, tag, startedAt)), true
+	}
+}
+
+// isGroupLoggerTag returns true if the values of tag are grouped by category
+func isGroupLoggerTag(tag *LoggerTag) bool {
+	switch tag.category {
+	case cookie, requestHeader, responseHeader, ctx, env:
+		return true
+	}
+	return false
+}
+
+// getLoggerAttrs returns the slog attrs of logger tags, the cookie, header, context
+// and env tags are grouped by category, e.g. {>X-Request-Id} is requestHeader.X-Request-Id.
+func getLoggerAttrs(c *elton.Context, tags []*LoggerTag, startedAt time.Time, mask *regexp.Regexp) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(tags))
+	var groups []*structuredLoggerGroup
+	for _, tag := range tags {
+		attr, ok := getLoggerTagAttr(c, tag, startedAt, mask)
+		if !ok {
+			continue
+		}
+		if !isGroupLoggerTag(tag) {
+			attrs = append(attrs, attr)
+			continue
+		}
+		var group *structuredLoggerGroup
+		for _, item := range groups {
+			if item.name == tag.category {
+				group = item
+				break
+			}
+		}
+		if group == nil {
+			group = &structuredLoggerGroup{
+				name: tag.category,
+			}
+			groups = append(groups, group)
+		}
+		group.attrs = append(group.attrs, attr)
+	}
+	for _, group := range groups {
+		attrs = append(attrs, slog.Group(group.name, group.attrs...))
+	}
+	return attrs
+}
+
+func defaultStructuredLoggerLevel(c *elton.Context, err error) slog.Level {
+	if err != nil || getStatusCode(c) >= http.StatusInternalServerError {
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+// NewStructuredLogger returns a new structured logger middleware, the tags of format
+// are emitted as slog attributes, so it can be used with any slog.Handler
+// (e.g. slog.NewJSONHandler). It will throw a panic if the Format is empty.
+func NewStructuredLogger(config StructuredLoggerConfig) elton.Handler {
+	if config.Format == "" {
+		panic(ErrStructuredLoggerRequireFormat)
+	}
+	tags := parseLoggerTags([]byte(config.Format))
+	mask := config.Mask
+	if mask == nil {
+		mask = defaultLoggerMaskFields
+	}
+	message := config.Message
+	if message == "" {
+		message = "access"
+	}
+	levelFn := config.Level
+	if levelFn == nil {
+		levelFn = defaultStructuredLoggerLevel
+	}
+	skipper := getSkipper(config.Skipper)
+	return func(c *elton.Context) error {
+		if skipper(c) {
+			return c.Next()
+		}
+		startedAt := time.Now()
+		err := c.Next()
+		logger := config.Logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		level := levelFn(c, err)
+		// 不满足日志级别则无需生成属性
+		if !logger.Enabled(c.Context(), level) {
+			return err
+		}
+		attrs := getLoggerAttrs(c, tags, startedAt, mask)
+		logger.LogAttrs(c.Context(), level, message, attrs...)
+		return err
+	}
+}
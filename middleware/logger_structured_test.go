@@ -0,0 +1,147 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton/v2"
+)
+
+func TestNewStructuredLoggerPanic(t *testing.T) {
+	assert := assert.New(t)
+	assert.PanicsWithValue(ErrStructuredLoggerRequireFormat, func() {
+		NewStructuredLogger(StructuredLoggerConfig{})
+	})
+}
+
+func TestRedactURI(t *testing.T) {
+	assert := assert.New(t)
+	mask := regexp.MustCompile("token")
+	assert.Equal("/users", redactURI("/users", mask))
+	assert.Equal("/users?b=2&a=1", redactURI("/users?b=2&a=1", mask))
+	assert.Equal("/users?a=1&token=%2A%2A%2A", redactURI("/users?token=abc&a=1", mask))
+}
+
+func TestStructuredLogger(t *testing.T) {
+	assert := assert.New(t)
+
+	_ = os.Setenv("__LOGGER__", "LOGGER")
+	buf := &bytes.Buffer{}
+	fn := NewStructuredLogger(StructuredLoggerConfig{
+		Format: `{method} "{route}" {uri} {status} {size} {size-human} {payload-size} {latency} {>X-Request-Id} {>Authorization} {<X-Response-Id} {~sid} {:userId} {:account} {:token} {$__LOGGER__}`,
+		Logger: slog.New(slog.NewJSONHandler(buf, nil)),
+	})
+	e := elton.New()
+	e.Use(fn)
+	e.POST("/users/{id}", func(c *elton.Context) error {
+		c.RequestBody = []byte("request-body")
+		c.Set("userId", 1)
+		c.Set("token", "abc")
+		c.SetHeader("X-Response-Id", "2")
+		c.BodyBuffer = bytes.NewBufferString("response-body")
+		return nil
+	})
+
+	req := httptest.NewRequest("POST", "/users/1?access_token=abc&a=1", nil)
+	req.Header.Set("X-Request-Id", "1")
+	req.Header.Set("Authorization", "Bearer abc")
+	req.Header.Set("Cookie", "sid=s1")
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+
+	data := make(map[string]any)
+	assert.Nil(json.Unmarshal(buf.Bytes(), &data))
+	assert.NotEmpty(data["latency"])
+	delete(data, "latency")
+	delete(data, "time")
+	assert.Equal(map[string]any{
+		"level":        "INFO",
+		"msg":          "access",
+		"method":       "POST",
+		"route":        "/users/{id}",
+		"uri":          "/users/1?a=1&access_token=%2A%2A%2A",
+		"status":       float64(200),
+		"size":         float64(13),
+		"size-human":   "13B",
+		"payload-size": float64(12),
+		"requestHeader": map[string]any{
+			"X-Request-Id":  "1",
+			"Authorization": "***",
+		},
+		"responseHeader": map[string]any{
+			"X-Response-Id": "2",
+		},
+		"cookie": map[string]any{
+			"sid": "s1",
+		},
+		"context": map[string]any{
+			"userId": float64(1),
+			"token":  "***",
+		},
+		"env": map[string]any{
+			"__LOGGER__": "LOGGER",
+		},
+	}, data)
+}
+
+func TestStructuredLoggerLevel(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	fn := NewStructuredLogger(StructuredLoggerConfig{
+		Format:  "{status}",
+		Message: "request",
+		Logger: slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{
+			Level: slog.LevelWarn,
+		})),
+	})
+
+	// info级别的日志被忽略
+	req := httptest.NewRequest("GET", "/", nil)
+	c := elton.NewContext(httptest.NewRecorder(), req)
+	c.Next = func() error {
+		return nil
+	}
+	assert.Nil(fn(c))
+	assert.Empty(buf.String())
+
+	c = elton.NewContext(httptest.NewRecorder(), req)
+	c.Next = func() error {
+		return errors.New("abc")
+	}
+	assert.Equal("abc", fn(c).Error())
+	data := make(map[string]any)
+	assert.Nil(json.Unmarshal(buf.Bytes(), &data))
+	assert.Equal("ERROR", data["level"])
+	assert.Equal("request", data["msg"])
+}
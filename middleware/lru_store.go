@@ -24,21 +24,66 @@ package middleware
 import (
 	"context"
 	"encoding/binary"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	lru "github.com/hashicorp/golang-lru/v2"
 )
 
-var _ CacheStore = (*LRUStore)(nil)
+var (
+	_ CacheStore         = (*LRUStore)(nil)
+	_ CacheDeleter       = (*LRUStore)(nil)
+	_ CacheKeyPurger     = (*LRUStore)(nil)
+	_ CachePrefixPurger  = (*LRUStore)(nil)
+	_ CacheTagger        = (*LRUStore)(nil)
+	_ CacheEntryLister   = (*LRUStore)(nil)
+	_ CacheStatsProvider = (*LRUStore)(nil)
+)
+
+// LRUStoreStats the stats of lru store
+type LRUStoreStats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	Sets   uint64 `json:"sets"`
+	// Evictions the count of data evicted by lru(not include the removed data)
+	Evictions uint64 `json:"evictions"`
+	Keys      int    `json:"keys"`
+	// Bytes the bytes of data in store
+	Bytes int64 `json:"bytes"`
+}
 
 type LRUStore struct {
 	usePeek bool
 	store   *lru.Cache[string, []byte]
+
+	mu sync.Mutex
+	// tag对应的key
+	tags map[string]map[string]struct{}
+	// key对应的tag，用于key被删除或淘汰时清除索引
+	keyTags map[string][]string
+
+	// writeMu 串行化写入与删除，淘汰回调在调用的goroutine中执行，
+	// 因此可根据removing区分主动删除，字节数的统计也不会并发交错
+	writeMu sync.Mutex
+	// removing 是否主动删除中，由writeMu保护
+	removing bool
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	sets      atomic.Uint64
+	bytes     atomic.Int64
+	evictions atomic.Uint64
 }
 
-// 存储布局为 [过期时间戳(4字节,大端,单位秒)][payload]，
+// 存储布局为 [过期时间戳(4字节,大端,单位秒)][创建时间戳(4字节,大端,单位秒)][payload]，
 // 过期判断使用墙上时钟，读取到过期数据返回nil（不主动删除，由lru淘汰）
-const expiredByteSize = 4
+const (
+	expiredByteSize = 4
+	createdByteSize = 4
+	lruMetaByteSize = expiredByteSize + createdByteSize
+)
 
 func nowSeconds() uint32 {
 	return uint32(time.Now().Unix())
@@ -53,35 +98,196 @@ func (s *LRUStore) Get(ctx context.Context, key string) ([]byte, error) {
 	} else {
 		value, ok = s.store.Get(key)
 	}
-	if !ok || len(value) < expiredByteSize {
+	if !ok || len(value) < lruMetaByteSize {
+		s.misses.Add(1)
 		return nil, nil
 	}
 	expired := binary.BigEndian.Uint32(value)
 	if nowSeconds() > expired {
+		s.misses.Add(1)
 		return nil, nil
 	}
-	return value[expiredByteSize:], nil
+	s.hits.Add(1)
+	return value[lruMetaByteSize:], nil
 }
 
 func (s *LRUStore) Set(ctx context.Context, key string, data []byte, ttl time.Duration) error {
-	buf := make([]byte, len(data)+expiredByteSize)
-	expired := nowSeconds() + uint32(ttl/time.Second)
-	binary.BigEndian.PutUint32(buf, expired)
-	copy(buf[expiredByteSize:], data)
+	buf := make([]byte, len(data)+lruMetaByteSize)
+	now := nowSeconds()
+	binary.BigEndian.PutUint32(buf, now+uint32(ttl/time.Second))
+	binary.BigEndian.PutUint32(buf[expiredByteSize:], now)
+	copy(buf[lruMetaByteSize:], data)
+	// 重新设置的数据需重新打标签
+	s.untag(key)
+	s.writeMu.Lock()
+	// 替换已有数据时不会触发淘汰回调，需减去原有数据的长度
+	if prev, ok := s.store.Peek(key); ok {
+		s.bytes.Add(-int64(len(prev)))
+	}
+	s.bytes.Add(int64(len(buf)))
 	s.store.Add(key, buf)
+	s.writeMu.Unlock()
+	s.sets.Add(1)
 	return nil
 }
 
+// remove removes the data of key, it returns true if the key is exists
+func (s *LRUStore) remove(key string) bool {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.removing = true
+	defer func() {
+		s.removing = false
+	}()
+	return s.store.Remove(key)
+}
+
+// Delete removes the data of key from store
+func (s *LRUStore) Delete(ctx context.Context, key string) error {
+	s.remove(key)
+	return nil
+}
+
+// PurgeKey removes the data of key from store,
+// it returns true if the key is exists
+func (s *LRUStore) PurgeKey(ctx context.Context, key string) (bool, error) {
+	return s.remove(key), nil
+}
+
+// PurgePrefix removes the data whose key has the prefix,
+// it returns the count of removed keys
+func (s *LRUStore) PurgePrefix(ctx context.Context, prefix string) (int, error) {
+	count := 0
+	for _, key := range s.store.Keys() {
+		if strings.HasPrefix(key, prefix) && s.remove(key) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Tag associates the key with tags, the tags of key are
+// cleared when the key is set again, removed or evicted.
+// The key which is not exists(e.g. evicted after set) is ignored.
+func (s *LRUStore) Tag(ctx context.Context, key string, tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 淘汰回调在lru解锁后执行且需获取s.mu，因此在锁内判断key存在时，
+	// 若之后被淘汰，回调会在打标签后清除索引；不存在则不打标签，避免索引泄漏
+	if !s.store.Contains(key) {
+		return nil
+	}
+	for _, tag := range tags {
+		keys, ok := s.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			s.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	s.keyTags[key] = append(s.keyTags[key], tags...)
+	return nil
+}
+
+// PurgeTag removes the data of keys which are tagged with the tag,
+// it returns the count of removed keys
+func (s *LRUStore) PurgeTag(ctx context.Context, tag string) (int, error) {
+	s.mu.Lock()
+	keys := make([]string, 0, len(s.tags[tag]))
+	for key := range s.tags[tag] {
+		keys = append(keys, key)
+	}
+	s.mu.Unlock()
+	// remove会触发淘汰回调清除索引，因此不能在锁内调用
+	count := 0
+	for _, key := range keys {
+		if s.remove(key) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// untag clears the tags of key
+func (s *LRUStore) untag(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tag := range s.keyTags[key] {
+		keys := s.tags[tag]
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(s.tags, tag)
+		}
+	}
+	delete(s.keyTags, key)
+}
+
 func newLRUStore(size int, usePeek bool) *LRUStore {
 	if size <= 0 {
 		size = 128
 	}
-	// 只要size > 0则不会出错
-	s, _ := lru.New[string, []byte](size)
-	return &LRUStore{
+	s := &LRUStore{
 		usePeek: usePeek,
-		store:   s,
+		tags:    make(map[string]map[string]struct{}),
+		keyTags: make(map[string][]string),
+	}
+	// 只要size > 0则不会出错
+	// 回调在Add或Remove的goroutine中执行，此时已持有writeMu
+	s.store, _ = lru.NewWithEvict(size, func(key string, value []byte) {
+		if !s.removing {
+			s.evictions.Add(1)
+		}
+		s.bytes.Add(-int64(len(value)))
+		s.untag(key)
+	})
+	return s
+}
+
+// Stats returns the stats of lru store
+func (s *LRUStore) Stats() LRUStoreStats {
+	return LRUStoreStats{
+		Hits:      s.hits.Load(),
+		Misses:    s.misses.Load(),
+		Sets:      s.sets.Load(),
+		Evictions: s.evictions.Load(),
+		Keys:      s.store.Len(),
+		Bytes:     s.bytes.Load(),
+	}
+}
+
+// StoreStats returns the stats of store, it implements CacheStatsProvider
+func (s *LRUStore) StoreStats() any {
+	return s.Stats()
+}
+
+// Entries returns the entries of store, ordered from oldest to newest
+func (s *LRUStore) Entries() []CacheEntry {
+	now := nowSeconds()
+	keys := s.store.Keys()
+	entries := make([]CacheEntry, 0, len(keys))
+	for _, key := range keys {
+		value, ok := s.store.Peek(key)
+		if !ok || len(value) < lruMetaByteSize {
+			continue
+		}
+		expired := binary.BigEndian.Uint32(value)
+		created := binary.BigEndian.Uint32(value[expiredByteSize:])
+		entry := CacheEntry{
+			Key:  key,
+			Size: len(value) - lruMetaByteSize,
+		}
+		if now > created {
+			entry.Age = int(now - created)
+		}
+		if expired > now {
+			entry.TTL = int(expired - now)
+		}
+		entries = append(entries, entry)
 	}
+	return entries
 }
 
 // NewPeekLRUStore creates a lru store use peek
@@ -24,6 +24,9 @@ package middleware
 
 import (
 	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -67,6 +70,121 @@ func TestPeekLRUStore(t *testing.T) {
 	assert.Empty(buf)
 }
 
+func TestLRUStoreDelete(t *testing.T) {
+	assert := assert.New(t)
+	store := NewLRUStore(10)
+	ctx := context.Background()
+	_ = store.Set(ctx, "k", []byte("v"), time.Minute)
+	assert.Nil(store.Delete(ctx, "k"))
+	buf, _ := store.Get(ctx, "k")
+	assert.Empty(buf)
+}
+
+func TestLRUStorePurgePrefix(t *testing.T) {
+	assert := assert.New(t)
+	store := NewLRUStore(10)
+	ctx := context.Background()
+	_ = store.Set(ctx, "GET /users/1", []byte("1"), time.Minute)
+	_ = store.Set(ctx, "GET /users/2", []byte("2"), time.Minute)
+	_ = store.Set(ctx, "GET /books/1", []byte("3"), time.Minute)
+
+	count, err := store.PurgePrefix(ctx, "GET /users/")
+	assert.Nil(err)
+	assert.Equal(2, count)
+	buf, _ := store.Get(ctx, "GET /users/1")
+	assert.Empty(buf)
+	buf, _ = store.Get(ctx, "GET /books/1")
+	assert.Equal([]byte("3"), buf)
+}
+
+func TestLRUStoreTag(t *testing.T) {
+	assert := assert.New(t)
+	store := NewLRUStore(3)
+	ctx := context.Background()
+	_ = store.Set(ctx, "a", []byte("a"), time.Minute)
+	_ = store.Set(ctx, "b", []byte("b"), time.Minute)
+	_ = store.Set(ctx, "c", []byte("c"), time.Minute)
+	assert.Nil(store.Tag(ctx, "a", "user", "user-1"))
+	assert.Nil(store.Tag(ctx, "b", "user"))
+	assert.Nil(store.Tag(ctx, "c", "book"))
+
+	count, err := store.PurgeTag(ctx, "user-1")
+	assert.Nil(err)
+	assert.Equal(1, count)
+	buf, _ := store.Get(ctx, "a")
+	assert.Empty(buf)
+	// 删除后索引清除
+	assert.Equal(map[string]struct{}{
+		"b": {},
+	}, store.tags["user"])
+	assert.Empty(store.tags["user-1"])
+
+	// 重新设置的数据不再保留原有标签
+	_ = store.Set(ctx, "b", []byte("b"), time.Minute)
+	count, err = store.PurgeTag(ctx, "user")
+	assert.Nil(err)
+	assert.Equal(0, count)
+	buf, _ = store.Get(ctx, "b")
+	assert.Equal([]byte("b"), buf)
+
+	// 淘汰时清除索引
+	_ = store.Set(ctx, "d", []byte("d"), time.Minute)
+	_ = store.Set(ctx, "e", []byte("e"), time.Minute)
+	assert.Empty(store.tags)
+	assert.Empty(store.keyTags)
+
+	// 不存在（如已被淘汰）的key不打标签
+	assert.Nil(store.Tag(ctx, "a", "user"))
+	assert.Empty(store.tags)
+	assert.Empty(store.keyTags)
+}
+
+func TestLRUStorePurgeKey(t *testing.T) {
+	assert := assert.New(t)
+	store := NewLRUStore(3)
+	ctx := context.Background()
+	_ = store.Set(ctx, "a", []byte("a"), time.Minute)
+
+	existed, err := store.PurgeKey(ctx, "a")
+	assert.Nil(err)
+	assert.True(existed)
+	existed, err = store.PurgeKey(ctx, "a")
+	assert.Nil(err)
+	assert.False(existed)
+}
+
+func TestLRUStoreStats(t *testing.T) {
+	assert := assert.New(t)
+	store := NewLRUStore(2)
+	ctx := context.Background()
+
+	_, _ = store.Get(ctx, "a")
+	_ = store.Set(ctx, "a", []byte("a"), time.Minute)
+	_ = store.Set(ctx, "a", []byte("aa"), time.Minute)
+	_, _ = store.Get(ctx, "a")
+	_ = store.Set(ctx, "b", []byte("b"), time.Minute)
+	// 淘汰a
+	_ = store.Set(ctx, "c", []byte("c"), 2*time.Minute)
+	// 主动删除不计入淘汰
+	_ = store.Delete(ctx, "b")
+
+	assert.Equal(LRUStoreStats{
+		Hits:      1,
+		Misses:    1,
+		Sets:      4,
+		Evictions: 1,
+		Keys:      1,
+		Bytes:     int64(1 + lruMetaByteSize),
+	}, store.Stats())
+
+	entries := store.Entries()
+	assert.Len(entries, 1)
+	assert.Equal("c", entries[0].Key)
+	assert.Equal(1, entries[0].Size)
+	assert.InDelta(120, entries[0].TTL, 1)
+	assert.InDelta(0, entries[0].Age, 1)
+}
+
 func BenchmarkLRUStore(b *testing.B) {
 	store := NewLRUStore(128)
 	ctx := context.Background()
@@ -75,3 +193,42 @@ func BenchmarkLRUStore(b *testing.B) {
 		_, _ = store.Get(ctx, "key")
 	}
 }
+
+func TestLRUStoreStatsConcurrent(t *testing.T) {
+	assert := assert.New(t)
+	store := NewLRUStore(10)
+	ctx := context.Background()
+
+	var removed atomic.Uint64
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				key := strconv.Itoa(i) + "-" + strconv.Itoa(j)
+				_ = store.Set(ctx, key, make([]byte, j), time.Minute)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				key := strconv.Itoa(i) + "-" + strconv.Itoa(j)
+				if existed, _ := store.PurgeKey(ctx, key); existed {
+					removed.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := store.Stats()
+	// 每个key只写入一次，因此写入数为淘汰、删除与剩余之和
+	assert.Equal(uint64(400), stats.Evictions+removed.Load()+uint64(stats.Keys))
+	bytes := 0
+	for _, key := range store.store.Keys() {
+		value, _ := store.store.Peek(key)
+		bytes += len(value)
+	}
+	assert.Equal(int64(bytes), stats.Bytes)
+}
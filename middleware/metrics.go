@@ -0,0 +1,398 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/vicanso/elton/v2"
+)
+
+const (
+	// MetricsContentType the content type of prometheus text exposition format
+	MetricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+	metricsTypeCounter   = "counter"
+	metricsTypeGauge     = "gauge"
+	metricsTypeHistogram = "histogram"
+	// labelValuesSeparator the separator of label values for series key
+	labelValuesSeparator = "\xff"
+)
+
+var (
+	// DefaultLatencyBuckets default buckets(seconds) of request duration
+	DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	// DefaultSizeBuckets default buckets(bytes) of response size
+	DefaultSizeBuckets = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+)
+
+type (
+	// MetricsRegistry the registry of metrics, it renders
+	// all metrics as prometheus text exposition format.
+	MetricsRegistry struct {
+		mu      sync.RWMutex
+		metrics []*metric
+		names   map[string]bool
+	}
+	// metric the metric with series of label values
+	metric struct {
+		name       string
+		help       string
+		metricType string
+		labelNames []string
+		buckets    []float64
+		fn         func() float64
+
+		mu     sync.Mutex
+		series map[string]*metricSeries
+	}
+	metricSeries struct {
+		labelValues []string
+		value       float64
+		// bucket counts of histogram(not cumulative)
+		counts []uint64
+		count  uint64
+	}
+	// MetricsCounter counter metric, the value only goes up
+	MetricsCounter struct {
+		m *metric
+	}
+	// MetricsGauge gauge metric, the value can go up and down
+	MetricsGauge struct {
+		m *metric
+	}
+	// MetricsHistogram histogram metric
+	MetricsHistogram struct {
+		m *metric
+	}
+	// MetricsConfig metrics config
+	MetricsConfig struct {
+		// Registry the registry of metrics, a new registry is created if nil
+		Registry *MetricsRegistry
+		// Namespace the prefix of metric name, default is "http"
+		Namespace string
+		// LatencyBuckets the buckets of request duration, default is DefaultLatencyBuckets
+		LatencyBuckets []float64
+		// SizeBuckets the buckets of response size, default is DefaultSizeBuckets
+		SizeBuckets []float64
+		Skipper     elton.Skipper
+	}
+)
+
+// NewMetricsRegistry returns a new metrics registry
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{
+		names: make(map[string]bool),
+	}
+}
+
+func (r *MetricsRegistry) register(m *metric) *metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[m.name] {
+		panic(fmt.Errorf("metric %s is already registered", m.name))
+	}
+	r.names[m.name] = true
+	m.series = make(map[string]*metricSeries)
+	r.metrics = append(r.metrics, m)
+	return m
+}
+
+// NewCounter registers and returns a new counter
+func (r *MetricsRegistry) NewCounter(name, help string, labelNames ...string) *MetricsCounter {
+	return &MetricsCounter{
+		m: r.register(&metric{
+			name:       name,
+			help:       help,
+			metricType: metricsTypeCounter,
+			labelNames: labelNames,
+		}),
+	}
+}
+
+// NewGauge registers and returns a new gauge
+func (r *MetricsRegistry) NewGauge(name, help string, labelNames ...string) *MetricsGauge {
+	return &MetricsGauge{
+		m: r.register(&metric{
+			name:       name,
+			help:       help,
+			metricType: metricsTypeGauge,
+			labelNames: labelNames,
+		}),
+	}
+}
+
+// NewGaugeFunc registers a gauge whose value is got from fn when rendering
+func (r *MetricsRegistry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&metric{
+		name:       name,
+		help:       help,
+		metricType: metricsTypeGauge,
+		fn:         fn,
+	})
+}
+
+// NewHistogram registers and returns a new histogram,
+// the buckets should be sorted in increasing order.
+func (r *MetricsRegistry) NewHistogram(name, help string, buckets []float64, labelNames ...string) *MetricsHistogram {
+	if !slices.IsSorted(buckets) {
+		panic(fmt.Errorf("buckets of histogram %s should be sorted", name))
+	}
+	return &MetricsHistogram{
+		m: r.register(&metric{
+			name:       name,
+			help:       help,
+			metricType: metricsTypeHistogram,
+			labelNames: labelNames,
+			buckets:    buckets,
+		}),
+	}
+}
+
+// with returns the series of label values, the caller should hold the lock.
+func (m *metric) with(labelValues []string) *metricSeries {
+	if len(labelValues) != len(m.labelNames) {
+		panic(fmt.Errorf("metric %s expects %d label values, but got %d", m.name, len(m.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, labelValuesSeparator)
+	s, ok := m.series[key]
+	if !ok {
+		s = &metricSeries{
+			labelValues: slices.Clone(labelValues),
+		}
+		if m.buckets != nil {
+			s.counts = make([]uint64, len(m.buckets))
+		}
+		m.series[key] = s
+	}
+	return s
+}
+
+// Inc increases the counter by 1
+func (c *MetricsCounter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds the value to counter, the value should not be negative
+func (c *MetricsCounter) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		panic(fmt.Errorf("counter %s can not decrease", c.m.name))
+	}
+	c.m.mu.Lock()
+	defer c.m.mu.Unlock()
+	c.m.with(labelValues).value += value
+}
+
+// Set sets the value of gauge
+func (g *MetricsGauge) Set(value float64, labelValues ...string) {
+	g.m.mu.Lock()
+	defer g.m.mu.Unlock()
+	g.m.with(labelValues).value = value
+}
+
+// Add adds the value(can be negative) to gauge
+func (g *MetricsGauge) Add(value float64, labelValues ...string) {
+	g.m.mu.Lock()
+	defer g.m.mu.Unlock()
+	g.m.with(labelValues).value += value
+}
+
+// Observe adds an observation to histogram
+func (h *MetricsHistogram) Observe(value float64, labelValues ...string) {
+	h.m.mu.Lock()
+	defer h.m.mu.Unlock()
+	s := h.m.with(labelValues)
+	// 只记录所在的bucket，输出时再累加
+	if i := sort.SearchFloat64s(h.m.buckets, value); i < len(s.counts) {
+		s.counts[i]++
+	}
+	s.count++
+	s.value += value
+}
+
+func formatMetricValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpReplacer       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func writeMetricLabels(buf *bytes.Buffer, names, values []string, extraName, extraValue string) {
+	if len(names) == 0 && extraName == "" {
+		return
+	}
+	buf.WriteByte('{')
+	for i, name := range names {
+		if i != 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(name)
+		buf.WriteString(`="`)
+		buf.WriteString(labelValueReplacer.Replace(values[i]))
+		buf.WriteByte('"')
+	}
+	if extraName != "" {
+		if len(names) != 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(extraName)
+		buf.WriteString(`="`)
+		buf.WriteString(extraValue)
+		buf.WriteByte('"')
+	}
+	buf.WriteByte('}')
+}
+
+func writeMetricSample(buf *bytes.Buffer, name string, names, values []string, extraName, extraValue string, value float64) {
+	buf.WriteString(name)
+	writeMetricLabels(buf, names, values, extraName, extraValue)
+	buf.WriteByte(' ')
+	buf.WriteString(formatMetricValue(value))
+	buf.WriteByte('\n')
+}
+
+func (m *metric) writeTo(buf *bytes.Buffer) {
+	buf.WriteString("# HELP " + m.name + " " + helpReplacer.Replace(m.help) + "\n")
+	buf.WriteString("# TYPE " + m.name + " " + m.metricType + "\n")
+	if m.fn != nil {
+		writeMetricSample(buf, m.name, nil, nil, "", "", m.fn())
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := m.series[key]
+		if m.metricType != metricsTypeHistogram {
+			writeMetricSample(buf, m.name, m.labelNames, s.labelValues, "", "", s.value)
+			continue
+		}
+		var cumulative uint64
+		for i, bucket := range m.buckets {
+			cumulative += s.counts[i]
+			writeMetricSample(buf, m.name+"_bucket", m.labelNames, s.labelValues, "le", formatMetricValue(bucket), float64(cumulative))
+		}
+		writeMetricSample(buf, m.name+"_bucket", m.labelNames, s.labelValues, "le", "+Inf", float64(s.count))
+		writeMetricSample(buf, m.name+"_sum", m.labelNames, s.labelValues, "", "", s.value)
+		writeMetricSample(buf, m.name+"_count", m.labelNames, s.labelValues, "", "", float64(s.count))
+	}
+}
+
+// WriteTo writes all metrics as prometheus text exposition format
+func (r *MetricsRegistry) WriteTo(w io.Writer) (int64, error) {
+	buf := new(bytes.Buffer)
+	r.mu.RLock()
+	for _, m := range r.metrics {
+		m.writeTo(buf)
+	}
+	r.mu.RUnlock()
+	return buf.WriteTo(w)
+}
+
+// NewMetrics returns a new metrics middleware, the metrics below are recorded
+// (the prefix is the namespace, default is "http"):
+//   - requests_total: counter of requests by method, route and status
+//   - requests_in_flight: gauge of processing requests
+//   - request_duration_seconds: histogram of latency by method, route and status
+//   - response_size_bytes: histogram of response size by method, route and status
+//
+// Use NewMetricsHandler with the same registry to expose the metrics.
+func NewMetrics(config MetricsConfig) elton.Handler {
+	registry := config.Registry
+	if registry == nil {
+		registry = NewMetricsRegistry()
+	}
+	namespace := config.Namespace
+	if namespace == "" {
+		namespace = "http"
+	}
+	latencyBuckets := config.LatencyBuckets
+	if len(latencyBuckets) == 0 {
+		latencyBuckets = DefaultLatencyBuckets
+	}
+	sizeBuckets := config.SizeBuckets
+	if len(sizeBuckets) == 0 {
+		sizeBuckets = DefaultSizeBuckets
+	}
+	labelNames := []string{
+		"method",
+		"route",
+		"status",
+	}
+	requests := registry.NewCounter(namespace+"_requests_total", "Total number of http requests.", labelNames...)
+	latency := registry.NewHistogram(namespace+"_request_duration_seconds", "The latency of http requests.", latencyBuckets, labelNames...)
+	size := registry.NewHistogram(namespace+"_response_size_bytes", "The size of http responses.", sizeBuckets, labelNames...)
+	// 与stats中间件相同的处理中请求数统计
+	connecting := &atomic.Int32{}
+	registry.NewGaugeFunc(namespace+"_requests_in_flight", "The number of http requests being processed.", func() float64 {
+		return float64(connecting.Load())
+	})
+
+	return newStats(StatsConfig{
+		Skipper: config.Skipper,
+		OnStats: func(info *StatsInfo, _ *elton.Context) {
+			status := strconv.Itoa(info.Status)
+			requests.Inc(info.Method, info.Route, status)
+			latency.Observe(info.Latency.Seconds(), info.Method, info.Route, status)
+			size.Observe(float64(info.Size), info.Method, info.Route, status)
+		},
+	}, connecting)
+}
+
+// NewMetricsHandler returns a handler which renders the metrics
+// of registry as prometheus text exposition format.
+func NewMetricsHandler(registry *MetricsRegistry) elton.Handler {
+	return func(c *elton.Context) error {
+		buf := new(bytes.Buffer)
+		if _, err := registry.WriteTo(buf); err != nil {
+			return err
+		}
+		c.SetHeader(elton.HeaderContentType, MetricsContentType)
+		c.NoCache()
+		c.BodyBuffer = buf
+		return nil
+	}
+}
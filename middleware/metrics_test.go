@@ -0,0 +1,132 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton/v2"
+)
+
+func TestMetricsRegistry(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewMetricsRegistry()
+	counter := r.NewCounter("jobs_total", "Total jobs.", "kind")
+	counter.Inc("b")
+	counter.Add(2, "a")
+	counter.Inc(`a"\` + "\n")
+
+	gauge := r.NewGauge("temperature", "Current\ntemperature.")
+	gauge.Set(10)
+	gauge.Add(-2.5)
+
+	r.NewGaugeFunc("up", "Up.", func() float64 {
+		return 1
+	})
+
+	histogram := r.NewHistogram("latency_seconds", "Latency.", []float64{0.1, 1}, "path")
+	histogram.Observe(0.05, "/")
+	histogram.Observe(0.1, "/")
+	histogram.Observe(0.5, "/")
+	histogram.Observe(3, "/")
+
+	buf := &bytes.Buffer{}
+	_, err := r.WriteTo(buf)
+	assert.Nil(err)
+	assert.Equal(`# HELP jobs_total Total jobs.
+# TYPE jobs_total counter
+jobs_total{kind="a"} 2
+jobs_total{kind="a\"\\\n"} 1
+jobs_total{kind="b"} 1
+# HELP temperature Current\ntemperature.
+# TYPE temperature gauge
+temperature 7.5
+# HELP up Up.
+# TYPE up gauge
+up 1
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{path="/",le="0.1"} 2
+latency_seconds_bucket{path="/",le="1"} 3
+latency_seconds_bucket{path="/",le="+Inf"} 4
+latency_seconds_sum{path="/"} 3.65
+latency_seconds_count{path="/"} 4
+`, buf.String())
+
+	// 重复注册
+	assert.Panics(func() {
+		r.NewGauge("up", "")
+	})
+	// label数量不匹配
+	assert.Panics(func() {
+		counter.Inc()
+	})
+	// counter不可减少
+	assert.Panics(func() {
+		counter.Add(-1, "a")
+	})
+	// bucket未排序
+	assert.Panics(func() {
+		r.NewHistogram("size", "", []float64{2, 1})
+	})
+}
+
+func TestMetrics(t *testing.T) {
+	assert := assert.New(t)
+
+	registry := NewMetricsRegistry()
+	e := elton.New()
+	e.Use(NewMetrics(MetricsConfig{
+		Registry:    registry,
+		SizeBuckets: []float64{1, 10},
+	}))
+	e.GET("/users/{id}", func(c *elton.Context) error {
+		c.BodyBuffer = bytes.NewBufferString("hello")
+		return nil
+	})
+	e.GET("/metrics", NewMetricsHandler(registry))
+
+	for range 2 {
+		req := httptest.NewRequest("GET", "/users/1", nil)
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(200, resp.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal(MetricsContentType, resp.Header().Get(elton.HeaderContentType))
+	body := resp.Body.String()
+	assert.True(strings.Contains(body, `http_requests_total{method="GET",route="/users/{id}",status="200"} 2`))
+	assert.True(strings.Contains(body, `http_response_size_bytes_bucket{method="GET",route="/users/{id}",status="200",le="10"} 2`))
+	assert.True(strings.Contains(body, `http_request_duration_seconds_count{method="GET",route="/users/{id}",status="200"} 2`))
+	// 当前请求仍在处理中
+	assert.True(strings.Contains(body, "http_requests_in_flight 1\n"))
+}
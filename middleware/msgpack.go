@@ -0,0 +1,172 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"slices"
+)
+
+const (
+	// MIMEApplicationMsgpack application msgpack
+	MIMEApplicationMsgpack = "application/msgpack"
+)
+
+// marshalMsgpack marshals the value as MessagePack, the value is converted
+// by encoding/json first, so the json tags and marshalers of value are
+// respected and the output is the same structure as json response.
+func marshalMsgpack(v any) ([]byte, error) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	// 使用json.Number避免整数转换为float64
+	decoder.UseNumber()
+	var data any
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+	b := &bytes.Buffer{}
+	if err := writeMsgpack(b, data); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// writeMsgpack writes the value which is decoded from json as MessagePack
+func writeMsgpack(b *bytes.Buffer, v any) error {
+	switch data := v.(type) {
+	case nil:
+		b.WriteByte(0xc0)
+	case bool:
+		if data {
+			b.WriteByte(0xc3)
+		} else {
+			b.WriteByte(0xc2)
+		}
+	case json.Number:
+		return writeMsgpackNumber(b, data)
+	case string:
+		writeMsgpackString(b, data)
+	case []any:
+		writeMsgpackLength(b, len(data), 0x90, 16, 0xdc)
+		for _, item := range data {
+			if err := writeMsgpack(b, item); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		writeMsgpackLength(b, len(data), 0x80, 16, 0xde)
+		// key排序保证输出稳定
+		keys := make([]string, 0, len(data))
+		for key := range data {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			writeMsgpackString(b, key)
+			if err := writeMsgpack(b, data[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.ErrUnsupported
+	}
+	return nil
+}
+
+func writeMsgpackNumber(b *bytes.Buffer, n json.Number) error {
+	if i, err := n.Int64(); err == nil {
+		writeMsgpackInt(b, i)
+		return nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return err
+	}
+	b.WriteByte(0xcb)
+	b.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+	return nil
+}
+
+func writeMsgpackInt(b *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i <= math.MaxInt8:
+		// positive fixint
+		b.WriteByte(byte(i))
+	case i >= -32 && i < 0:
+		// negative fixint
+		b.WriteByte(byte(int8(i)))
+	case i >= math.MinInt8 && i <= math.MaxInt8:
+		b.WriteByte(0xd0)
+		b.WriteByte(byte(int8(i)))
+	case i >= math.MinInt16 && i <= math.MaxInt16:
+		b.WriteByte(0xd1)
+		b.Write(binary.BigEndian.AppendUint16(nil, uint16(int16(i))))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		b.WriteByte(0xd2)
+		b.Write(binary.BigEndian.AppendUint32(nil, uint32(int32(i))))
+	default:
+		b.WriteByte(0xd3)
+		b.Write(binary.BigEndian.AppendUint64(nil, uint64(i)))
+	}
+}
+
+func writeMsgpackString(b *bytes.Buffer, s string) {
+	size := len(s)
+	switch {
+	case size < 32:
+		b.WriteByte(0xa0 | byte(size))
+	case size <= math.MaxUint8:
+		b.WriteByte(0xd9)
+		b.WriteByte(byte(size))
+	case size <= math.MaxUint16:
+		b.WriteByte(0xda)
+		b.Write(binary.BigEndian.AppendUint16(nil, uint16(size)))
+	default:
+		b.WriteByte(0xdb)
+		b.Write(binary.BigEndian.AppendUint32(nil, uint32(size)))
+	}
+	b.WriteString(s)
+}
+
+// writeMsgpackLength writes the length of array or map, the fix format
+// is used if the size is less than fixSize, otherwise the 16/32 format.
+func writeMsgpackLength(b *bytes.Buffer, size int, fixPrefix byte, fixSize int, prefix16 byte) {
+	switch {
+	case size < fixSize:
+		b.WriteByte(fixPrefix | byte(size))
+	case size <= math.MaxUint16:
+		b.WriteByte(prefix16)
+		b.Write(binary.BigEndian.AppendUint16(nil, uint16(size)))
+	default:
+		// 32位长度的类型标识为16位的下一个
+		b.WriteByte(prefix16 + 1)
+		b.Write(binary.BigEndian.AppendUint32(nil, uint32(size)))
+	}
+}
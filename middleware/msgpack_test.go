@@ -0,0 +1,130 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/hes"
+)
+
+func TestMarshalMsgpack(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		value  any
+		result []byte
+	}{
+		{
+			value:  nil,
+			result: []byte{0xc0},
+		},
+		{
+			value:  true,
+			result: []byte{0xc3},
+		},
+		{
+			value:  false,
+			result: []byte{0xc2},
+		},
+		{
+			value:  1,
+			result: []byte{0x01},
+		},
+		{
+			value:  -1,
+			result: []byte{0xff},
+		},
+		{
+			value:  -100,
+			result: []byte{0xd0, 0x9c},
+		},
+		{
+			value:  1000,
+			result: []byte{0xd1, 0x03, 0xe8},
+		},
+		{
+			value:  100000,
+			result: []byte{0xd2, 0x00, 0x01, 0x86, 0xa0},
+		},
+		{
+			value:  int64(1) << 40,
+			result: []byte{0xd3, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			value:  1.5,
+			result: []byte{0xcb, 0x3f, 0xf8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			value:  "abc",
+			result: []byte{0xa3, 'a', 'b', 'c'},
+		},
+		{
+			value:  []int{1, 2},
+			result: []byte{0x92, 0x01, 0x02},
+		},
+		{
+			value: &negotiatorUser{
+				ID:      1,
+				Account: "tree",
+				Token:   "token",
+			},
+			// json tag生效，key按字典序
+			result: []byte{
+				0x82,
+				0xa7, 'a', 'c', 'c', 'o', 'u', 'n', 't', 0xa4, 't', 'r', 'e', 'e',
+				0xa2, 'i', 'd', 0x01,
+			},
+		},
+		{
+			value: hes.New("abc", hes.WithStatus(400)),
+			result: []byte{
+				0x82,
+				0xa7, 'm', 'e', 's', 's', 'a', 'g', 'e', 0xa3, 'a', 'b', 'c',
+				0xaa, 's', 't', 'a', 't', 'u', 's', 'C', 'o', 'd', 'e', 0xd1, 0x01, 0x90,
+			},
+		},
+	}
+	for _, tt := range tests {
+		buf, err := marshalMsgpack(tt.value)
+		assert.Nil(err)
+		assert.Equal(tt.result, buf)
+	}
+
+	s := strings.Repeat("a", 300)
+	buf, err := marshalMsgpack(s)
+	assert.Nil(err)
+	assert.Equal([]byte{0xda, 0x01, 0x2c}, buf[:3])
+	assert.Equal(s, string(buf[3:]))
+
+	items := make([]bool, 20)
+	buf, err = marshalMsgpack(items)
+	assert.Nil(err)
+	assert.Equal([]byte{0xdc, 0x00, 0x14, 0xc2}, buf[:4])
+	assert.Equal(23, len(buf))
+
+	_, err = marshalMsgpack(make(chan int))
+	assert.NotNil(err)
+}
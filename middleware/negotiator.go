@@ -0,0 +1,431 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vicanso/elton/v2"
+	"github.com/vicanso/hes"
+)
+
+const (
+	// ErrNegotiatorCategory negotiator error category
+	ErrNegotiatorCategory = "elton-negotiator"
+	// MIMEApplicationXML application xml
+	MIMEApplicationXML = "application/xml; charset=utf-8"
+	// MIMETextCSV text csv
+	MIMETextCSV = "text/csv; charset=utf-8"
+
+	headerAccept = "Accept"
+)
+
+var (
+	// ErrNotAcceptable none of the encoders is acceptable for the request
+	ErrNotAcceptable = &hes.Error{
+		StatusCode: http.StatusNotAcceptable,
+		Message:    "not acceptable",
+		Category:   ErrNegotiatorCategory,
+	}
+	// ErrNegotiatorRequireEncoder encoder's media type and marshal are required
+	ErrNegotiatorRequireEncoder = errors.New("elton: require media type and marshal for encoder")
+)
+
+type (
+	// ResponseEncoder the encoder of response body for the media type
+	ResponseEncoder struct {
+		// MediaType the media type which is matched with Accept, e.g. application/json
+		MediaType string
+		// ContentType the content type of response, default is media type
+		ContentType string
+		// Marshal marshals the value, it should return errors.ErrUnsupported
+		// if the value can not be encoded, then the next acceptable encoder is tried.
+		Marshal func(v any) ([]byte, error)
+	}
+	// Negotiator the registry of response encoders, it selects the encoder
+	// by Accept header of request. The encoders should be registered before
+	// it is used, the order of registration is the preference of server.
+	Negotiator struct {
+		encoders []*ResponseEncoder
+	}
+	// acceptRange the media range of Accept header
+	acceptRange struct {
+		typ     string
+		subtype string
+		q       float64
+	}
+	xmlError struct {
+		XMLName     xml.Name        `xml:"error"`
+		StatusCode  int             `xml:"statusCode,omitempty"`
+		Code        string          `xml:"code,omitempty"`
+		Category    string          `xml:"category,omitempty"`
+		SubCategory string          `xml:"subCategory,omitempty"`
+		Title       string          `xml:"title,omitempty"`
+		Message     string          `xml:"message,omitempty"`
+		Exception   bool            `xml:"exception,omitempty"`
+		File        string          `xml:"file,omitempty"`
+		Line        int             `xml:"line,omitempty"`
+		Extra       *xmlErrorExtras `xml:"extra,omitempty"`
+		Errs        *xmlErrors      `xml:"errs,omitempty"`
+	}
+	xmlErrorExtras struct {
+		Items []xmlErrorExtra `xml:"item"`
+	}
+	xmlErrors struct {
+		Items []*xmlError `xml:"error"`
+	}
+	xmlErrorExtra struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}
+)
+
+// NewNegotiator returns a new negotiator of the encoders,
+// it will throw a panic if the media type or marshal of encoder is nil.
+func NewNegotiator(encoders ...ResponseEncoder) *Negotiator {
+	n := &Negotiator{}
+	for _, encoder := range encoders {
+		n.Register(encoder)
+	}
+	return n
+}
+
+// NewDefaultNegotiator returns a new negotiator of json, xml, msgpack, csv and text
+func NewDefaultNegotiator() *Negotiator {
+	return NewNegotiator(
+		ResponseEncoder{
+			MediaType:   "application/json",
+			ContentType: elton.MIMEApplicationJSON,
+			Marshal:     json.Marshal,
+		},
+		ResponseEncoder{
+			MediaType:   "application/xml",
+			ContentType: MIMEApplicationXML,
+			Marshal:     marshalXML,
+		},
+		ResponseEncoder{
+			MediaType:   "application/msgpack",
+			ContentType: MIMEApplicationMsgpack,
+			Marshal:     marshalMsgpack,
+		},
+		ResponseEncoder{
+			MediaType:   "text/csv",
+			ContentType: MIMETextCSV,
+			Marshal:     marshalCSV,
+		},
+		ResponseEncoder{
+			MediaType:   "text/plain",
+			ContentType: elton.MIMETextPlain,
+			Marshal:     marshalText,
+		},
+	)
+}
+
+// Register adds the encoder to negotiator, the encoder of the same
+// media type is replaced. It will throw a panic if the media type
+// or marshal of encoder is nil.
+func (n *Negotiator) Register(encoder ResponseEncoder) *Negotiator {
+	if encoder.MediaType == "" || encoder.Marshal == nil {
+		panic(ErrNegotiatorRequireEncoder)
+	}
+	encoder.MediaType = strings.ToLower(encoder.MediaType)
+	if encoder.ContentType == "" {
+		encoder.ContentType = encoder.MediaType
+	}
+	index := slices.IndexFunc(n.encoders, func(item *ResponseEncoder) bool {
+		return item.MediaType == encoder.MediaType
+	})
+	if index >= 0 {
+		n.encoders[index] = &encoder
+	} else {
+		n.encoders = append(n.encoders, &encoder)
+	}
+	return n
+}
+
+// MediaTypes returns the media types of encoders
+func (n *Negotiator) MediaTypes() []string {
+	types := make([]string, len(n.encoders))
+	for i, encoder := range n.encoders {
+		types[i] = encoder.MediaType
+	}
+	return types
+}
+
+// Negotiate returns the acceptable encoders of Accept header, they are sorted
+// by quality and the order of registration. All encoders are acceptable if
+// the Accept header is empty.
+func (n *Negotiator) Negotiate(accept string) []*ResponseEncoder {
+	if strings.TrimSpace(accept) == "" {
+		return slices.Clone(n.encoders)
+	}
+	ranges := parseAccept(accept)
+	type candidate struct {
+		encoder *ResponseEncoder
+		q       float64
+	}
+	candidates := make([]candidate, 0, len(n.encoders))
+	for _, encoder := range n.encoders {
+		if q := acceptQuality(ranges, encoder.MediaType); q > 0 {
+			candidates = append(candidates, candidate{
+				encoder: encoder,
+				q:       q,
+			})
+		}
+	}
+	// 相同q值的按注册顺序
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	encoders := make([]*ResponseEncoder, len(candidates))
+	for i, item := range candidates {
+		encoders[i] = item.encoder
+	}
+	return encoders
+}
+
+// Marshal marshals the value by the acceptable encoders, the encoder which
+// returns errors.ErrUnsupported is skipped. It returns ErrNotAcceptable if
+// none of the encoders is acceptable.
+func (n *Negotiator) Marshal(accept string, v any) ([]byte, string, error) {
+	for _, encoder := range n.Negotiate(accept) {
+		buf, err := encoder.Marshal(v)
+		if errors.Is(err, errors.ErrUnsupported) {
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		return buf, encoder.ContentType, nil
+	}
+	return nil, "", ErrNotAcceptable
+}
+
+// parseAccept parses the media ranges of Accept header,
+// the range with invalid q value is ignored.
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+	for item := range strings.SplitSeq(accept, ",") {
+		mediaType, params, _ := strings.Cut(item, ";")
+		typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(mediaType)), "/")
+		if !ok || typ == "" || subtype == "" || (typ == "*" && subtype != "*") {
+			continue
+		}
+		r := acceptRange{
+			typ:     typ,
+			subtype: subtype,
+			q:       1,
+		}
+		valid := true
+		for param := range strings.SplitSeq(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if !strings.EqualFold(key, "q") {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || q < 0 || q > 1 {
+				valid = false
+				break
+			}
+			r.q = q
+		}
+		if valid {
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges
+}
+
+// acceptQuality returns the quality of media type, the most
+// specific matched range is used, 0 means not acceptable.
+func acceptQuality(ranges []acceptRange, mediaType string) float64 {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	q := 0.0
+	specificity := -1
+	for _, r := range ranges {
+		s := 0
+		switch {
+		case r.typ == typ && r.subtype == subtype:
+			s = 2
+		case r.typ == typ && r.subtype == "*":
+			s = 1
+		case r.typ == "*":
+			s = 0
+		default:
+			continue
+		}
+		if s > specificity {
+			specificity = s
+			q = r.q
+		}
+	}
+	return q
+}
+
+// addVary adds the value to Vary header if it's not exists
+func addVary(c *elton.Context, value string) {
+	for _, item := range c.Header().Values(headerVary) {
+		for v := range strings.SplitSeq(item, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), value) {
+				return
+			}
+		}
+	}
+	c.AddHeader(headerVary, value)
+}
+
+// marshalText marshals string, []byte, error and fmt.Stringer as text
+func marshalText(v any) ([]byte, error) {
+	switch data := v.(type) {
+	case string:
+		return []byte(data), nil
+	case []byte:
+		return data, nil
+	case error:
+		return []byte(data.Error()), nil
+	case fmt.Stringer:
+		return []byte(data.String()), nil
+	}
+	return nil, errors.ErrUnsupported
+}
+
+// marshalXML marshals the value as xml, hes.Error is converted
+// as its extra can not be marshaled by encoding/xml.
+func marshalXML(v any) ([]byte, error) {
+	if he, ok := v.(*hes.Error); ok {
+		v = newXMLError(he)
+	}
+	buf, err := xml.Marshal(v)
+	if err != nil {
+		var unsupported *xml.UnsupportedTypeError
+		if errors.As(err, &unsupported) {
+			return nil, errors.ErrUnsupported
+		}
+		return nil, err
+	}
+	return buf, nil
+}
+
+func newXMLError(he *hes.Error) *xmlError {
+	e := &xmlError{
+		StatusCode:  he.StatusCode,
+		Code:        he.Code,
+		Category:    he.Category,
+		SubCategory: he.SubCategory,
+		Title:       he.Title,
+		Message:     he.Message,
+		Exception:   he.Exception,
+		File:        he.File,
+		Line:        he.Line,
+	}
+	if len(he.Extra) != 0 {
+		keys := make([]string, 0, len(he.Extra))
+		for key := range he.Extra {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		e.Extra = &xmlErrorExtras{}
+		for _, key := range keys {
+			e.Extra.Items = append(e.Extra.Items, xmlErrorExtra{
+				Key:   key,
+				Value: fmt.Sprint(he.Extra[key]),
+			})
+		}
+	}
+	if len(he.Errs) != 0 {
+		e.Errs = &xmlErrors{}
+		for _, item := range he.Errs {
+			e.Errs.Items = append(e.Errs.Items, newXMLError(item))
+		}
+	}
+	return e
+}
+
+// marshalCSV marshals [][]string or slice of struct as csv, the header
+// of struct is the json name of field(or field name).
+func marshalCSV(v any) ([]byte, error) {
+	var records [][]string
+	if data, ok := v.([][]string); ok {
+		records = data
+	} else {
+		rv := reflect.ValueOf(v)
+		for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+			rv = rv.Elem()
+		}
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return nil, errors.ErrUnsupported
+		}
+		t := rv.Type().Elem()
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, errors.ErrUnsupported
+		}
+		var header []string
+		var indexes []int
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			header = append(header, name)
+			indexes = append(indexes, i)
+		}
+		records = append(records, header)
+		for i := 0; i < rv.Len(); i++ {
+			item := rv.Index(i)
+			for item.Kind() == reflect.Pointer && !item.IsNil() {
+				item = item.Elem()
+			}
+			record := make([]string, len(indexes))
+			if item.Kind() == reflect.Struct {
+				for j, index := range indexes {
+					record[j] = fmt.Sprint(item.Field(index).Interface())
+				}
+			}
+			records = append(records, record)
+		}
+	}
+	b := &bytes.Buffer{}
+	w := csv.NewWriter(b)
+	if err := w.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
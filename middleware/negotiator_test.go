@@ -0,0 +1,253 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton/v2"
+	"github.com/vicanso/hes"
+)
+
+type negotiatorUser struct {
+	ID      int    `json:"id" xml:"id"`
+	Account string `json:"account" xml:"account"`
+	Token   string `json:"-" xml:"-"`
+}
+
+func TestParseAccept(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]acceptRange{
+		{
+			typ:     "application",
+			subtype: "json",
+			q:       1,
+		},
+		{
+			typ:     "text",
+			subtype: "*",
+			q:       0.5,
+		},
+		{
+			typ:     "*",
+			subtype: "*",
+			q:       0,
+		},
+	}, parseAccept("Application/JSON, text/*;q=0.5, invalid, */json, text/html;q=2, */*;Q=0"))
+
+	ranges := parseAccept("text/*;q=0.3, text/csv;q=0.8, */*;q=0.1")
+	assert.Equal(0.8, acceptQuality(ranges, "text/csv"))
+	assert.Equal(0.3, acceptQuality(ranges, "text/plain"))
+	assert.Equal(0.1, acceptQuality(ranges, "application/json"))
+	assert.Equal(0.0, acceptQuality(parseAccept("text/html"), "application/json"))
+}
+
+func TestNegotiator(t *testing.T) {
+	assert := assert.New(t)
+
+	n := NewDefaultNegotiator()
+	assert.Equal([]string{
+		"application/json",
+		"application/xml",
+		"application/msgpack",
+		"text/csv",
+		"text/plain",
+	}, n.MediaTypes())
+
+	assert.Equal(n.MediaTypes(), mediaTypesOf(n.Negotiate("")))
+	assert.Equal([]string{
+		"application/xml",
+		"application/json",
+		"application/msgpack",
+		"text/csv",
+		"text/plain",
+	}, mediaTypesOf(n.Negotiate("application/xml, */*;q=0.8")))
+	// q为0表示不可接受
+	assert.Equal([]string{
+		"text/plain",
+	}, mediaTypesOf(n.Negotiate("text/*, text/csv;q=0")))
+	assert.Empty(n.Negotiate("image/png"))
+
+	user := &negotiatorUser{
+		ID:      1,
+		Account: "tree",
+	}
+	buf, contentType, err := n.Marshal("", user)
+	assert.Nil(err)
+	assert.Equal(elton.MIMEApplicationJSON, contentType)
+	assert.Equal(`{"id":1,"account":"tree"}`, string(buf))
+
+	buf, contentType, err = n.Marshal("application/xml", user)
+	assert.Nil(err)
+	assert.Equal(MIMEApplicationXML, contentType)
+	assert.Equal(`<negotiatorUser><id>1</id><account>tree</account></negotiatorUser>`, string(buf))
+
+	buf, contentType, err = n.Marshal("text/csv", []*negotiatorUser{user})
+	assert.Nil(err)
+	assert.Equal(MIMETextCSV, contentType)
+	assert.Equal("id,account\n1,tree\n", string(buf))
+
+	// csv不支持则使用下一个可接受的encoder
+	buf, contentType, err = n.Marshal("text/csv, application/json;q=0.5", user)
+	assert.Nil(err)
+	assert.Equal(elton.MIMEApplicationJSON, contentType)
+	assert.Equal(`{"id":1,"account":"tree"}`, string(buf))
+
+	_, _, err = n.Marshal("text/csv", user)
+	assert.Equal(ErrNotAcceptable, err)
+	_, _, err = n.Marshal("image/png", user)
+	assert.Equal(ErrNotAcceptable, err)
+
+	he := hes.New("invalid params", hes.WithStatus(400))
+	he = he.WithExtra("field", "account")
+	buf, contentType, err = n.Marshal("application/xml", he)
+	assert.Nil(err)
+	assert.Equal(MIMEApplicationXML, contentType)
+	assert.Equal(`<error><statusCode>400</statusCode><message>invalid params</message><extra><item key="field">account</item></extra></error>`, string(buf))
+
+	// 注册新的encoder或替换已有的
+	n.Register(ResponseEncoder{
+		MediaType: "application/msgpack",
+		Marshal: func(v any) ([]byte, error) {
+			return []byte("msgpack"), nil
+		},
+	})
+	buf, contentType, err = n.Marshal("application/msgpack", user)
+	assert.Nil(err)
+	assert.Equal("application/msgpack", contentType)
+	assert.Equal("msgpack", string(buf))
+	n.Register(ResponseEncoder{
+		MediaType: "application/json",
+		Marshal: func(v any) ([]byte, error) {
+			return nil, errors.New("marshal fail")
+		},
+	})
+	_, _, err = n.Marshal("application/json", user)
+	assert.Equal("marshal fail", err.Error())
+
+	assert.Panics(func() {
+		n.Register(ResponseEncoder{
+			MediaType: "application/yaml",
+		})
+	})
+}
+
+func mediaTypesOf(encoders []*ResponseEncoder) []string {
+	types := make([]string, len(encoders))
+	for i, encoder := range encoders {
+		types[i] = encoder.MediaType
+	}
+	return types
+}
+
+func TestNegotiatorMiddleware(t *testing.T) {
+	assert := assert.New(t)
+
+	n := NewDefaultNegotiator()
+	e := elton.New()
+	e.Use(NewError(ErrorConfig{
+		Negotiator: n,
+	}))
+	e.Use(NewResponder(ResponderConfig{
+		Negotiator: n,
+	}))
+	e.GET("/users", func(c *elton.Context) error {
+		c.Body = []*negotiatorUser{
+			{
+				ID:      1,
+				Account: "tree",
+			},
+		}
+		return nil
+	})
+	e.GET("/users-json", func(c *elton.Context) error {
+		c.SetContentTypeByExt(".json")
+		c.Body = &negotiatorUser{
+			ID:      1,
+			Account: "tree",
+		}
+		return nil
+	})
+	e.GET("/error", func(c *elton.Context) error {
+		return hes.New("custom error", hes.WithStatus(400))
+	})
+
+	req := httptest.NewRequest("GET", "/users", nil)
+	req.Header.Set("Accept", "text/csv")
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal(MIMETextCSV, resp.Header().Get(elton.HeaderContentType))
+	assert.Equal("Accept", resp.Header().Get("Vary"))
+	assert.Equal("id,account\n1,tree\n", resp.Body.String())
+
+	req = httptest.NewRequest("GET", "/users", nil)
+	req.Header.Set("Accept", "application/msgpack")
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal(MIMEApplicationMsgpack, resp.Header().Get(elton.HeaderContentType))
+	assert.Equal([]byte{
+		0x91, 0x82,
+		0xa7, 'a', 'c', 'c', 'o', 'u', 'n', 't', 0xa4, 't', 'r', 'e', 'e',
+		0xa2, 'i', 'd', 0x01,
+	}, resp.Body.Bytes())
+
+	// 已设置的Content-Type会被所选encoder的覆盖
+	req = httptest.NewRequest("GET", "/users-json", nil)
+	req.Header.Set("Accept", "application/xml")
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal(MIMEApplicationXML, resp.Header().Get(elton.HeaderContentType))
+
+	req = httptest.NewRequest("GET", "/users", nil)
+	req.Header.Set("Accept", "image/png")
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(http.StatusNotAcceptable, resp.Code)
+	assert.Equal(elton.MIMETextPlain, resp.Header().Get(elton.HeaderContentType))
+	assert.Equal([]string{"Accept"}, resp.Header().Values("Vary"))
+	assert.Equal("statusCode=406, category=elton-negotiator, message=not acceptable", resp.Body.String())
+
+	req = httptest.NewRequest("GET", "/error", nil)
+	req.Header.Set("Accept", "application/json")
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(http.StatusBadRequest, resp.Code)
+	assert.Equal(elton.MIMEApplicationJSON, resp.Header().Get(elton.HeaderContentType))
+	assert.Equal(`{"statusCode":400,"message":"custom error"}`, resp.Body.String())
+
+	req = httptest.NewRequest("GET", "/error", nil)
+	req.Header.Set("Accept", "application/xml")
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(http.StatusBadRequest, resp.Code)
+	assert.Equal(MIMEApplicationXML, resp.Header().Get(elton.HeaderContentType))
+	assert.Equal(`<error><statusCode>400</statusCode><message>custom error</message></error>`, resp.Body.String())
+}
@@ -0,0 +1,158 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+
+	"github.com/vicanso/elton/v2"
+	"github.com/vicanso/hes"
+)
+
+const (
+	// MIMEApplicationProblemJSON problem details json(RFC 9457)
+	MIMEApplicationProblemJSON = "application/problem+json"
+	// ProblemTypeBlank the default problem type
+	ProblemTypeBlank = "about:blank"
+)
+
+type (
+	// ProblemConfig the config of problem details(RFC 9457) response
+	ProblemConfig struct {
+		// Types the type uri of error category, e.g.
+		// "elton-jwt": "https://example.com/problems/unauthorized"
+		Types map[string]string
+		// DefaultType the type uri of the category which is not in Types,
+		// default is about:blank
+		DefaultType string
+		// Instance returns the instance of problem, default is
+		// the request id of NewRequestID(or Context.ID)
+		Instance func(c *elton.Context) string
+	}
+	// Problem the problem details(RFC 9457), the extension members
+	// are marshaled at the same level as the standard members.
+	Problem struct {
+		Type     string
+		Title    string
+		Status   int
+		Detail   string
+		Instance string
+		// Extensions the extension members, e.g. category, code and errors
+		Extensions map[string]any
+	}
+	// ProblemError the validation error of problem's "errors" extension
+	ProblemError struct {
+		// Detail the message of error
+		Detail string `json:"detail"`
+		// Field the field of request params
+		Field string `json:"field,omitempty"`
+		// Code the code of error, e.g. the rule of validate
+		Code string `json:"code,omitempty"`
+	}
+)
+
+// NewProblem converts the hes.Error to problem details. The status, title(or
+// the status text), message and type uri of category are mapped to status,
+// title, detail and type. The category, sub category, code and extra of error
+// are added as extensions, the sub errors(e.g. validation errors of Bind) are
+// added as "errors" extension.
+func NewProblem(c *elton.Context, he *hes.Error, config ProblemConfig) *Problem {
+	status := he.StatusOrInternal()
+	p := &Problem{
+		Type:   config.DefaultType,
+		Title:  he.Title,
+		Status: status,
+		Detail: he.Message,
+	}
+	if p.Type == "" {
+		p.Type = ProblemTypeBlank
+	}
+	if typ, ok := config.Types[he.Category]; ok {
+		p.Type = typ
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(status)
+	}
+	if config.Instance != nil {
+		p.Instance = config.Instance(c)
+	} else if c != nil {
+		p.Instance = GetRequestID(c)
+		if p.Instance == "" {
+			p.Instance = c.ID
+		}
+	}
+	ext := make(map[string]any)
+	maps.Copy(ext, he.Extra)
+	for key, value := range map[string]string{
+		"category":    he.Category,
+		"subCategory": he.SubCategory,
+		"code":        he.Code,
+	} {
+		if value != "" {
+			ext[key] = value
+		}
+	}
+	if len(he.Errs) != 0 {
+		errs := make([]ProblemError, len(he.Errs))
+		for i, item := range he.Errs {
+			errs[i] = ProblemError{
+				Detail: item.Message,
+				Code:   item.Code,
+			}
+			if field, ok := item.Extra["field"].(string); ok {
+				errs[i].Field = field
+			}
+		}
+		ext["errors"] = errs
+	}
+	if len(ext) != 0 {
+		p.Extensions = ext
+	}
+	return p
+}
+
+// MarshalJSON marshals the problem as json, the extension members
+// can not override the standard members.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]any, len(p.Extensions)+5)
+	maps.Copy(m, p.Extensions)
+	for key, value := range map[string]string{
+		"type":     p.Type,
+		"title":    p.Title,
+		"detail":   p.Detail,
+		"instance": p.Instance,
+	} {
+		if value != "" {
+			m[key] = value
+		} else {
+			delete(m, key)
+		}
+	}
+	delete(m, "status")
+	if p.Status != 0 {
+		m["status"] = p.Status
+	}
+	return json.Marshal(m)
+}
@@ -0,0 +1,143 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton/v2"
+	"github.com/vicanso/hes"
+)
+
+func TestNewProblem(t *testing.T) {
+	assert := assert.New(t)
+
+	he := hes.New("token is expired", hes.WithStatus(http.StatusUnauthorized), hes.WithCategory(ErrJWTCategory))
+	he.Code = "expired"
+	he = he.WithExtra("expiredAt", "2026-01-01")
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	c.ID = "abc"
+	config := ProblemConfig{
+		Types: map[string]string{
+			ErrJWTCategory: "https://example.com/problems/unauthorized",
+		},
+	}
+	p := NewProblem(c, he, config)
+	assert.Equal(&Problem{
+		Type:     "https://example.com/problems/unauthorized",
+		Title:    "Unauthorized",
+		Status:   http.StatusUnauthorized,
+		Detail:   "token is expired",
+		Instance: "abc",
+		Extensions: map[string]any{
+			"category":  ErrJWTCategory,
+			"code":      "expired",
+			"expiredAt": "2026-01-01",
+		},
+	}, p)
+	buf, err := json.Marshal(p)
+	assert.Nil(err)
+	assert.Equal(`{"category":"elton-jwt","code":"expired","detail":"token is expired","expiredAt":"2026-01-01","instance":"abc","status":401,"title":"Unauthorized","type":"https://example.com/problems/unauthorized"}`, string(buf))
+
+	// 扩展字段不能覆盖标准字段
+	he = &hes.Error{
+		Title:   "custom",
+		Message: "error",
+		Extra: map[string]any{
+			"status": 200,
+			"type":   "override",
+		},
+	}
+	c.Set(ContextKeyRequestID, "request-id")
+	p = NewProblem(c, he, ProblemConfig{
+		DefaultType: "https://example.com/problems/default",
+	})
+	assert.Equal("request-id", p.Instance)
+	buf, err = json.Marshal(p)
+	assert.Nil(err)
+	assert.Equal(`{"detail":"error","instance":"request-id","status":500,"title":"custom","type":"https://example.com/problems/default"}`, string(buf))
+
+	p = NewProblem(nil, he, ProblemConfig{
+		Instance: func(_ *elton.Context) string {
+			return "/errors/1"
+		},
+	})
+	assert.Equal("/errors/1", p.Instance)
+	assert.Equal(ProblemTypeBlank, p.Type)
+}
+
+func TestErrorProblem(t *testing.T) {
+	assert := assert.New(t)
+
+	type params struct {
+		Account string `json:"account" validate:"required"`
+		Limit   int    `query:"limit" validate:"max=100"`
+	}
+
+	e := elton.New()
+	e.Use(NewDefaultRequestID())
+	e.Use(NewError(ErrorConfig{
+		Problem: &ProblemConfig{
+			Types: map[string]string{
+				elton.ErrCategory: "https://example.com/problems/invalid-params",
+			},
+		},
+	}))
+	e.GET("/", func(c *elton.Context) error {
+		_, err := elton.Bind[params](c)
+		return err
+	})
+
+	req := httptest.NewRequest("GET", "/?limit=1000", nil)
+	req.Header.Set(HeaderXRequestID, "request-id")
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(http.StatusBadRequest, resp.Code)
+	assert.Equal(MIMEApplicationProblemJSON, resp.Header().Get(elton.HeaderContentType))
+	assert.Equal(`{"category":"elton","detail":"request params are invalid","errors":[{"detail":"account is required","field":"account","code":"required"},{"detail":"limit should be at most 100","field":"limit","code":"max"}],"instance":"request-id","status":400,"title":"Bad Request","type":"https://example.com/problems/invalid-params"}`, resp.Body.String())
+}
+
+func TestRecoverProblem(t *testing.T) {
+	assert := assert.New(t)
+
+	e := elton.New()
+	e.Use(NewDefaultRequestID())
+	e.Use(NewRecoverWithConfig(RecoverConfig{
+		Problem: &ProblemConfig{},
+	}))
+	e.GET("/", func(c *elton.Context) error {
+		panic("abc")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(HeaderXRequestID, "request-id")
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(http.StatusInternalServerError, resp.Code)
+	assert.Equal(MIMEApplicationProblemJSON, resp.Header().Get(elton.HeaderContentType))
+	assert.Equal(`{"category":"elton-recover","detail":"abc","instance":"request-id","status":500,"title":"Internal Server Error","type":"about:blank"}`, resp.Body.String())
+}
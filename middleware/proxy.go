@@ -23,15 +23,20 @@
 package middleware
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/vicanso/elton/v2"
 	"github.com/vicanso/hes"
@@ -71,7 +76,38 @@ type (
 		Transport    http.RoundTripper
 		TargetPicker ProxyTargetPicker
 		Skipper      elton.Skipper
+		// CircuitBreaker the circuit breaker of targets, the request
+		// to the target whose circuit is open is rejected
+		CircuitBreaker *CircuitBreaker
+		// Retry the retry policy of idempotent requests
+		Retry *ProxyRetryConfig
 	}
+	// ProxyRetryConfig the retry policy of proxy, the idempotent request is
+	// retried if the proxy fails(e.g. connection refused) or the circuit is open,
+	// the target is picked again by TargetPicker for each attempt.
+	ProxyRetryConfig struct {
+		// Attempts the max attempts including the first one, default is 3
+		Attempts int
+		// Backoff the wait duration before the second attempt, it is doubled
+		// for the next attempt, default is 50ms
+		Backoff time.Duration
+		// MaxBackoff the max wait duration, default is 1s
+		MaxBackoff time.Duration
+		// Methods the idempotent methods which can be retried,
+		// default is GET, HEAD, OPTIONS, PUT, DELETE and TRACE
+		Methods []string
+		// StatusCodes the status codes of upstream response which can be retried,
+		// the response of last attempt is returned, default is 502, 503 and 504
+		StatusCodes []int
+	}
+	proxyRetry struct {
+		attempts    int
+		backoff     time.Duration
+		maxBackoff  time.Duration
+		methods     []string
+		statusCodes []int
+	}
+	proxyRetryStatusKey struct{}
 
 	rewriteRegexp struct {
 		Regexp *regexp.Regexp
@@ -162,11 +198,21 @@ func newReverseProxy(target *url.URL, config *ProxyConfig, bufPool httputil.Buff
 		p.Transport = config.Transport
 	}
 	p.BufferPool = bufPool
+	// 透传分布式追踪的trace context，上游的parent为当前span
+	director := p.Director
+	p.Director = func(req *http.Request) {
+		director(req)
+		elton.InjectTraceContext(req.Context(), req.Header)
+	}
+	p.ModifyResponse = modifyProxyRetryResponse
 	p.ErrorHandler = func(rw http.ResponseWriter, _ *http.Request, e error) {
-		he := hes.Wrap(e,
-			hes.WithStatus(http.StatusBadGateway),
-			hes.WithCategory(ErrProxyCategory),
-			hes.WithException())
+		he, ok := e.(*hes.Error)
+		if !ok {
+			he = hes.Wrap(e,
+				hes.WithStatus(http.StatusBadGateway),
+				hes.WithCategory(ErrProxyCategory),
+				hes.WithException())
+		}
 		if c, ok := rw.(*elton.Context); ok {
 			c.Set(proxyErrorKey, he)
 		}
@@ -193,6 +239,8 @@ func NewProxy(config ProxyConfig) elton.Handler {
 	if config.Target != nil {
 		sharedProxy = newReverseProxy(config.Target, &config, bufPool)
 	}
+	retry := newProxyRetry(config.Retry)
+	breaker := config.CircuitBreaker
 	return func(c *elton.Context) error {
 		if skipper(c) {
 			return c.Next()
@@ -200,24 +248,6 @@ func NewProxy(config ProxyConfig) elton.Handler {
 		if config.Done != nil {
 			defer config.Done(c)
 		}
-		target := config.Target
-		p := sharedProxy
-		if target == nil {
-			t, done, err := config.TargetPicker(c)
-			if err != nil {
-				return err
-			}
-			if done != nil {
-				defer done(c)
-			}
-			// 如果无target，则抛错
-			if t == nil {
-				return ErrProxyTargetIsNil
-			}
-			target = t
-			p = newReverseProxy(target, &config, bufPool)
-		}
-		c.Set(ProxyTargetKey, target.String())
 		req := c.Request
 		var originalPath, originalHost string
 		if len(rewrites) != 0 {
@@ -228,9 +258,34 @@ func NewProxy(config ProxyConfig) elton.Handler {
 			originalHost = req.Host
 			req.Host = config.Host
 		}
-		p.ServeHTTP(c, req)
-		if value, exists := c.Get(proxyErrorKey); exists {
-			return value.(error)
+		attempts := 1
+		if retry != nil && retry.retryable(c) {
+			attempts = retry.attempts
+		}
+		var err error
+		for attempt := 0; attempt < attempts; attempt++ {
+			if attempt != 0 {
+				// 熔断的target未发送请求，无需等待
+				if !errors.Is(err, ErrCircuitOpen) {
+					if err = retry.wait(c, attempt); err != nil {
+						return err
+					}
+				}
+				if err = resetProxyBody(c); err != nil {
+					return err
+				}
+			}
+			err = proxyAttempt(c, &config, sharedProxy, bufPool, breaker, attempt != attempts-1, retry)
+			if err == nil {
+				break
+			}
+			// 非代理的出错（如target picker）不重试
+			if !isProxyRetryError(err) {
+				return err
+			}
+		}
+		if err != nil {
+			return err
 		}
 		if originalPath != "" {
 			req.URL.Path = originalPath
@@ -241,3 +296,160 @@ func NewProxy(config ProxyConfig) elton.Handler {
 		return c.Next()
 	}
 }
+
+// proxyAttempt picks the target and proxies the request once,
+// the retryable status of upstream is returned as error if canRetry is true.
+func proxyAttempt(c *elton.Context, config *ProxyConfig, p *httputil.ReverseProxy, bufPool httputil.BufferPool, breaker *CircuitBreaker, canRetry bool, retry *proxyRetry) error {
+	target := config.Target
+	if target == nil {
+		t, done, err := config.TargetPicker(c)
+		if err != nil {
+			return err
+		}
+		if done != nil {
+			defer done(c)
+		}
+		// 如果无target，则抛错
+		if t == nil {
+			return ErrProxyTargetIsNil
+		}
+		target = t
+		p = newReverseProxy(target, config, bufPool)
+	}
+	key := target.String()
+	c.Set(ProxyTargetKey, key)
+	c.Set(proxyErrorKey, nil)
+	if breaker != nil {
+		if err := breaker.Allow(key); err != nil {
+			// 熔断时未发送请求，记录出错以免done回调按成功上报（如Upstream重置失败次数）
+			c.Set(proxyErrorKey, err)
+			return err
+		}
+	}
+	req := c.Request
+	if canRetry && retry != nil {
+		// 可重试的响应状态码由ModifyResponse转换为出错
+		req = req.WithContext(context.WithValue(req.Context(), proxyRetryStatusKey{}, retry.statusCodes))
+	}
+	p.ServeHTTP(c, req)
+	var err error
+	if value, _ := c.Get(proxyErrorKey); value != nil {
+		err = value.(error)
+	}
+	if breaker != nil {
+		breaker.Report(key, err == nil && c.StatusCode < http.StatusInternalServerError)
+	}
+	return err
+}
+
+func newProxyRetry(config *ProxyRetryConfig) *proxyRetry {
+	if config == nil {
+		return nil
+	}
+	r := &proxyRetry{
+		attempts:    config.Attempts,
+		backoff:     config.Backoff,
+		maxBackoff:  config.MaxBackoff,
+		methods:     config.Methods,
+		statusCodes: config.StatusCodes,
+	}
+	if r.attempts <= 0 {
+		r.attempts = 3
+	}
+	if r.backoff <= 0 {
+		r.backoff = 50 * time.Millisecond
+	}
+	if r.maxBackoff <= 0 {
+		r.maxBackoff = time.Second
+	}
+	if len(r.methods) == 0 {
+		r.methods = []string{
+			http.MethodGet,
+			http.MethodHead,
+			http.MethodOptions,
+			http.MethodPut,
+			http.MethodDelete,
+			http.MethodTrace,
+		}
+	}
+	if len(r.statusCodes) == 0 {
+		r.statusCodes = []int{
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		}
+	}
+	return r
+}
+
+// retryable returns true if the method is idempotent and the body can be resent
+func (r *proxyRetry) retryable(c *elton.Context) bool {
+	req := c.Request
+	if !slices.Contains(r.methods, req.Method) {
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil || c.RequestBody != nil
+}
+
+// wait waits the backoff duration of attempt, the error of context
+// is returned if the request is canceled.
+func (r *proxyRetry) wait(c *elton.Context, attempt int) error {
+	d := r.backoff << (attempt - 1)
+	if d > r.maxBackoff || d <= 0 {
+		d = r.maxBackoff
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-c.Context().Done():
+		return c.Context().Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// resetProxyBody resets the request body for the next attempt
+func resetProxyBody(c *elton.Context) error {
+	req := c.Request
+	switch {
+	case req.GetBody != nil:
+		body, err := req.GetBody()
+		if err != nil {
+			return err
+		}
+		req.Body = body
+	case c.RequestBody != nil:
+		req.Body = io.NopCloser(bytes.NewReader(c.RequestBody))
+		req.ContentLength = int64(len(c.RequestBody))
+	}
+	return nil
+}
+
+// modifyProxyRetryResponse converts the retryable status of upstream to error,
+// the response of the last attempt is not converted.
+func modifyProxyRetryResponse(resp *http.Response) error {
+	statusCodes, _ := resp.Request.Context().Value(proxyRetryStatusKey{}).([]int)
+	if !slices.Contains(statusCodes, resp.StatusCode) {
+		return nil
+	}
+	return &hes.Error{
+		StatusCode: resp.StatusCode,
+		Message:    fmt.Sprintf("upstream responds %d", resp.StatusCode),
+		Category:   ErrProxyCategory,
+	}
+}
+
+// GetProxyError returns the error of the last proxy attempt, it can be used
+// in ProxyDone to check whether the attempt is failed.
+func GetProxyError(c *elton.Context) error {
+	return elton.GetContextValue[error](c, proxyErrorKey)
+}
+
+// isProxyRetryError returns true if the error is returned by proxy or circuit breaker
+func isProxyRetryError(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) {
+		return true
+	}
+	var he *hes.Error
+	return errors.As(err, &he) && he.Category == ErrProxyCategory && he != ErrProxyTargetIsNil
+}
@@ -25,10 +25,15 @@ package middleware
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -335,3 +340,219 @@ func BenchmarkProxy(b *testing.B) {
 
 	}
 }
+
+func TestProxyTraceContext(t *testing.T) {
+	assert := assert.New(t)
+
+	var traceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get(elton.HeaderTraceparent)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+	target, _ := url.Parse(upstream.URL)
+
+	exporter := elton.NewInMemoryExporter()
+	e := elton.New()
+	e.Tracer = elton.NewTracer(elton.TracerConfig{
+		Exporter: exporter,
+	})
+	fn := NewProxy(ProxyConfig{
+		Target: target,
+	})
+	e.SetFunctionName(fn, "proxy")
+	e.GET("/", fn)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(elton.HeaderTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+
+	spans := exporter.Spans()
+	assert.Equal(2, len(spans))
+	// 上游的parent为proxy中间件的span
+	assert.Equal("proxy", spans[1].Name)
+	assert.Equal(spans[1].SpanContext.Traceparent(), traceparent)
+}
+
+// newClosedTarget returns the url of a closed server, the request to it fails
+func newClosedTarget() *url.URL {
+	server := httptest.NewServer(http.NotFoundHandler())
+	target, _ := url.Parse(server.URL)
+	server.Close()
+	return target
+}
+
+func TestProxyRetry(t *testing.T) {
+	assert := assert.New(t)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte("ok:" + string(body)))
+	}))
+	defer upstream.Close()
+	okTarget, _ := url.Parse(upstream.URL)
+	badTarget := newClosedTarget()
+
+	var changes []string
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{
+		MinRequests: 1,
+		OpenTimeout: time.Minute,
+		OnStateChange: func(target string, from, to CircuitState) {
+			changes = append(changes, target+":"+to.String())
+		},
+	})
+	var mu sync.Mutex
+	index := 0
+	var released []string
+	e := elton.New()
+	e.Use(NewBodyParser(BodyParserConfig{}))
+	fn := NewProxy(ProxyConfig{
+		// 轮询选择target
+		TargetPicker: func(c *elton.Context) (*url.URL, ProxyDone, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			targets := []*url.URL{
+				badTarget,
+				okTarget,
+			}
+			target := targets[index%len(targets)]
+			index++
+			return target, func(_ *elton.Context) {
+				released = append(released, target.String())
+			}, nil
+		},
+		CircuitBreaker: breaker,
+		Retry: &ProxyRetryConfig{
+			Backoff: time.Millisecond,
+		},
+	})
+	e.GET("/", fn)
+	e.PUT("/", fn)
+	e.POST("/", fn)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal("ok:", resp.Body.String())
+	assert.Equal([]string{badTarget.String(), okTarget.String()}, released)
+	assert.Equal(CircuitOpen, breaker.State(badTarget.String()))
+	assert.Equal(CircuitClosed, breaker.State(okTarget.String()))
+	assert.Equal([]string{badTarget.String() + ":open"}, changes)
+
+	// 熔断的target不发送请求，直接重试下一个
+	released = nil
+	req = httptest.NewRequest("PUT", "/", strings.NewReader("data"))
+	req.Header.Set(elton.HeaderContentType, "application/json")
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal("ok:data", resp.Body.String())
+	assert.Equal([]string{badTarget.String(), okTarget.String()}, released)
+
+	// 非幂等的请求不重试
+	released = nil
+	req = httptest.NewRequest("POST", "/", nil)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(http.StatusServiceUnavailable, resp.Code)
+	assert.Equal([]string{badTarget.String()}, released)
+}
+
+func TestProxyUpstreamCircuitBreaker(t *testing.T) {
+	assert := assert.New(t)
+
+	badTarget := newClosedTarget()
+	u := NewUpstream(UpstreamConfig{
+		Targets: []UpstreamTarget{
+			{
+				URL: badTarget,
+			},
+		},
+		MaxFails:    3,
+		FailTimeout: time.Minute,
+	})
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{
+		MinRequests: 1,
+		OpenTimeout: time.Minute,
+	})
+	e := elton.New()
+	e.GET("/", NewProxy(ProxyConfig{
+		TargetPicker:   u.Pick,
+		CircuitBreaker: breaker,
+	}))
+	// 首次请求失败后熔断，熔断拒绝的请求也计入失败次数
+	for range 3 {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+		assert.NotEqual(http.StatusOK, resp.Code)
+	}
+	assert.Equal(CircuitOpen, breaker.State(badTarget.String()))
+	assert.True(u.Status()[0].Ejected)
+
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(http.StatusServiceUnavailable, resp.Code)
+	assert.Contains(resp.Body.String(), ErrUpstreamUnavailable.Message)
+}
+
+func TestProxyRetryStatus(t *testing.T) {
+	assert := assert.New(t)
+
+	var mu sync.Mutex
+	count := 0
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		count++
+		current := count
+		mu.Unlock()
+		w.Header().Set("X-Count", strconv.Itoa(current))
+		if r.URL.Path == "/unavailable" || current == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("unavailable"))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+	target, _ := url.Parse(upstream.URL)
+
+	e := elton.New()
+	e.GET("/*", NewProxy(ProxyConfig{
+		Target: target,
+		Retry: &ProxyRetryConfig{
+			Attempts: 2,
+			Backoff:  time.Millisecond,
+		},
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal("2", resp.Header().Get("X-Count"))
+	assert.Equal("ok", resp.Body.String())
+
+	// 最后一次的响应直接返回
+	req = httptest.NewRequest("GET", "/unavailable", nil)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(http.StatusServiceUnavailable, resp.Code)
+	assert.Equal("4", resp.Header().Get("X-Count"))
+	assert.Equal("unavailable", resp.Body.String())
+}
+
+func TestIsProxyRetryError(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(isProxyRetryError(ErrCircuitOpen))
+	assert.True(isProxyRetryError(fmt.Errorf("wrap: %w", ErrCircuitOpen)))
+	he := hes.New("connection refused", hes.WithCategory(ErrProxyCategory))
+	assert.True(isProxyRetryError(he))
+	assert.True(isProxyRetryError(fmt.Errorf("wrap: %w", he)))
+	assert.False(isProxyRetryError(ErrProxyTargetIsNil))
+	assert.False(isProxyRetryError(hes.New("abc")))
+	assert.False(isProxyRetryError(errors.New("abc")))
+}
@@ -0,0 +1,297 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/vicanso/elton/v2"
+	"github.com/vicanso/hes"
+)
+
+const (
+	// ErrRateLimiterCategory rate limiter error category
+	ErrRateLimiterCategory = "elton-rate-limiter"
+	// HeaderRateLimitLimit RateLimit-Limit
+	HeaderRateLimitLimit = "RateLimit-Limit"
+	// HeaderRateLimitRemaining RateLimit-Remaining
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	// HeaderRateLimitReset RateLimit-Reset
+	HeaderRateLimitReset = "RateLimit-Reset"
+	// HeaderRetryAfter Retry-After
+	HeaderRetryAfter = "Retry-After"
+)
+
+var (
+	// ErrRateLimitExceeded rate limit exceeded
+	ErrRateLimitExceeded = &hes.Error{
+		StatusCode: http.StatusTooManyRequests,
+		Message:    "rate limit exceeded",
+		Category:   ErrRateLimiterCategory,
+	}
+	ErrRateLimiterRequireStore = errors.New("require store for rate limiter")
+	ErrRateLimiterInvalidLimit = errors.New("limit and period of rate limiter should be gt 0")
+)
+
+// RateLimitAlgorithm the algorithm of rate limit
+type RateLimitAlgorithm uint8
+
+const (
+	// RateLimitTokenBucket token bucket, allows burst up to limit
+	// and refills limit tokens per period
+	RateLimitTokenBucket RateLimitAlgorithm = iota
+	// RateLimitSlidingWindow sliding window counter, weights the
+	// previous window's count by its overlap with the sliding window
+	RateLimitSlidingWindow
+)
+
+type (
+	// RateLimitResult the result of taking from rate limit store
+	RateLimitResult struct {
+		// Allowed whether the request is allowed
+		Allowed bool
+		// Limit the max requests of period
+		Limit int
+		// Remaining the remaining requests of current period
+		Remaining int
+		// RetryAfter the duration to wait before next request is allowed,
+		// it's only set when the request is not allowed
+		RetryAfter time.Duration
+		// Reset the duration until the quota is fully restored
+		Reset time.Duration
+	}
+	// RateLimitStore rate limit store
+	RateLimitStore interface {
+		// Take consumes one request of the key, limit requests are allowed per period
+		Take(ctx context.Context, key string, limit int, period time.Duration) (*RateLimitResult, error)
+	}
+	// RateLimiterConfig rate limiter config
+	RateLimiterConfig struct {
+		// KeyGenerator generate custom key
+		KeyGenerator KeyGenerator
+		// Keys 与 ConcurrentLimiterConfig.Keys 语法一致：
+		// ":ip"、"h:name"、"q:name"、"p:name"，其它通过 BodyValue 从请求体取值
+		Keys []string
+		// BodyValue 从请求体按 name 取值。未设置时，Keys 中的 body 字段不解析。
+		BodyValue ConcurrentLimiterBodyValue
+		// NotAllowEmpty if value is empty, will return error
+		NotAllowEmpty bool
+		// Store rate limit store
+		Store RateLimitStore
+		// Limit the max requests of period
+		Limit int
+		// Period the period of limit
+		Period time.Duration
+		// DisableHeaders not set RateLimit-* headers to response
+		DisableHeaders bool
+		Skipper        elton.Skipper
+	}
+)
+
+type rateLimitEntry struct {
+	sync.Mutex
+	// token bucket: 当前令牌数与最后填充时间
+	tokens float64
+	last   time.Time
+	// sliding window: 当前窗口开始时间与前后两个窗口的计数
+	windowStart time.Time
+	prevCount   int
+	currCount   int
+}
+
+var _ RateLimitStore = (*MemoryRateLimitStore)(nil)
+
+// MemoryRateLimitStore in-memory rate limit store,
+// the keys are kept in lru cache to limit the memory usage.
+type MemoryRateLimitStore struct {
+	algorithm RateLimitAlgorithm
+	mu        sync.Mutex
+	entries   *lru.Cache[string, *rateLimitEntry]
+	now       func() time.Time
+}
+
+// NewMemoryRateLimitStore creates an in-memory rate limit store,
+// size is the max count of keys(default 1024).
+// The evicted key's quota will be restored.
+func NewMemoryRateLimitStore(algorithm RateLimitAlgorithm, size int) *MemoryRateLimitStore {
+	if size <= 0 {
+		size = 1024
+	}
+	// 只要size > 0则不会出错
+	entries, _ := lru.New[string, *rateLimitEntry](size)
+	return &MemoryRateLimitStore{
+		algorithm: algorithm,
+		entries:   entries,
+		now:       time.Now,
+	}
+}
+
+func (s *MemoryRateLimitStore) getEntry(key string) *rateLimitEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries.Get(key)
+	if !ok {
+		entry = &rateLimitEntry{
+			tokens: -1,
+		}
+		s.entries.Add(key, entry)
+	}
+	return entry
+}
+
+// Take consumes one request of the key
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, limit int, period time.Duration) (*RateLimitResult, error) {
+	entry := s.getEntry(key)
+	now := s.now()
+	entry.Lock()
+	defer entry.Unlock()
+	if s.algorithm == RateLimitSlidingWindow {
+		return entry.takeSlidingWindow(now, limit, period), nil
+	}
+	return entry.takeTokenBucket(now, limit, period), nil
+}
+
+func (entry *rateLimitEntry) takeTokenBucket(now time.Time, limit int, period time.Duration) *RateLimitResult {
+	capacity := float64(limit)
+	// 每纳秒填充的令牌数
+	rate := capacity / float64(period)
+	if entry.tokens < 0 {
+		entry.tokens = capacity
+	} else {
+		elapsed := now.Sub(entry.last)
+		entry.tokens = math.Min(capacity, entry.tokens+float64(elapsed)*rate)
+	}
+	entry.last = now
+	result := &RateLimitResult{
+		Limit: limit,
+	}
+	if entry.tokens >= 1 {
+		entry.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - entry.tokens) / rate))
+	}
+	result.Remaining = int(entry.tokens)
+	result.Reset = time.Duration(math.Ceil((capacity - entry.tokens) / rate))
+	return result
+}
+
+func (entry *rateLimitEntry) takeSlidingWindow(now time.Time, limit int, period time.Duration) *RateLimitResult {
+	if entry.windowStart.IsZero() {
+		entry.windowStart = now
+	}
+	elapsed := now.Sub(entry.windowStart)
+	if elapsed >= period {
+		windows := elapsed / period
+		// 仅跨越一个窗口时，当前窗口成为前一窗口；否则两个窗口均已无请求
+		if windows == 1 {
+			entry.prevCount = entry.currCount
+		} else {
+			entry.prevCount = 0
+		}
+		entry.currCount = 0
+		entry.windowStart = entry.windowStart.Add(windows * period)
+		elapsed = now.Sub(entry.windowStart)
+	}
+	// 前一窗口与滑动窗口重叠部分的权重
+	weight := 1 - float64(elapsed)/float64(period)
+	count := float64(entry.prevCount)*weight + float64(entry.currCount)
+	result := &RateLimitResult{
+		Limit: limit,
+		Reset: 2*period - elapsed,
+	}
+	if count+1 <= float64(limit) {
+		entry.currCount++
+		result.Allowed = true
+		count++
+	} else {
+		result.RetryAfter = period - elapsed
+		// 若当前窗口未满，前一窗口的权重随时间下降后即可放行
+		if entry.prevCount != 0 && entry.currCount+1 <= limit {
+			// prev * (1 - (elapsed + t) / period) + curr + 1 <= limit
+			ratio := float64(limit-entry.currCount-1) / float64(entry.prevCount)
+			wait := time.Duration(math.Ceil((1-ratio)*float64(period))) - elapsed
+			if wait > 0 && wait < result.RetryAfter {
+				result.RetryAfter = wait
+			}
+		}
+	}
+	result.Remaining = max(limit-int(math.Ceil(count)), 0)
+	return result
+}
+
+func formatRateLimitSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// NewRateLimiter returns a new rate limiter middleware, the key of request is generated
+// the same as concurrent limiter, limit requests are allowed per period for each key.
+// It will throw a panic if Store is nil, or limit and period is not gt 0.
+func NewRateLimiter(config RateLimiterConfig) elton.Handler {
+	if config.Store == nil {
+		panic(ErrRateLimiterRequireStore)
+	}
+	if config.Limit <= 0 || config.Period <= 0 {
+		panic(ErrRateLimiterInvalidLimit)
+	}
+	keyBuilder := &limiterKeyBuilder{
+		keys:          parseLimiterKeys(config.Keys),
+		bodyValue:     config.BodyValue,
+		keyGenerator:  config.KeyGenerator,
+		notAllowEmpty: config.NotAllowEmpty,
+	}
+	skipper := getSkipper(config.Skipper)
+	store := config.Store
+	limit := strconv.Itoa(config.Limit)
+	return func(c *elton.Context) error {
+		if skipper(c) {
+			return c.Next()
+		}
+		key, err := keyBuilder.Build(c)
+		if err != nil {
+			return err
+		}
+		result, err := store.Take(c.Context(), key, config.Limit, config.Period)
+		if err != nil {
+			return wrapAsHesError(err, ErrRateLimiterCategory)
+		}
+		if !config.DisableHeaders {
+			c.SetHeader(HeaderRateLimitLimit, limit)
+			c.SetHeader(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+			c.SetHeader(HeaderRateLimitReset, formatRateLimitSeconds(result.Reset))
+		}
+		if !result.Allowed {
+			retryAfter := formatRateLimitSeconds(result.RetryAfter)
+			c.SetHeader(HeaderRetryAfter, retryAfter)
+			return ErrRateLimitExceeded.WithExtra("retryAfter", retryAfter)
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,189 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton/v2"
+)
+
+type errRateLimitStore struct{}
+
+func (*errRateLimitStore) Take(_ context.Context, _ string, _ int, _ time.Duration) (*RateLimitResult, error) {
+	return nil, errors.New("store error")
+}
+
+func TestNewRateLimiterPanic(t *testing.T) {
+	assert := assert.New(t)
+	assert.PanicsWithValue(ErrRateLimiterRequireStore, func() {
+		NewRateLimiter(RateLimiterConfig{})
+	})
+	assert.PanicsWithValue(ErrRateLimiterInvalidLimit, func() {
+		NewRateLimiter(RateLimiterConfig{
+			Store: NewMemoryRateLimitStore(RateLimitTokenBucket, 0),
+		})
+	})
+}
+
+func TestTokenBucketRateLimitStore(t *testing.T) {
+	assert := assert.New(t)
+	store := NewMemoryRateLimitStore(RateLimitTokenBucket, 10)
+	now := time.Unix(1000, 0)
+	store.now = func() time.Time {
+		return now
+	}
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		result, err := store.Take(ctx, "a", 3, 3*time.Second)
+		assert.Nil(err)
+		assert.True(result.Allowed)
+		assert.Equal(2-i, result.Remaining)
+	}
+	result, _ := store.Take(ctx, "a", 3, 3*time.Second)
+	assert.False(result.Allowed)
+	assert.Equal(time.Second, result.RetryAfter)
+	assert.Equal(3*time.Second, result.Reset)
+
+	// other key is not affected
+	result, _ = store.Take(ctx, "b", 3, 3*time.Second)
+	assert.True(result.Allowed)
+
+	// refill one token
+	now = now.Add(time.Second)
+	result, _ = store.Take(ctx, "a", 3, 3*time.Second)
+	assert.True(result.Allowed)
+	assert.Equal(0, result.Remaining)
+}
+
+func TestSlidingWindowRateLimitStore(t *testing.T) {
+	assert := assert.New(t)
+	store := NewMemoryRateLimitStore(RateLimitSlidingWindow, 10)
+	now := time.Unix(1000, 0)
+	store.now = func() time.Time {
+		return now
+	}
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		result, err := store.Take(ctx, "a", 4, 10*time.Second)
+		assert.Nil(err)
+		assert.True(result.Allowed)
+		assert.Equal(3-i, result.Remaining)
+	}
+	result, _ := store.Take(ctx, "a", 4, 10*time.Second)
+	assert.False(result.Allowed)
+	assert.Equal(10*time.Second, result.RetryAfter)
+
+	// next window: previous count(4) weighted by 0.5
+	now = now.Add(15 * time.Second)
+	result, _ = store.Take(ctx, "a", 4, 10*time.Second)
+	assert.True(result.Allowed)
+	assert.Equal(1, result.Remaining)
+	result, _ = store.Take(ctx, "a", 4, 10*time.Second)
+	assert.True(result.Allowed)
+	assert.Equal(0, result.Remaining)
+	result, _ = store.Take(ctx, "a", 4, 10*time.Second)
+	assert.False(result.Allowed)
+	// 4 * (1 - (5 + t) / 10) + 2 + 1 <= 4 → t >= 2.5s
+	assert.Equal(2500*time.Millisecond, result.RetryAfter)
+
+	// skip more than one window, all counts are cleared
+	now = now.Add(30 * time.Second)
+	result, _ = store.Take(ctx, "a", 4, 10*time.Second)
+	assert.True(result.Allowed)
+	assert.Equal(3, result.Remaining)
+}
+
+func TestRateLimiter(t *testing.T) {
+	assert := assert.New(t)
+
+	e := elton.New()
+	e.Use(NewRateLimiter(RateLimiterConfig{
+		Keys: []string{
+			":ip",
+			"q:type",
+		},
+		Store:  NewMemoryRateLimitStore(RateLimitTokenBucket, 0),
+		Limit:  2,
+		Period: time.Minute,
+	}))
+	e.GET("/", func(c *elton.Context) error {
+		c.BodyBuffer = bytes.NewBufferString("ok")
+		return nil
+	})
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "/?type=1", nil)
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(200, resp.Code)
+		assert.Equal("2", resp.Header().Get(HeaderRateLimitLimit))
+		assert.Equal(strconv.Itoa(1-i), resp.Header().Get(HeaderRateLimitRemaining))
+	}
+
+	req := httptest.NewRequest("GET", "/?type=1", nil)
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(429, resp.Code)
+	assert.Equal("0", resp.Header().Get(HeaderRateLimitRemaining))
+	assert.Equal("30", resp.Header().Get(HeaderRetryAfter))
+	assert.Contains(resp.Body.String(), "rate limit exceeded")
+
+	// the other query value uses another key
+	req = httptest.NewRequest("GET", "/?type=2", nil)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+}
+
+func TestRateLimiterError(t *testing.T) {
+	assert := assert.New(t)
+
+	fn := NewRateLimiter(RateLimiterConfig{
+		Keys: []string{
+			"p:id",
+		},
+		NotAllowEmpty: true,
+		Store:         NewMemoryRateLimitStore(RateLimitTokenBucket, 0),
+		Limit:         1,
+		Period:        time.Second,
+	})
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Equal(ErrNotAllowEmpty, fn(c))
+
+	fn = NewRateLimiter(RateLimiterConfig{
+		Store:  &errRateLimitStore{},
+		Limit:  1,
+		Period: time.Second,
+	})
+	c = elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	err := fn(c)
+	assert.Equal("statusCode=500, category=elton-rate-limiter, message=store error, exception=true", err.Error())
+}
@@ -23,6 +23,7 @@
 package middleware
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
@@ -36,10 +37,22 @@ const (
 	ErrRecoverCategory = "elton-recover"
 )
 
+// RecoverConfig recover config
+type RecoverConfig struct {
+	// Problem responses the error as problem details(application/problem+json)
+	Problem *ProblemConfig
+}
+
 // NewRecover return a recover middleware, it can recover from panic,
 // and then emit an `elton-recover` error.
 // Suggest to graceful close the elton instance for recover error.
 func NewRecover() elton.Handler {
+	return NewRecoverWithConfig(RecoverConfig{})
+}
+
+// NewRecoverWithConfig return a recover middleware with config,
+// the error is responded as problem details if Problem is set.
+func NewRecoverWithConfig(config RecoverConfig) elton.Handler {
 	return func(c *elton.Context) error {
 		defer func() {
 			// 可针对实际需求调整，如对于每个recover增加邮件通知等
@@ -67,7 +80,12 @@ func NewRecover() elton.Handler {
 				c.Committed = true
 				resp := c.Response
 				buf := []byte(err.Error())
-				if strings.Contains(c.GetRequestHeader("Accept"), "application/json") {
+				if config.Problem != nil {
+					if b, e := json.Marshal(NewProblem(c, he, *config.Problem)); e == nil {
+						c.SetHeader(elton.HeaderContentType, MIMEApplicationProblemJSON)
+						buf = b
+					}
+				} else if strings.Contains(c.GetRequestHeader("Accept"), "application/json") {
 					// 序列化失败时保持 text 输出
 					if b, e := he.ToJSON(); e == nil {
 						c.SetHeader(elton.HeaderContentType, elton.MIMEApplicationJSON)
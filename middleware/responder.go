@@ -39,6 +39,9 @@ type (
 		Marshal func(v any) ([]byte, error)
 		// ContentType response's content type
 		ContentType string
+		// Negotiator selects the encoder by Accept header, Marshal and
+		// ContentType are ignored if it is set
+		Negotiator *Negotiator
 	}
 )
 
@@ -65,6 +68,9 @@ func NewDefaultResponder() elton.Handler {
 // NewResponder returns a new responder middleware.
 // If will use json.Marshal as default marshal function.
 // If will use application/json as default content type.
+// If the negotiator is set, the body is marshaled by the encoder
+// which is selected by Accept header and the Content-Type is set to the
+// encoder's, 406 is returned if none is acceptable.
 func NewResponder(config ResponderConfig) elton.Handler {
 	skipper := getSkipper(config.Skipper)
 	marshal := config.Marshal
@@ -76,6 +82,7 @@ func NewResponder(config ResponderConfig) elton.Handler {
 	if contentType == "" {
 		contentType = elton.MIMEApplicationJSON
 	}
+	negotiator := config.Negotiator
 
 	return func(c *elton.Context) error {
 		if skipper(c) {
@@ -117,6 +124,18 @@ func NewResponder(config ResponderConfig) elton.Handler {
 				}
 				body = data
 			default:
+				if negotiator != nil {
+					addVary(c, headerAccept)
+					// 无可接受的encoder时返回406
+					buf, negotiatedType, e := negotiator.Marshal(c.GetRequestHeader(headerAccept), data)
+					if e != nil {
+						return wrapAsHesError(e, ErrResponderCategory)
+					}
+					// 响应数据由所选encoder生成，因此Content-Type需与其一致
+					c.SetHeader(elton.HeaderContentType, negotiatedType)
+					body = buf
+					break
+				}
 				// 使用marshal转换（默认为转换为json）
 				buf, e := marshal(data)
 				if e != nil {
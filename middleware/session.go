@@ -0,0 +1,467 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/vicanso/elton/v2"
+	"github.com/vicanso/hes"
+)
+
+const (
+	// ErrSessionCategory session error category
+	ErrSessionCategory = "elton-session"
+	// ContextKeySession context store key for session
+	ContextKeySession = "session"
+	// defaultSessionCookieName default cookie name of session
+	defaultSessionCookieName = "elton.sid"
+	// defaultSessionTTL default ttl of session
+	defaultSessionTTL = 24 * time.Hour
+	// maxSessionCookieSize 浏览器对单个cookie的限制为4KB，预留部分给cookie属性
+	maxSessionCookieSize = 4000
+)
+
+var (
+	ErrSessionRequireStore  = errors.New("require store for session")
+	ErrSessionRequireSecret = errors.New("require secret for cookie session store")
+	// ErrSessionNotFound session middleware is not used
+	ErrSessionNotFound = &hes.Error{
+		Exception:  true,
+		StatusCode: http.StatusInternalServerError,
+		Message:    "session not found, please use session middleware first",
+		Category:   ErrSessionCategory,
+	}
+	// ErrSessionCookieTooLarge the data of cookie session is too large
+	ErrSessionCookieTooLarge = &hes.Error{
+		Exception:  true,
+		StatusCode: http.StatusInternalServerError,
+		Message:    "session data is too large for cookie",
+		Category:   ErrSessionCategory,
+	}
+)
+
+type (
+	// SessionStore session store.
+	// The token is the value of session cookie, it's returned by Save.
+	SessionStore interface {
+		// Load returns the data of token, nil will be returned if not exists or expired
+		Load(ctx context.Context, token string) ([]byte, error)
+		// Save saves the data of session id and returns the token for cookie
+		Save(ctx context.Context, id string, data []byte, ttl time.Duration) (token string, err error)
+		// Destroy destroys the data of token
+		Destroy(ctx context.Context, token string) error
+	}
+	// SessionConfig session config
+	SessionConfig struct {
+		// Store session store
+		Store SessionStore
+		// TTL the ttl of session, default is 24 hours
+		TTL time.Duration
+		// Rolling reset the expiry of session on every request
+		Rolling bool
+		// GenerateID generate session id, default is 16-byte hex
+		GenerateID func() string
+		// CookieName the cookie name of session, default is "elton.sid"
+		CookieName string
+		// CookiePath the cookie path, default is "/"
+		CookiePath string
+		// CookieDomain the cookie domain
+		CookieDomain string
+		// Secure the cookie secure
+		Secure bool
+		// SameSite the cookie same site
+		SameSite http.SameSite
+		Skipper  elton.Skipper
+	}
+	// Session session of request, it will be loaded from store
+	// when GetSession is called first time
+	Session struct {
+		c      *elton.Context
+		config *SessionConfig
+
+		loaded bool
+		// token the value of session cookie
+		token string
+		// prevToken the token before regenerate
+		prevToken string
+		data      *sessionData
+		isNew     bool
+		modified  bool
+		destroyed bool
+	}
+	// sessionData the data saved to store
+	sessionData struct {
+		ID        string         `json:"id"`
+		CreatedAt int64          `json:"createdAt"`
+		Values    map[string]any `json:"values"`
+	}
+)
+
+// NewSession returns a new session middleware, the session cookie is signed,
+// so the SignedKeys of elton should be set.
+// It will throw a panic if Store is nil.
+func NewSession(config SessionConfig) elton.Handler {
+	if config.Store == nil {
+		panic(ErrSessionRequireStore)
+	}
+	if config.TTL <= 0 {
+		config.TTL = defaultSessionTTL
+	}
+	if config.GenerateID == nil {
+		config.GenerateID = defaultRequestID
+	}
+	if config.CookieName == "" {
+		config.CookieName = defaultSessionCookieName
+	}
+	if config.CookiePath == "" {
+		config.CookiePath = "/"
+	}
+	skipper := getSkipper(config.Skipper)
+	return func(c *elton.Context) error {
+		if skipper(c) {
+			return c.Next()
+		}
+		s := &Session{
+			c:      c,
+			config: &config,
+		}
+		c.Set(ContextKeySession, s)
+		err := c.Next()
+		// 保存失败不覆盖原有的出错
+		if e := s.commit(); e != nil && err == nil {
+			err = wrapAsHesError(e, ErrSessionCategory)
+		}
+		return err
+	}
+}
+
+// GetSession returns the session of context,
+// the session data will be loaded from store if it's not loaded.
+func GetSession(c *elton.Context) (*Session, error) {
+	s := elton.GetContextValue[*Session](c, ContextKeySession)
+	if s == nil {
+		return nil, ErrSessionNotFound
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetSessionValue returns the value of session as type T.
+// The values are saved as json, so a number will be float64 after loading from store,
+// it will be converted by json if the type is not matched.
+// The zero value of T will be returned if the key does not exist or fail to convert.
+func GetSessionValue[T any](s *Session, key string) T {
+	var zero T
+	value := s.Get(key)
+	if value == nil {
+		return zero
+	}
+	if v, ok := value.(T); ok {
+		return v
+	}
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return zero
+	}
+	var v T
+	if err := json.Unmarshal(buf, &v); err != nil {
+		return zero
+	}
+	return v
+}
+
+func (s *Session) newData() {
+	s.isNew = true
+	s.data = &sessionData{
+		ID:        s.config.GenerateID(),
+		CreatedAt: time.Now().Unix(),
+		Values:    make(map[string]any),
+	}
+}
+
+func (s *Session) load() error {
+	if s.loaded {
+		return nil
+	}
+	cookie, err := s.c.SignedCookie(s.config.CookieName)
+	if err != nil && err != http.ErrNoCookie {
+		return wrapAsHesError(err, ErrSessionCategory)
+	}
+	s.loaded = true
+	if cookie == nil || cookie.Value == "" {
+		s.newData()
+		return nil
+	}
+	buf, err := s.config.Store.Load(s.c.Context(), cookie.Value)
+	if err != nil {
+		s.loaded = false
+		return wrapAsHesError(err, ErrSessionCategory)
+	}
+	data := &sessionData{}
+	// 数据不存在或已损坏时创建新的session
+	if len(buf) == 0 || json.Unmarshal(buf, data) != nil || data.ID == "" {
+		s.newData()
+		return nil
+	}
+	if data.Values == nil {
+		data.Values = make(map[string]any)
+	}
+	s.token = cookie.Value
+	s.data = data
+	return nil
+}
+
+func (s *Session) setCookie(value string, maxAge int) {
+	s.c.AddSignedCookie(&http.Cookie{
+		Name:     s.config.CookieName,
+		Value:    value,
+		Path:     s.config.CookiePath,
+		Domain:   s.config.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   s.config.Secure,
+		HttpOnly: true,
+		SameSite: s.config.SameSite,
+	})
+}
+
+// commit saves the session to store and sets the cookie
+func (s *Session) commit() error {
+	if !s.loaded {
+		return nil
+	}
+	ctx := s.c.Context()
+	store := s.config.Store
+	if s.prevToken != "" {
+		if err := store.Destroy(ctx, s.prevToken); err != nil {
+			return err
+		}
+	}
+	if s.destroyed {
+		if s.token == "" {
+			return nil
+		}
+		if err := store.Destroy(ctx, s.token); err != nil {
+			return err
+		}
+		s.setCookie("", -1)
+		return nil
+	}
+	// 新的session未修改时不保存，避免生成空session
+	if !s.modified && (s.isNew || !s.config.Rolling) {
+		return nil
+	}
+	buf, err := json.Marshal(s.data)
+	if err != nil {
+		return err
+	}
+	ttl := s.config.TTL
+	token, err := store.Save(ctx, s.data.ID, buf, ttl)
+	if err != nil {
+		return err
+	}
+	s.token = token
+	s.setCookie(token, int(ttl.Seconds()))
+	return nil
+}
+
+// ID returns the id of session
+func (s *Session) ID() string {
+	return s.data.ID
+}
+
+// IsNew returns whether the session is new created
+func (s *Session) IsNew() bool {
+	return s.isNew
+}
+
+// CreatedAt returns the created time of session
+func (s *Session) CreatedAt() time.Time {
+	return time.Unix(s.data.CreatedAt, 0)
+}
+
+// Get returns the value of key
+func (s *Session) Get(key string) any {
+	return s.data.Values[key]
+}
+
+// Set sets the value of key
+func (s *Session) Set(key string, value any) {
+	s.data.Values[key] = value
+	s.modified = true
+}
+
+// Delete deletes the value of key
+func (s *Session) Delete(key string) {
+	if _, ok := s.data.Values[key]; !ok {
+		return
+	}
+	delete(s.data.Values, key)
+	s.modified = true
+}
+
+// Clear clears all values of session
+func (s *Session) Clear() {
+	clear(s.data.Values)
+	s.modified = true
+}
+
+// Regenerate regenerates the session id and keeps the values,
+// the previous session will be destroyed.
+// It should be called after login to avoid session fixation.
+func (s *Session) Regenerate() {
+	if s.token != "" && s.prevToken == "" {
+		s.prevToken = s.token
+	}
+	s.token = ""
+	s.data.ID = s.config.GenerateID()
+	s.data.CreatedAt = time.Now().Unix()
+	s.modified = true
+}
+
+// Destroy destroys the session and clears the cookie
+func (s *Session) Destroy() {
+	clear(s.data.Values)
+	s.destroyed = true
+}
+
+var _ SessionStore = (*MemorySessionStore)(nil)
+
+// MemorySessionStore memory session store base on lru store,
+// the token is the session id.
+type MemorySessionStore struct {
+	store *LRUStore
+}
+
+// NewMemorySessionStore returns a new memory session store
+func NewMemorySessionStore(size int) *MemorySessionStore {
+	return &MemorySessionStore{
+		store: NewLRUStore(size),
+	}
+}
+
+// Load returns the data of session
+func (ms *MemorySessionStore) Load(ctx context.Context, token string) ([]byte, error) {
+	return ms.store.Get(ctx, token)
+}
+
+// Save saves the data of session, the id is used as token
+func (ms *MemorySessionStore) Save(ctx context.Context, id string, data []byte, ttl time.Duration) (string, error) {
+	if err := ms.store.Set(ctx, id, data, ttl); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Destroy destroys the data of session
+func (ms *MemorySessionStore) Destroy(ctx context.Context, token string) error {
+	return ms.store.Delete(ctx, token)
+}
+
+var _ SessionStore = (*CookieSessionStore)(nil)
+
+// CookieSessionStore cookie session store, the session data is encrypted
+// by AES-GCM and saved in cookie, so the data should be small.
+type CookieSessionStore struct {
+	aeads []cipher.AEAD
+}
+
+// NewCookieSessionStore returns a new cookie session store,
+// the first secret is used for encrypting, and all secrets are used for decrypting,
+// so the secret can be rotated by prepending the new one.
+// It will throw a panic if secrets is empty.
+func NewCookieSessionStore(secrets ...string) *CookieSessionStore {
+	if len(secrets) == 0 {
+		panic(ErrSessionRequireSecret)
+	}
+	aeads := make([]cipher.AEAD, len(secrets))
+	for i, secret := range secrets {
+		key := sha256.Sum256([]byte(secret))
+		// 32字节的key与默认nonce长度不会出错
+		block, _ := aes.NewCipher(key[:])
+		aead, _ := cipher.NewGCM(block)
+		aeads[i] = aead
+	}
+	return &CookieSessionStore{
+		aeads: aeads,
+	}
+}
+
+// Load decrypts the data from token
+func (cs *CookieSessionStore) Load(_ context.Context, token string) ([]byte, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, nil
+	}
+	for _, aead := range cs.aeads {
+		nonceSize := aead.NonceSize()
+		if len(buf) < nonceSize {
+			return nil, nil
+		}
+		data, err := aead.Open(nil, buf[:nonceSize], buf[nonceSize:], nil)
+		if err != nil || len(data) < expiredByteSize {
+			continue
+		}
+		if nowSeconds() > binary.BigEndian.Uint32(data) {
+			return nil, nil
+		}
+		return data[expiredByteSize:], nil
+	}
+	return nil, nil
+}
+
+// Save encrypts the data as token, the layout is:
+// [过期时间戳(4字节,大端,单位秒)][data]
+func (cs *CookieSessionStore) Save(_ context.Context, _ string, data []byte, ttl time.Duration) (string, error) {
+	aead := cs.aeads[0]
+	buf := make([]byte, expiredByteSize+len(data))
+	binary.BigEndian.PutUint32(buf, nowSeconds()+uint32(ttl/time.Second))
+	copy(buf[expiredByteSize:], data)
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(buf)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, buf, nil))
+	if len(token) > maxSessionCookieSize {
+		return "", ErrSessionCookieTooLarge
+	}
+	return token, nil
+}
+
+// Destroy does nothing, the cookie will be cleared
+func (cs *CookieSessionStore) Destroy(_ context.Context, _ string) error {
+	return nil
+}
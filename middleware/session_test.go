@@ -0,0 +1,275 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton/v2"
+)
+
+type sessionUser struct {
+	Account string `json:"account"`
+	Age     int    `json:"age"`
+}
+
+func newSessionTestServer(store SessionStore, rolling bool) *elton.Elton {
+	e := elton.New()
+	e.SignedKeys = &elton.SimpleSignedKeys{}
+	e.SignedKeys.SetKeys([]string{"secret"})
+	e.Use(NewSession(SessionConfig{
+		Store:   store,
+		Rolling: rolling,
+	}))
+	e.Use(NewDefaultResponder())
+	e.POST("/login", func(c *elton.Context) error {
+		s, err := GetSession(c)
+		if err != nil {
+			return err
+		}
+		s.Regenerate()
+		s.Set("user", &sessionUser{
+			Account: c.QueryParam("account"),
+			Age:     18,
+		})
+		c.Body = s.ID()
+		return nil
+	})
+	e.GET("/me", func(c *elton.Context) error {
+		s, err := GetSession(c)
+		if err != nil {
+			return err
+		}
+		user := GetSessionValue[sessionUser](s, "user")
+		c.Body = user.Account
+		return nil
+	})
+	e.GET("/none", func(c *elton.Context) error {
+		c.Body = "none"
+		return nil
+	})
+	e.POST("/logout", func(c *elton.Context) error {
+		s, err := GetSession(c)
+		if err != nil {
+			return err
+		}
+		s.Destroy()
+		c.Body = "bye"
+		return nil
+	})
+	return e
+}
+
+func sessionCookies(resp *httptest.ResponseRecorder) []*http.Cookie {
+	return resp.Result().Cookies()
+}
+
+func doSessionRequest(e *elton.Elton, method, url string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestNewSessionPanic(t *testing.T) {
+	assert := assert.New(t)
+	assert.PanicsWithValue(ErrSessionRequireStore, func() {
+		NewSession(SessionConfig{})
+	})
+	assert.PanicsWithValue(ErrSessionRequireSecret, func() {
+		NewCookieSessionStore()
+	})
+}
+
+func TestGetSessionWithoutMiddleware(t *testing.T) {
+	assert := assert.New(t)
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	_, err := GetSession(c)
+	assert.Equal(ErrSessionNotFound, err)
+}
+
+func TestSession(t *testing.T) {
+	for _, store := range []SessionStore{
+		NewMemorySessionStore(10),
+		NewCookieSessionStore("key2", "key1"),
+	} {
+		assert := assert.New(t)
+		e := newSessionTestServer(store, false)
+
+		// session is not loaded, no cookie
+		resp := doSessionRequest(e, "GET", "/none", nil)
+		assert.Equal(200, resp.Code)
+		assert.Empty(sessionCookies(resp))
+
+		// new session is not modified, no cookie
+		resp = doSessionRequest(e, "GET", "/me", nil)
+		assert.Equal(200, resp.Code)
+		assert.Empty(sessionCookies(resp))
+
+		resp = doSessionRequest(e, "POST", "/login?account=tree", nil)
+		assert.Equal(200, resp.Code)
+		cookies := sessionCookies(resp)
+		assert.Equal(2, len(cookies))
+		assert.Equal(defaultSessionCookieName, cookies[0].Name)
+		assert.Equal(defaultSessionCookieName+elton.SignedCookieSuffix, cookies[1].Name)
+		assert.True(cookies[0].HttpOnly)
+		assert.Equal(86400, cookies[0].MaxAge)
+
+		resp = doSessionRequest(e, "GET", "/me", cookies)
+		assert.Equal("tree", resp.Body.String())
+		// not rolling, not modified
+		assert.Empty(sessionCookies(resp))
+
+		// tampered cookie
+		tampered := []*http.Cookie{
+			{
+				Name:  cookies[0].Name,
+				Value: cookies[0].Value + "a",
+			},
+			cookies[1],
+		}
+		resp = doSessionRequest(e, "GET", "/me", tampered)
+		assert.Empty(resp.Body.String())
+
+		resp = doSessionRequest(e, "POST", "/logout", cookies)
+		assert.Equal("bye", resp.Body.String())
+		logoutCookies := sessionCookies(resp)
+		assert.Equal(2, len(logoutCookies))
+		assert.Equal(-1, logoutCookies[0].MaxAge)
+	}
+}
+
+func TestSessionRegenerate(t *testing.T) {
+	assert := assert.New(t)
+	store := NewMemorySessionStore(10)
+	e := newSessionTestServer(store, false)
+
+	resp := doSessionRequest(e, "POST", "/login?account=tree", nil)
+	cookies := sessionCookies(resp)
+	id := resp.Body.String()
+	assert.Equal(id, cookies[0].Value)
+
+	resp = doSessionRequest(e, "POST", "/login?account=tree", cookies)
+	newID := resp.Body.String()
+	assert.NotEqual(id, newID)
+	// the previous session is destroyed
+	buf, _ := store.Load(context.Background(), id)
+	assert.Empty(buf)
+
+	// destroyed session can not be used
+	resp = doSessionRequest(e, "GET", "/me", cookies)
+	assert.Empty(resp.Body.String())
+	resp = doSessionRequest(e, "GET", "/me", sessionCookies(doSessionRequest(e, "POST", "/login?account=abc", nil)))
+	assert.Equal("abc", resp.Body.String())
+}
+
+func TestSessionRolling(t *testing.T) {
+	assert := assert.New(t)
+	e := newSessionTestServer(NewMemorySessionStore(10), true)
+
+	resp := doSessionRequest(e, "POST", "/login?account=tree", nil)
+	cookies := sessionCookies(resp)
+
+	resp = doSessionRequest(e, "GET", "/me", cookies)
+	assert.Equal("tree", resp.Body.String())
+	// the expiry is reset
+	assert.Equal(2, len(sessionCookies(resp)))
+	assert.Equal(cookies[0].Value, sessionCookies(resp)[0].Value)
+}
+
+func TestSessionRequireSignedKeys(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.Use(NewSession(SessionConfig{
+		Store: NewMemorySessionStore(10),
+	}))
+	e.GET("/", func(c *elton.Context) error {
+		_, err := GetSession(c)
+		return err
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{
+		Name:  defaultSessionCookieName,
+		Value: "abc",
+	})
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(400, resp.Code)
+	assert.Contains(resp.Body.String(), "keys for sign cookie can't be nil")
+}
+
+func TestCookieSessionStore(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	store := NewCookieSessionStore("key1")
+	token, err := store.Save(ctx, "id", []byte("data"), time.Minute)
+	assert.Nil(err)
+
+	buf, err := store.Load(ctx, token)
+	assert.Nil(err)
+	assert.Equal([]byte("data"), buf)
+
+	// rotate secret
+	buf, _ = NewCookieSessionStore("key2", "key1").Load(ctx, token)
+	assert.Equal([]byte("data"), buf)
+	buf, _ = NewCookieSessionStore("key2").Load(ctx, token)
+	assert.Empty(buf)
+
+	// invalid token
+	buf, err = store.Load(ctx, "abc")
+	assert.Nil(err)
+	assert.Empty(buf)
+
+	// expired
+	token, _ = store.Save(ctx, "id", []byte("data"), -2*time.Second)
+	buf, _ = store.Load(ctx, token)
+	assert.Empty(buf)
+
+	_, err = store.Save(ctx, "id", []byte(strings.Repeat("a", 4096)), time.Minute)
+	assert.Equal(ErrSessionCookieTooLarge, err)
+}
+
+func TestGetSessionValue(t *testing.T) {
+	assert := assert.New(t)
+	s := &Session{
+		data: &sessionData{
+			Values: map[string]any{
+				"count": float64(1),
+				"name":  "tree",
+			},
+		},
+	}
+	assert.Equal(1, GetSessionValue[int](s, "count"))
+	assert.Equal("tree", GetSessionValue[string](s, "name"))
+	assert.Equal(0, GetSessionValue[int](s, "name"))
+	assert.Equal("", GetSessionValue[string](s, "missing"))
+}
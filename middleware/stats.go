@@ -74,10 +74,15 @@ type (
 // NewStats returns a new stats middleware,
 // it will throw a panic if the OnStats is nil.
 func NewStats(config StatsConfig) elton.Handler {
+	return newStats(config, &atomic.Int32{})
+}
+
+// newStats returns a new stats middleware, the connecting count
+// is shared with the in-flight gauge of metrics middleware.
+func newStats(config StatsConfig, connectingCount *atomic.Int32) elton.Handler {
 	if config.OnStats == nil {
 		panic(ErrStatsNoFunction)
 	}
-	connectingCount := atomic.Int32{}
 	skipper := getSkipper(config.Skipper)
 	return func(c *elton.Context) error {
 		if skipper(c) {
@@ -0,0 +1,229 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	_ CacheStore         = (*TieredStore)(nil)
+	_ CacheDeleter       = (*TieredStore)(nil)
+	_ CachePrefixPurger  = (*TieredStore)(nil)
+	_ CacheTagger        = (*TieredStore)(nil)
+	_ CacheStatsProvider = (*TieredStore)(nil)
+)
+
+// TieredStoreConfig tiered store config
+type TieredStoreConfig struct {
+	// Back the shared store behind the memory store
+	Back CacheStore
+	// Size the size of memory store, default is 1024
+	Size int
+	// FrontTTL the max ttl of memory store, default is 1 minute.
+	// The data read from back store is saved to memory store with this ttl,
+	// so it should be shorter than the ttl of back store
+	FrontTTL time.Duration
+	// NegativeTTL the ttl of negative cache, if the data is not exists in back store,
+	// the get of key will not read the back store in this ttl.
+	// Default is 0, which means negative cache is disabled
+	NegativeTTL time.Duration
+}
+
+// TieredStoreStats the hits and misses of each tier
+type TieredStoreStats struct {
+	FrontHits    uint64 `json:"frontHits"`
+	FrontMisses  uint64 `json:"frontMisses"`
+	BackHits     uint64 `json:"backHits"`
+	BackMisses   uint64 `json:"backMisses"`
+	NegativeHits uint64 `json:"negativeHits"`
+}
+
+// TieredStore is the cache store which layers a lru store in front of another store.
+// Get reads the lru store first, and reads through the back store when missing,
+// Set writes through both stores. The remaining ttl of back store is unknown, so the
+// data read from back store is kept in memory store for FrontTTL, it may be served
+// for up to FrontTTL after it is expired, updated or removed in back store by other
+// instances(the cache middleware checks the freshness of cache response itself).
+// The purge of tag requires the back store supports tag, otherwise Tag and PurgeTag
+// return ErrCachePurgeNotSupported.
+type TieredStore struct {
+	front       *LRUStore
+	negative    *LRUStore
+	back        CacheStore
+	frontTTL    time.Duration
+	negativeTTL time.Duration
+
+	frontHits    atomic.Uint64
+	frontMisses  atomic.Uint64
+	backHits     atomic.Uint64
+	backMisses   atomic.Uint64
+	negativeHits atomic.Uint64
+}
+
+// 负缓存的占位数据
+var tieredNegativeData = []byte{1}
+
+// NewTieredStore creates a tiered store
+func NewTieredStore(config TieredStoreConfig) *TieredStore {
+	if config.Back == nil {
+		panic("require back store for tiered store")
+	}
+	size := config.Size
+	if size <= 0 {
+		size = 1024
+	}
+	frontTTL := config.FrontTTL
+	if frontTTL <= 0 {
+		frontTTL = time.Minute
+	}
+	s := &TieredStore{
+		front:       NewLRUStore(size),
+		back:        config.Back,
+		frontTTL:    frontTTL,
+		negativeTTL: config.NegativeTTL,
+	}
+	if s.negativeTTL > 0 {
+		s.negative = NewLRUStore(size)
+	}
+	return s
+}
+
+func (s *TieredStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, _ := s.front.Get(ctx, key)
+	if data != nil {
+		s.frontHits.Add(1)
+		return data, nil
+	}
+	s.frontMisses.Add(1)
+	if s.negative != nil {
+		if v, _ := s.negative.Get(ctx, key); v != nil {
+			s.negativeHits.Add(1)
+			return nil, nil
+		}
+	}
+	data, err := s.back.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		s.backMisses.Add(1)
+		if s.negative != nil {
+			_ = s.negative.Set(ctx, key, tieredNegativeData, s.negativeTTL)
+		}
+		return nil, nil
+	}
+	s.backHits.Add(1)
+	// 无法获取back store数据的剩余ttl，因此使用FrontTTL，
+	// 最多可能在back store过期（或被其它实例更新、删除）后仍使用FrontTTL的时长
+	_ = s.front.Set(ctx, key, data, s.frontTTL)
+	return data, nil
+}
+
+func (s *TieredStore) Set(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	err := s.back.Set(ctx, key, data, ttl)
+	if err != nil {
+		return err
+	}
+	if s.negative != nil {
+		_ = s.negative.Delete(ctx, key)
+	}
+	return s.front.Set(ctx, key, data, min(ttl, s.frontTTL))
+}
+
+// Delete removes the data of key from both stores,
+// it returns ErrCachePurgeNotSupported if the back store does not support
+func (s *TieredStore) Delete(ctx context.Context, key string) error {
+	deleter, ok := s.back.(CacheDeleter)
+	if !ok {
+		return ErrCachePurgeNotSupported
+	}
+	err := deleter.Delete(ctx, key)
+	if err != nil {
+		return err
+	}
+	return s.front.Delete(ctx, key)
+}
+
+// PurgePrefix removes the data whose key has the prefix from both stores,
+// it returns the count of removed keys of back store
+func (s *TieredStore) PurgePrefix(ctx context.Context, prefix string) (int, error) {
+	purger, ok := s.back.(CachePrefixPurger)
+	if !ok {
+		return 0, ErrCachePurgeNotSupported
+	}
+	count, err := purger.PurgePrefix(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	_, _ = s.front.PurgePrefix(ctx, prefix)
+	return count, nil
+}
+
+// Tag associates the key with tags in both stores,
+// it returns ErrCachePurgeNotSupported if the back store does not support
+func (s *TieredStore) Tag(ctx context.Context, key string, tags ...string) error {
+	// 与PurgeTag保持一致，back store不支持时仅标记memory store会导致清除不完整
+	tagger, ok := s.back.(CacheTagger)
+	if !ok {
+		return ErrCachePurgeNotSupported
+	}
+	err := tagger.Tag(ctx, key, tags...)
+	if err != nil {
+		return err
+	}
+	return s.front.Tag(ctx, key, tags...)
+}
+
+// PurgeTag removes the data of keys which are tagged with the tag from both stores,
+// it returns the count of removed keys of back store
+func (s *TieredStore) PurgeTag(ctx context.Context, tag string) (int, error) {
+	tagger, ok := s.back.(CacheTagger)
+	if !ok {
+		return 0, ErrCachePurgeNotSupported
+	}
+	count, err := tagger.PurgeTag(ctx, tag)
+	if err != nil {
+		return 0, err
+	}
+	_, _ = s.front.PurgeTag(ctx, tag)
+	return count, nil
+}
+
+// Stats returns the hits and misses of each tier
+func (s *TieredStore) Stats() TieredStoreStats {
+	return TieredStoreStats{
+		FrontHits:    s.frontHits.Load(),
+		FrontMisses:  s.frontMisses.Load(),
+		BackHits:     s.backHits.Load(),
+		BackMisses:   s.backMisses.Load(),
+		NegativeHits: s.negativeHits.Load(),
+	}
+}
+
+// StoreStats returns the stats of store, it implements CacheStatsProvider
+func (s *TieredStore) StoreStats() any {
+	return s.Stats()
+}
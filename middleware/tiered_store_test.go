@@ -0,0 +1,145 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton/v2"
+)
+
+func TestTieredStore(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Panics(func() {
+		NewTieredStore(TieredStoreConfig{})
+	})
+
+	back := NewFileStore(t.TempDir())
+	store := NewTieredStore(TieredStoreConfig{
+		Back:        back,
+		FrontTTL:    time.Second,
+		NegativeTTL: time.Minute,
+	})
+	ctx := context.Background()
+
+	// 两级均无数据，设置负缓存
+	buf, err := store.Get(ctx, "a")
+	assert.Nil(err)
+	assert.Nil(buf)
+	// 后续写入back的数据在负缓存期间不可读取
+	_ = back.Set(ctx, "a", []byte("a"), time.Minute)
+	buf, _ = store.Get(ctx, "a")
+	assert.Nil(buf)
+
+	// 写入两级，并清除负缓存
+	assert.Nil(store.Set(ctx, "a", []byte("a"), time.Minute))
+	buf, _ = store.Get(ctx, "a")
+	assert.Equal([]byte("a"), buf)
+	buf, _ = back.Get(ctx, "a")
+	assert.Equal([]byte("a"), buf)
+
+	// front过期后从back读取并回写front
+	time.Sleep(2 * time.Second)
+	buf, _ = store.Get(ctx, "a")
+	assert.Equal([]byte("a"), buf)
+	buf, _ = store.Get(ctx, "a")
+	assert.Equal([]byte("a"), buf)
+
+	assert.Equal(TieredStoreStats{
+		FrontHits:    2,
+		FrontMisses:  3,
+		BackHits:     1,
+		BackMisses:   1,
+		NegativeHits: 1,
+	}, store.Stats())
+
+	assert.Nil(store.Delete(ctx, "a"))
+	buf, _ = store.Get(ctx, "a")
+	assert.Nil(buf)
+
+	// file store不支持prefix与tag
+	_, err = store.PurgePrefix(ctx, "a")
+	assert.True(errors.Is(err, ErrCachePurgeNotSupported))
+	_, err = store.PurgeTag(ctx, "a")
+	assert.True(errors.Is(err, ErrCachePurgeNotSupported))
+	err = store.Tag(ctx, "a", "tag")
+	assert.True(errors.Is(err, ErrCachePurgeNotSupported))
+}
+
+func TestTieredStoreCacheWithoutTag(t *testing.T) {
+	assert := assert.New(t)
+
+	store := NewTieredStore(TieredStoreConfig{
+		Back: NewFileStore(t.TempDir()),
+	})
+	e := elton.New()
+	e.Use(NewCache(CacheConfig{
+		Store: store,
+	}))
+	e.GET("/", func(c *elton.Context) error {
+		c.CacheMaxAge(time.Minute)
+		c.SetHeader(HeaderSurrogateKey, "user")
+		c.BodyBuffer = bytes.NewBufferString("abc")
+		return nil
+	})
+	// back store不支持标签时，忽略标签正常缓存
+	for _, xCache := range []string{"fetch", "hit"} {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(200, resp.Code)
+		assert.Equal(xCache, resp.Header().Get(HeaderXCache))
+		assert.Equal("abc", resp.Body.String())
+	}
+}
+
+func TestTieredStorePurge(t *testing.T) {
+	assert := assert.New(t)
+
+	back := NewLRUStore(10)
+	store := NewTieredStore(TieredStoreConfig{
+		Back: back,
+	})
+	ctx := context.Background()
+	_ = store.Set(ctx, "GET /users/1", []byte("1"), time.Minute)
+	_ = store.Set(ctx, "GET /users/2", []byte("2"), time.Minute)
+	assert.Nil(store.Tag(ctx, "GET /users/1", "user-1"))
+
+	count, err := store.PurgeTag(ctx, "user-1")
+	assert.Nil(err)
+	assert.Equal(1, count)
+	buf, _ := store.Get(ctx, "GET /users/1")
+	assert.Nil(buf)
+
+	count, err = store.PurgePrefix(ctx, "GET /users/")
+	assert.Nil(err)
+	assert.Equal(1, count)
+	buf, _ = store.Get(ctx, "GET /users/2")
+	assert.Nil(buf)
+}
@@ -0,0 +1,503 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"context"
+	"errors"
+	"hash/crc32"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vicanso/elton/v2"
+	"github.com/vicanso/hes"
+)
+
+// UpstreamStrategy the load balancing strategy of upstream
+type UpstreamStrategy int
+
+const (
+	// UpstreamRoundRobin picks the targets in turn
+	UpstreamRoundRobin UpstreamStrategy = iota
+	// UpstreamWeighted picks the targets by weight(smooth weighted round-robin)
+	UpstreamWeighted
+	// UpstreamLeastConn picks the target with the least connections
+	UpstreamLeastConn
+	// UpstreamHash picks the target by consistent hash of key
+	UpstreamHash
+)
+
+var (
+	// ErrUpstreamUnavailable all targets of upstream are unavailable
+	ErrUpstreamUnavailable = &hes.Error{
+		StatusCode: http.StatusServiceUnavailable,
+		Message:    "no available upstream target",
+		Category:   ErrProxyCategory,
+	}
+	// ErrUpstreamRequireTarget upstream require target
+	ErrUpstreamRequireTarget = errors.New("elton: require target for upstream")
+)
+
+type (
+	// UpstreamTarget the target of upstream
+	UpstreamTarget struct {
+		URL *url.URL
+		// Weight the weight of target for weighted strategy, default is 1
+		Weight int
+	}
+	// UpstreamHealthCheckConfig the active health check config
+	UpstreamHealthCheckConfig struct {
+		// Path the path of health check, default is "/"
+		Path string
+		// Interval the interval of health check, default is 10s
+		Interval time.Duration
+		// Timeout the timeout of health check request, default is 3s
+		Timeout time.Duration
+		// Client the http client of health check, default is http.DefaultClient
+		Client *http.Client
+		// HealthyThreshold the consecutive successes to mark the target healthy, default is 1
+		HealthyThreshold int
+		// UnhealthyThreshold the consecutive failures to mark the target unhealthy, default is 2
+		UnhealthyThreshold int
+		// Validate validates the response of health check, default is 2xx and 3xx
+		Validate func(resp *http.Response) bool
+	}
+	// UpstreamConfig upstream config
+	UpstreamConfig struct {
+		Targets  []UpstreamTarget
+		Strategy UpstreamStrategy
+		// HashKey returns the key of consistent hash, default is the client ip
+		HashKey func(c *elton.Context) string
+		// Replicas the virtual nodes of each target for consistent hash, default is 100
+		Replicas int
+		// HealthCheck the active health check, it is started by StartHealthCheck
+		HealthCheck *UpstreamHealthCheckConfig
+		// MaxFails the consecutive failures of proxy to eject the target, default is 3
+		MaxFails int
+		// FailTimeout the duration of ejection, default is 10s
+		FailTimeout time.Duration
+		// DisableEjection disables the passive ejection
+		DisableEjection bool
+		// OnStatusChange the hook of the target is marked unavailable(unhealthy or ejected)
+		// or available again, it is called synchronously. The recovery of ejection
+		// is emitted when the next target is picked after the fail timeout.
+		OnStatusChange func(target string, available bool)
+	}
+	// UpstreamStatus the status of upstream target
+	UpstreamStatus struct {
+		Target  string `json:"target"`
+		Weight  int    `json:"weight"`
+		Healthy bool   `json:"healthy"`
+		Ejected bool   `json:"ejected"`
+		Conns   int64  `json:"conns"`
+	}
+	// Upstream the pool of proxy targets, Pick can be used as ProxyConfig.TargetPicker.
+	Upstream struct {
+		config  UpstreamConfig
+		servers []*upstreamServer
+		// ring the consistent hash ring
+		ring      []upstreamRingNode
+		index     atomic.Uint64
+		mu        sync.Mutex
+		stopMu    sync.Mutex
+		stopCheck context.CancelFunc
+	}
+	upstreamServer struct {
+		url    *url.URL
+		target string
+		weight int
+		// currentWeight smooth weighted round-robin, protected by Upstream.mu
+		currentWeight int
+		conns         atomic.Int64
+		// unhealthy marked by active health check
+		unhealthy atomic.Bool
+		// ejectedUntil unix nano of passive ejection
+		ejectedUntil atomic.Int64
+		// fails consecutive failures of proxy
+		fails atomic.Int32
+		// checkPasses and checkFails consecutive results of health check
+		checkPasses int
+		checkFails  int
+	}
+	upstreamRingNode struct {
+		hash   uint32
+		server *upstreamServer
+	}
+)
+
+// NewUpstream returns a new upstream of targets,
+// it will throw a panic if there is no target.
+func NewUpstream(config UpstreamConfig) *Upstream {
+	if len(config.Targets) == 0 {
+		panic(ErrUpstreamRequireTarget)
+	}
+	if config.Replicas <= 0 {
+		config.Replicas = 100
+	}
+	if config.MaxFails <= 0 {
+		config.MaxFails = 3
+	}
+	if config.FailTimeout <= 0 {
+		config.FailTimeout = 10 * time.Second
+	}
+	if config.HashKey == nil {
+		config.HashKey = func(c *elton.Context) string {
+			return c.ClientIP()
+		}
+	}
+	u := &Upstream{
+		config: config,
+	}
+	for _, t := range config.Targets {
+		if t.URL == nil {
+			panic(ErrUpstreamRequireTarget)
+		}
+		weight := t.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		server := &upstreamServer{
+			url:    t.URL,
+			target: t.URL.String(),
+			weight: weight,
+		}
+		u.servers = append(u.servers, server)
+		for i := 0; i < config.Replicas; i++ {
+			u.ring = append(u.ring, upstreamRingNode{
+				hash:   crc32.ChecksumIEEE([]byte(server.target + "#" + strconv.Itoa(i))),
+				server: server,
+			})
+		}
+	}
+	slices.SortFunc(u.ring, func(a, b upstreamRingNode) int {
+		if a.hash < b.hash {
+			return -1
+		}
+		if a.hash > b.hash {
+			return 1
+		}
+		return 0
+	})
+	return u
+}
+
+func (s *upstreamServer) available(now int64) bool {
+	return !s.unhealthy.Load() && s.ejectedUntil.Load() <= now
+}
+
+// recoverEjected clears the expired ejection of servers and emits the
+// status change, the recovery is detected lazily when target is picked.
+func (u *Upstream) recoverEjected(now int64) {
+	for _, server := range u.servers {
+		ejectedUntil := server.ejectedUntil.Load()
+		if ejectedUntil == 0 || ejectedUntil > now {
+			continue
+		}
+		// 仅一个goroutine可成功清除，避免重复触发
+		if !server.ejectedUntil.CompareAndSwap(ejectedUntil, 0) {
+			continue
+		}
+		// 被主动健康检查标记为不健康的，由健康检查触发恢复
+		if !server.unhealthy.Load() {
+			u.emit(server.target, true)
+		}
+	}
+}
+
+func (u *Upstream) emit(target string, available bool) {
+	if u.config.OnStatusChange != nil {
+		u.config.OnStatusChange(target, available)
+	}
+}
+
+// Pick picks an available target by the strategy, it can be used as
+// ProxyConfig.TargetPicker. The returned ProxyDone releases the connection
+// and reports the result of proxy for passive ejection.
+func (u *Upstream) Pick(c *elton.Context) (*url.URL, ProxyDone, error) {
+	now := time.Now().UnixNano()
+	u.recoverEjected(now)
+	var server *upstreamServer
+	switch u.config.Strategy {
+	case UpstreamWeighted:
+		server = u.pickWeighted(now)
+	case UpstreamLeastConn:
+		server = u.pickLeastConn(now)
+	case UpstreamHash:
+		server = u.pickHash(u.config.HashKey(c), now)
+	default:
+		server = u.pickRoundRobin(now)
+	}
+	if server == nil {
+		return nil, nil, ErrUpstreamUnavailable
+	}
+	server.conns.Add(1)
+	return server.url, func(c *elton.Context) {
+		server.conns.Add(-1)
+		u.report(server, GetProxyError(c) == nil && c.StatusCode < http.StatusInternalServerError)
+	}, nil
+}
+
+func (u *Upstream) pickRoundRobin(now int64) *upstreamServer {
+	size := uint64(len(u.servers))
+	start := u.index.Add(1) - 1
+	for i := range size {
+		server := u.servers[(start+i)%size]
+		if server.available(now) {
+			return server
+		}
+	}
+	return nil
+}
+
+// pickWeighted picks the server by smooth weighted round-robin
+func (u *Upstream) pickWeighted(now int64) *upstreamServer {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	var best *upstreamServer
+	total := 0
+	for _, server := range u.servers {
+		if !server.available(now) {
+			continue
+		}
+		server.currentWeight += server.weight
+		total += server.weight
+		if best == nil || server.currentWeight > best.currentWeight {
+			best = server
+		}
+	}
+	if best != nil {
+		best.currentWeight -= total
+	}
+	return best
+}
+
+func (u *Upstream) pickLeastConn(now int64) *upstreamServer {
+	size := uint64(len(u.servers))
+	// 连接数相同的轮流选择
+	start := u.index.Add(1) - 1
+	var best *upstreamServer
+	for i := range size {
+		server := u.servers[(start+i)%size]
+		if !server.available(now) {
+			continue
+		}
+		if best == nil || server.conns.Load() < best.conns.Load() {
+			best = server
+		}
+	}
+	return best
+}
+
+// pickHash picks the first available server of the ring clockwise
+func (u *Upstream) pickHash(key string, now int64) *upstreamServer {
+	size := len(u.ring)
+	hash := crc32.ChecksumIEEE([]byte(key))
+	start, _ := slices.BinarySearchFunc(u.ring, hash, func(node upstreamRingNode, target uint32) int {
+		if node.hash < target {
+			return -1
+		}
+		if node.hash > target {
+			return 1
+		}
+		return 0
+	})
+	for i := range size {
+		server := u.ring[(start+i)%size].server
+		if server.available(now) {
+			return server
+		}
+	}
+	return nil
+}
+
+// report reports the result of proxy, the server is ejected
+// if the consecutive failures reach max fails.
+func (u *Upstream) report(server *upstreamServer, success bool) {
+	if success {
+		server.fails.Store(0)
+		return
+	}
+	if u.config.DisableEjection {
+		return
+	}
+	if int(server.fails.Add(1)) < u.config.MaxFails {
+		return
+	}
+	server.fails.Store(0)
+	server.ejectedUntil.Store(time.Now().Add(u.config.FailTimeout).UnixNano())
+	u.emit(server.target, false)
+}
+
+// Status returns the status of targets
+func (u *Upstream) Status() []UpstreamStatus {
+	now := time.Now().UnixNano()
+	result := make([]UpstreamStatus, len(u.servers))
+	for i, server := range u.servers {
+		result[i] = UpstreamStatus{
+			Target:  server.target,
+			Weight:  server.weight,
+			Healthy: !server.unhealthy.Load(),
+			Ejected: server.ejectedUntil.Load() > now,
+			Conns:   server.conns.Load(),
+		}
+	}
+	return result
+}
+
+// HealthCheck checks the health of all targets once, the target is marked
+// unhealthy if the consecutive failures reach the unhealthy threshold.
+// It does nothing if the health check is not configured.
+func (u *Upstream) HealthCheck(ctx context.Context) {
+	config := u.healthCheckConfig()
+	if config == nil {
+		return
+	}
+	var wg sync.WaitGroup
+	results := make([]bool, len(u.servers))
+	for i, server := range u.servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = checkUpstreamServer(ctx, config, server)
+		}()
+	}
+	wg.Wait()
+	u.mu.Lock()
+	type change struct {
+		target    string
+		available bool
+	}
+	var changes []change
+	for i, server := range u.servers {
+		if results[i] {
+			server.checkFails = 0
+			server.checkPasses++
+			if server.unhealthy.Load() && server.checkPasses >= config.HealthyThreshold {
+				server.unhealthy.Store(false)
+				// 恢复健康则取消被动摘除
+				server.ejectedUntil.Store(0)
+				changes = append(changes, change{server.target, true})
+			}
+			continue
+		}
+		server.checkPasses = 0
+		server.checkFails++
+		if !server.unhealthy.Load() && server.checkFails >= config.UnhealthyThreshold {
+			server.unhealthy.Store(true)
+			changes = append(changes, change{server.target, false})
+		}
+	}
+	u.mu.Unlock()
+	for _, item := range changes {
+		u.emit(item.target, item.available)
+	}
+}
+
+func (u *Upstream) healthCheckConfig() *UpstreamHealthCheckConfig {
+	if u.config.HealthCheck == nil {
+		return nil
+	}
+	config := *u.config.HealthCheck
+	if config.Path == "" {
+		config.Path = "/"
+	}
+	if config.Interval <= 0 {
+		config.Interval = 10 * time.Second
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 3 * time.Second
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	if config.HealthyThreshold <= 0 {
+		config.HealthyThreshold = 1
+	}
+	if config.UnhealthyThreshold <= 0 {
+		config.UnhealthyThreshold = 2
+	}
+	if config.Validate == nil {
+		config.Validate = func(resp *http.Response) bool {
+			return resp.StatusCode >= 200 && resp.StatusCode < 400
+		}
+	}
+	return &config
+}
+
+func checkUpstreamServer(ctx context.Context, config *UpstreamHealthCheckConfig, server *upstreamServer) bool {
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.url.JoinPath(config.Path).String(), nil)
+	if err != nil {
+		return false
+	}
+	resp, err := config.Client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	return config.Validate(resp)
+}
+
+// StartHealthCheck starts the active health check in background,
+// it does nothing if the health check is not configured or started.
+func (u *Upstream) StartHealthCheck() {
+	config := u.healthCheckConfig()
+	if config == nil {
+		return
+	}
+	u.stopMu.Lock()
+	defer u.stopMu.Unlock()
+	if u.stopCheck != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	u.stopCheck = cancel
+	go func() {
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+		for {
+			u.HealthCheck(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// StopHealthCheck stops the active health check
+func (u *Upstream) StopHealthCheck() {
+	u.stopMu.Lock()
+	defer u.stopMu.Unlock()
+	if u.stopCheck != nil {
+		u.stopCheck()
+		u.stopCheck = nil
+	}
+}
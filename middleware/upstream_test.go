@@ -0,0 +1,317 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton/v2"
+)
+
+func newUpstreamTargets(weights ...int) []UpstreamTarget {
+	targets := make([]UpstreamTarget, len(weights))
+	for i, weight := range weights {
+		u, _ := url.Parse("http://127.0.0.1:" + strconv.Itoa(3001+i))
+		targets[i] = UpstreamTarget{
+			URL:    u,
+			Weight: weight,
+		}
+	}
+	return targets
+}
+
+func pickUpstream(u *Upstream, c *elton.Context, release bool) string {
+	target, done, err := u.Pick(c)
+	if err != nil {
+		return err.Error()
+	}
+	if release {
+		done(c)
+	}
+	return target.Host
+}
+
+func TestUpstreamRoundRobin(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Panics(func() {
+		NewUpstream(UpstreamConfig{})
+	})
+
+	u := NewUpstream(UpstreamConfig{
+		Targets: newUpstreamTargets(1, 1, 1),
+	})
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	var hosts []string
+	for range 4 {
+		hosts = append(hosts, pickUpstream(u, c, true))
+	}
+	assert.Equal([]string{
+		"127.0.0.1:3001",
+		"127.0.0.1:3002",
+		"127.0.0.1:3003",
+		"127.0.0.1:3001",
+	}, hosts)
+
+	// 不可用的target跳过
+	u.servers[1].unhealthy.Store(true)
+	u.servers[2].ejectedUntil.Store(time.Now().Add(time.Minute).UnixNano())
+	for range 3 {
+		assert.Equal("127.0.0.1:3001", pickUpstream(u, c, true))
+	}
+	u.servers[0].unhealthy.Store(true)
+	_, _, err := u.Pick(c)
+	assert.Equal(ErrUpstreamUnavailable, err)
+}
+
+func TestUpstreamWeighted(t *testing.T) {
+	assert := assert.New(t)
+
+	u := NewUpstream(UpstreamConfig{
+		Targets:  newUpstreamTargets(5, 1, 1),
+		Strategy: UpstreamWeighted,
+	})
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	var hosts []string
+	for range 7 {
+		hosts = append(hosts, pickUpstream(u, c, true))
+	}
+	// 平滑加权轮询
+	assert.Equal([]string{
+		"127.0.0.1:3001",
+		"127.0.0.1:3001",
+		"127.0.0.1:3002",
+		"127.0.0.1:3001",
+		"127.0.0.1:3003",
+		"127.0.0.1:3001",
+		"127.0.0.1:3001",
+	}, hosts)
+}
+
+func TestUpstreamLeastConn(t *testing.T) {
+	assert := assert.New(t)
+
+	u := NewUpstream(UpstreamConfig{
+		Targets:  newUpstreamTargets(1, 1),
+		Strategy: UpstreamLeastConn,
+	})
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	first, done, err := u.Pick(c)
+	assert.Nil(err)
+	// 未释放的连接数更多，选择另一个
+	for range 3 {
+		target, release, err := u.Pick(c)
+		assert.Nil(err)
+		assert.NotEqual(first.Host, target.Host)
+		release(c)
+	}
+	assert.Equal(int64(1), u.Status()[0].Conns+u.Status()[1].Conns)
+	done(c)
+	assert.Equal(int64(0), u.Status()[0].Conns+u.Status()[1].Conns)
+}
+
+func TestUpstreamHash(t *testing.T) {
+	assert := assert.New(t)
+
+	u := NewUpstream(UpstreamConfig{
+		Targets:  newUpstreamTargets(1, 1, 1),
+		Strategy: UpstreamHash,
+		HashKey: func(c *elton.Context) string {
+			return c.QueryParam("user")
+		},
+	})
+	counts := make(map[string]int)
+	mapping := make(map[string]string)
+	for i := range 300 {
+		user := strconv.Itoa(i)
+		c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/?user="+user, nil))
+		host := pickUpstream(u, c, true)
+		// 相同的key选择相同的target
+		assert.Equal(host, pickUpstream(u, c, true))
+		counts[host]++
+		mapping[user] = host
+	}
+	assert.Equal(3, len(counts))
+
+	// target不可用时，仅其上的key迁移至其它target
+	u.servers[0].unhealthy.Store(true)
+	for user, host := range mapping {
+		c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/?user="+user, nil))
+		current := pickUpstream(u, c, true)
+		if host != "127.0.0.1:3001" {
+			assert.Equal(host, current)
+		} else {
+			assert.NotEqual(host, current)
+		}
+	}
+}
+
+func TestUpstreamEjection(t *testing.T) {
+	assert := assert.New(t)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+	okTarget, _ := url.Parse(upstream.URL)
+	badTarget := newClosedTarget()
+
+	var mu sync.Mutex
+	var changes []string
+	u := NewUpstream(UpstreamConfig{
+		Targets: []UpstreamTarget{
+			{
+				URL: badTarget,
+			},
+			{
+				URL: okTarget,
+			},
+		},
+		MaxFails:    2,
+		FailTimeout: time.Minute,
+		OnStatusChange: func(target string, available bool) {
+			mu.Lock()
+			defer mu.Unlock()
+			changes = append(changes, target+":"+strconv.FormatBool(available))
+		},
+	})
+	e := elton.New()
+	e.GET("/", NewProxy(ProxyConfig{
+		TargetPicker: u.Pick,
+	}))
+	codes := make(map[int]int)
+	for range 6 {
+		req := httptest.NewRequest("GET", "/", nil)
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		codes[resp.Code]++
+	}
+	// 连续失败两次后摘除
+	assert.Equal(map[int]int{
+		http.StatusBadGateway: 2,
+		http.StatusOK:         4,
+	}, codes)
+	status := u.Status()
+	assert.True(status[0].Ejected)
+	assert.False(status[1].Ejected)
+	assert.Equal(int64(0), status[0].Conns)
+	assert.Equal([]string{badTarget.String() + ":false"}, changes)
+}
+
+func TestUpstreamEjectionRecover(t *testing.T) {
+	assert := assert.New(t)
+
+	target, _ := url.Parse("http://127.0.0.1:3001")
+	var changes []string
+	u := NewUpstream(UpstreamConfig{
+		Targets: []UpstreamTarget{
+			{
+				URL: target,
+			},
+		},
+		MaxFails:    1,
+		FailTimeout: 20 * time.Millisecond,
+		OnStatusChange: func(target string, available bool) {
+			changes = append(changes, target+":"+strconv.FormatBool(available))
+		},
+	})
+	u.report(u.servers[0], false)
+	_, _, err := u.Pick(nil)
+	assert.Equal(ErrUpstreamUnavailable, err)
+
+	// 摘除超时后，下一次选择时触发恢复
+	time.Sleep(30 * time.Millisecond)
+	result, done, err := u.Pick(nil)
+	assert.Nil(err)
+	assert.Equal(target, result)
+	assert.NotNil(done)
+	_, _, err = u.Pick(nil)
+	assert.Nil(err)
+	assert.Equal([]string{
+		target.String() + ":false",
+		target.String() + ":true",
+	}, changes)
+}
+
+func TestUpstreamHealthCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	var mu sync.Mutex
+	healthy := false
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path != "/ping" || !healthy {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer upstream.Close()
+	target, _ := url.Parse(upstream.URL)
+
+	var changes []string
+	u := NewUpstream(UpstreamConfig{
+		Targets: []UpstreamTarget{
+			{
+				URL: target,
+			},
+		},
+		HealthCheck: &UpstreamHealthCheckConfig{
+			Path:     "/ping",
+			Interval: 10 * time.Millisecond,
+		},
+		OnStatusChange: func(_ string, available bool) {
+			mu.Lock()
+			defer mu.Unlock()
+			changes = append(changes, strconv.FormatBool(available))
+		},
+	})
+	ctx := context.Background()
+	u.HealthCheck(ctx)
+	assert.True(u.Status()[0].Healthy)
+	u.HealthCheck(ctx)
+	assert.False(u.Status()[0].Healthy)
+
+	mu.Lock()
+	healthy = true
+	mu.Unlock()
+	u.StartHealthCheck()
+	defer u.StopHealthCheck()
+	assert.Eventually(func() bool {
+		return u.Status()[0].Healthy
+	}, time.Second, 5*time.Millisecond)
+	u.StopHealthCheck()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal([]string{"false", "true"}, changes)
+}
@@ -0,0 +1,193 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package elton
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// mountPoint the elton instance or http handler mounted at prefix
+type mountPoint struct {
+	prefix  string
+	child   *Elton
+	handler http.Handler
+}
+
+// mountResponseWriter records the status of mounted handler,
+// it is set to Context.StatusCode for outer middlewares.
+type mountResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *mountResponseWriter) WriteHeader(statusCode int) {
+	if w.status == 0 {
+		w.status = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *mountResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap supports http.ResponseController and middleware that peel wrappers.
+func (w *mountResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *mountResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *mountResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hj, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hj.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+// normalizeMountPrefix returns the prefix without trailing slash,
+// the prefix should start with "/" and not contain any wildcard.
+func normalizeMountPrefix(prefix string) (string, error) {
+	p := strings.TrimRight(prefix, "/")
+	if p == "" || p[0] != '/' || strings.ContainsAny(p, "{}:*") {
+		return "", fmt.Errorf("elton: invalid mount prefix %q", prefix)
+	}
+	return p, nil
+}
+
+// stripMountPrefix returns a shallow copy of request with the prefix
+// stripped from its url path, the original request is not modified.
+func stripMountPrefix(req *http.Request, prefix string) *http.Request {
+	path := strings.TrimPrefix(req.URL.Path, prefix)
+	if path == "" {
+		path = "/"
+	}
+	rawPath := ""
+	if req.URL.RawPath != "" {
+		rawPath = strings.TrimPrefix(req.URL.RawPath, prefix)
+		if rawPath == "" {
+			rawPath = "/"
+		}
+	}
+	r := new(http.Request)
+	*r = *req
+	r.URL = new(url.URL)
+	*r.URL = *req.URL
+	r.URL.Path = path
+	r.URL.RawPath = rawPath
+	return r
+}
+
+// Mount mounts the child elton at prefix, the requests of "prefix/..." are
+// forwarded to child with the prefix stripped from url path. The child uses
+// its own middlewares, error handler, not found handler and listeners, the
+// status of child is ignored. Unlike Context.Pass, the global middlewares of
+// current elton are called before and after child, and the request path is
+// not changed for them. The routers of child are merged into Routers with the
+// prefix, e.g.
+//
+//	api := elton.New()
+//	api.GET("/users/{id}", getUser)
+//	e.Mount("/api/v1", api)
+//
+// The returned *Route can be used to set name, meta and route middlewares.
+// It will throw a panic if the prefix is invalid or conflicted.
+func (e *Elton) Mount(prefix string, child *Elton) *Route {
+	if child == nil || child == e {
+		panic(fmt.Errorf("elton: invalid elton of mount %q", prefix))
+	}
+	return e.mount(&mountPoint{
+		prefix: prefix,
+		child:  child,
+	})
+}
+
+// MountHandler mounts the http handler at prefix, the prefix is stripped
+// from url path before the handler is called, e.g.
+//
+//	e.MountHandler("/static", http.FileServer(http.Dir("./public")))
+//
+// It will throw a panic if the prefix is invalid or conflicted.
+func (e *Elton) MountHandler(prefix string, handler http.Handler) *Route {
+	if handler == nil {
+		panic(fmt.Errorf("elton: invalid handler of mount %q", prefix))
+	}
+	return e.mount(&mountPoint{
+		prefix:  prefix,
+		handler: handler,
+	})
+}
+
+func (e *Elton) mount(mp *mountPoint) *Route {
+	prefix, err := normalizeMountPrefix(mp.prefix)
+	if err != nil {
+		panic(err)
+	}
+	mp.prefix = prefix
+	// "prefix/" 匹配所有子路径，"prefix" 由ServeMux重定向至"prefix/"
+	route := e.Handle("", prefix+"/", mp.serve)
+	route.mount = mp
+	return route
+}
+
+func (mp *mountPoint) serve(c *Context) error {
+	// 设置为已commit，由子实例（handler）响应
+	c.Committed = true
+	w := &mountResponseWriter{
+		ResponseWriter: c.Response,
+	}
+	req := stripMountPrefix(c.Request, mp.prefix)
+	if mp.child != nil {
+		mp.child.serve(w, req)
+	} else {
+		mp.handler.ServeHTTP(w, req)
+	}
+	if w.status != 0 {
+		c.StatusCode = w.status
+	}
+	return nil
+}
+
+// routers returns the routers of mounted elton with prefix
+func (mp *mountPoint) routers() []RouterInfo {
+	if mp.child == nil {
+		return nil
+	}
+	routers := mp.child.Routers()
+	for i, r := range routers {
+		routers[i].Route = mp.prefix + r.Route
+	}
+	return routers
+}
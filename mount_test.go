@@ -0,0 +1,230 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package elton
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/hes"
+)
+
+func TestNormalizeMountPrefix(t *testing.T) {
+	assert := assert.New(t)
+
+	prefix, err := normalizeMountPrefix("/api/")
+	assert.Nil(err)
+	assert.Equal("/api", prefix)
+
+	for _, prefix := range []string{
+		"",
+		"/",
+		"api",
+		"/users/{id}",
+		"/users/:id",
+		"/static/*",
+	} {
+		_, err := normalizeMountPrefix(prefix)
+		assert.NotNil(err, prefix)
+	}
+}
+
+func TestMount(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New()
+	var outerPaths []string
+	var outerStatus int
+	e.Use(func(c *Context) error {
+		err := c.Next()
+		outerPaths = append(outerPaths, c.Request.URL.Path)
+		outerStatus = c.StatusCode
+		return err
+	})
+	e.GET("/ping", func(c *Context) error {
+		c.BodyBuffer = bytes.NewBufferString("pong")
+		return nil
+	})
+
+	api := New()
+	var errs []error
+	api.OnError(func(_ *Context, err error) {
+		errs = append(errs, err)
+	})
+	api.Use(func(c *Context) error {
+		c.SetHeader("X-Api", "1")
+		return c.Next()
+	})
+	api.GET("/", func(c *Context) error {
+		c.BodyBuffer = bytes.NewBufferString("index")
+		return nil
+	})
+	api.GET("/users/{id}", func(c *Context) error {
+		c.BodyBuffer = bytes.NewBufferString(c.Request.URL.Path + ":" + c.Param("id"))
+		return nil
+	})
+	api.GET("/error", func(c *Context) error {
+		return hes.New("custom error", hes.WithStatus(http.StatusBadRequest))
+	})
+	route := e.Mount("/api/v1/", api).Name("api")
+	assert.Equal("/api/v1/", route.Path())
+	assert.Equal("api", route.name)
+
+	req := httptest.NewRequest("GET", "/api/v1/users/1", nil)
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal("/users/1:1", resp.Body.String())
+	assert.Equal("1", resp.Header().Get("X-Api"))
+	// 外层中间件的请求路径不受影响
+	assert.Equal([]string{"/api/v1/users/1"}, outerPaths)
+	assert.Equal(http.StatusOK, outerStatus)
+
+	req = httptest.NewRequest("GET", "/api/v1/", nil)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal("index", resp.Body.String())
+
+	// 子实例的出错处理
+	req = httptest.NewRequest("GET", "/api/v1/error", nil)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(http.StatusBadRequest, resp.Code)
+	assert.Equal("statusCode=400, message=custom error", resp.Body.String())
+	assert.Equal(http.StatusBadRequest, outerStatus)
+	assert.Equal(1, len(errs))
+
+	// 子实例的not found
+	req = httptest.NewRequest("GET", "/api/v1/books", nil)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(http.StatusNotFound, resp.Code)
+	assert.Equal(http.StatusNotFound, outerStatus)
+
+	// 无尾斜杠重定向
+	req = httptest.NewRequest("GET", "/api/v1", nil)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(http.StatusTemporaryRedirect, resp.Code)
+	assert.Equal("/api/v1/", resp.Header().Get("Location"))
+
+	req = httptest.NewRequest("GET", "/ping", nil)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal("pong", resp.Body.String())
+
+	assert.Equal([]RouterInfo{
+		{
+			Method: "GET",
+			Route:  "/ping",
+		},
+		{
+			Method: "GET",
+			Route:  "/api/v1/{$}",
+		},
+		{
+			Method: "GET",
+			Route:  "/api/v1/users/{id}",
+		},
+		{
+			Method: "GET",
+			Route:  "/api/v1/error",
+		},
+	}, e.Routers())
+
+	assert.Panics(func() {
+		e.Mount("/api/v1", New())
+	})
+	assert.Panics(func() {
+		e.Mount("/admin", e)
+	})
+	assert.Panics(func() {
+		e.Mount("/{name}", New())
+	})
+}
+
+func TestMountHandler(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New()
+	e.MountHandler("/static", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(r.URL.Path + "|" + r.URL.RawPath))
+	})).Use(func(c *Context) error {
+		if c.GetRequestHeader("X-Token") == "" {
+			return errors.New("token is required")
+		}
+		return c.Next()
+	})
+
+	req := httptest.NewRequest("GET", "/static/a%2Fb.js", nil)
+	req.Header.Set("X-Token", "1")
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(http.StatusAccepted, resp.Code)
+	assert.Equal("/a/b.js|/a%2Fb.js", resp.Body.String())
+
+	// 路由中间件在handler之前执行
+	req = httptest.NewRequest("GET", "/static/a.js", nil)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(http.StatusInternalServerError, resp.Code)
+
+	assert.Equal([]RouterInfo{
+		{
+			Route: "/static/",
+		},
+	}, e.Routers())
+
+	assert.Panics(func() {
+		e.MountHandler("/files", nil)
+	})
+}
+
+func TestMountRouteDoc(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New()
+	api := New()
+	api.GET("/users", func(c *Context) error {
+		return nil
+	}).Describe(&RouteDoc{
+		Summary: "list users",
+	})
+	e.Mount("/api", api)
+
+	doc := e.GetRouteDoc("GET", "/api/users")
+	assert.NotNil(doc)
+	assert.Equal("list users", doc.Summary)
+	assert.Nil(e.GetRouteDoc("GET", "/api/books"))
+
+	openapi := e.OpenAPI(OpenAPIInfo{
+		Title: "test",
+	})
+	assert.Equal("list users", openapi.Paths["/api/users"]["get"].Summary)
+}
@@ -0,0 +1,445 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package elton
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OpenAPIVersion the version of generated openapi document
+const OpenAPIVersion = "3.1.0"
+
+type (
+	// RouteDoc the document of route, it is used to generate openapi document
+	RouteDoc struct {
+		// OperationID unique id of the operation
+		OperationID string
+		// Summary short summary of the operation
+		Summary string
+		// Description verbose explanation of the operation
+		Description string
+		// Tags tags for grouping operations
+		Tags []string
+		// Deprecated the operation is deprecated
+		Deprecated bool
+		// Request the value(or nil pointer) of request body type, e.g. &User{}
+		Request any
+		// Response the value(or nil pointer) of response body type
+		Response any
+		// ResponseStatus the status code of response, default is 200
+		ResponseStatus int
+		// Parameters other parameters(query, header, cookie),
+		// path parameters are generated from route automatically
+		Parameters []OpenAPIParameter
+	}
+	// OpenAPIInfo the info of openapi document
+	OpenAPIInfo struct {
+		Title       string `json:"title"`
+		Version     string `json:"version"`
+		Description string `json:"description,omitempty"`
+	}
+	// OpenAPIDocument openapi document
+	OpenAPIDocument struct {
+		OpenAPI    string                                  `json:"openapi"`
+		Info       OpenAPIInfo                             `json:"info"`
+		Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+		Components *OpenAPIComponents                      `json:"components,omitempty"`
+	}
+	// OpenAPIComponents openapi components
+	OpenAPIComponents struct {
+		Schemas map[string]*OpenAPISchema `json:"schemas,omitempty"`
+	}
+	// OpenAPIOperation openapi operation of path
+	OpenAPIOperation struct {
+		OperationID string                      `json:"operationId,omitempty"`
+		Summary     string                      `json:"summary,omitempty"`
+		Description string                      `json:"description,omitempty"`
+		Tags        []string                    `json:"tags,omitempty"`
+		Deprecated  bool                        `json:"deprecated,omitempty"`
+		Parameters  []OpenAPIParameter          `json:"parameters,omitempty"`
+		RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+		Responses   map[string]*OpenAPIResponse `json:"responses"`
+	}
+	// OpenAPIParameter openapi parameter
+	OpenAPIParameter struct {
+		Name        string         `json:"name"`
+		In          string         `json:"in"`
+		Description string         `json:"description,omitempty"`
+		Required    bool           `json:"required,omitempty"`
+		Schema      *OpenAPISchema `json:"schema,omitempty"`
+	}
+	// OpenAPIRequestBody openapi request body
+	OpenAPIRequestBody struct {
+		Required bool                         `json:"required,omitempty"`
+		Content  map[string]*OpenAPIMediaType `json:"content"`
+	}
+	// OpenAPIResponse openapi response
+	OpenAPIResponse struct {
+		Description string                       `json:"description"`
+		Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+	}
+	// OpenAPIMediaType openapi media type
+	OpenAPIMediaType struct {
+		Schema *OpenAPISchema `json:"schema,omitempty"`
+	}
+	// OpenAPISchema openapi(json) schema
+	OpenAPISchema struct {
+		Ref                  string                    `json:"$ref,omitempty"`
+		Type                 string                    `json:"type,omitempty"`
+		Format               string                    `json:"format,omitempty"`
+		Items                *OpenAPISchema            `json:"items,omitempty"`
+		Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+		AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+		Required             []string                  `json:"required,omitempty"`
+	}
+	// openAPISchemaBuilder generates schemas of go types,
+	// the named struct is added to components
+	openAPISchemaBuilder struct {
+		schemas map[string]*OpenAPISchema
+		names   map[reflect.Type]string
+	}
+)
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// Describe sets the document of the routes added by the last
+// Handle(or GET, POST, Multi...) call, e.g.
+//
+//	e.GET("/users/{id}", getUser).Describe(&elton.RouteDoc{
+//		Summary:  "get user",
+//		Response: &User{},
+//	})
+func (e *Elton) Describe(doc *RouteDoc) *Elton {
+	if route := e.RouteTable().lastRoute; route != nil {
+		route.Describe(doc)
+	}
+	return e
+}
+
+// GetRouteDoc returns the document of route, nil will be returned if not set.
+func (e *Elton) GetRouteDoc(method, route string) *RouteDoc {
+	if r, _ := e.lookupRoute(method, route); r != nil {
+		return r.doc
+	}
+	return nil
+}
+
+// lookupRoute returns the route of method and path, the routes of mounted
+// elton are also looked up. The disabled is true if the route or
+// its mount point is disabled.
+func (e *Elton) lookupRoute(method, route string) (*Route, bool) {
+	t := e.RouteTable()
+	if r := t.find(method, route); r != nil {
+		return r, r.Disabled()
+	}
+	// 挂载的elton实例的路由
+	for _, entry := range t.entries {
+		mp := entry.route.mount
+		if mp == nil || mp.child == nil {
+			continue
+		}
+		if sub, ok := strings.CutPrefix(route, mp.prefix); ok {
+			if r, disabled := mp.child.lookupRoute(method, sub); r != nil {
+				return r, disabled || entry.route.Disabled()
+			}
+		}
+	}
+	return nil, false
+}
+
+// Describe sets the document of the routes added by the last
+// handle(GET, POST, Multi...) call of group.
+func (g *Group) Describe(doc *RouteDoc) *Group {
+	for _, r := range g.routers[g.lastRouterIndex:] {
+		r.Doc = doc
+	}
+	return g
+}
+
+// toOpenAPIPath converts ServeMux pattern to openapi path,
+// "{$}" is removed and "{name...}" is converted to "{name}".
+func toOpenAPIPath(route string) string {
+	route = strings.ReplaceAll(route, "{$}", "")
+	route = strings.ReplaceAll(route, "...}", "}")
+	if route == "" {
+		return "/"
+	}
+	return route
+}
+
+func (b *openAPISchemaBuilder) schemaOf(value any) *OpenAPISchema {
+	if value == nil {
+		return nil
+	}
+	t, ok := value.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(value)
+	}
+	return b.build(t)
+}
+
+func (b *openAPISchemaBuilder) build(t reflect.Type) *OpenAPISchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &OpenAPISchema{
+			Type:   "string",
+			Format: "date-time",
+		}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		// []byte 以base64编码
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		return &OpenAPISchema{Type: "array", Items: b.build(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: b.build(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.buildStruct(t)
+		}
+		return b.buildRef(t)
+	}
+	// interface等无法确定类型
+	return &OpenAPISchema{}
+}
+
+// buildRef adds the named struct to components and returns its reference
+func (b *openAPISchemaBuilder) buildRef(t reflect.Type) *OpenAPISchema {
+	name, ok := b.names[t]
+	if !ok {
+		name = t.Name()
+		// 不同package的同名struct
+		if _, exists := b.schemas[name]; exists {
+			pkg := t.PkgPath()
+			name = pkg[strings.LastIndexByte(pkg, '/')+1:] + "." + name
+		}
+		b.names[t] = name
+		// 先占位，避免递归引用时死循环
+		b.schemas[name] = &OpenAPISchema{}
+		*b.schemas[name] = *b.buildStruct(t)
+	}
+	return &OpenAPISchema{Ref: "#/components/schemas/" + name}
+}
+
+func (b *openAPISchemaBuilder) buildStruct(t reflect.Type) *OpenAPISchema {
+	schema := &OpenAPISchema{
+		Type:       "object",
+		Properties: make(map[string]*OpenAPISchema),
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		ft := field.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		// 匿名嵌入的struct（与encoding/json一致，未导出的也展开）
+		if field.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			embedded := b.buildStruct(ft)
+			for k, v := range embedded.Properties {
+				schema.Properties[k] = v
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = b.build(field.Type)
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// OpenAPI generates the openapi 3.1 document of all routes,
+// the routes without method are ignored.
+func (e *Elton) OpenAPI(info OpenAPIInfo) *OpenAPIDocument {
+	doc := &OpenAPIDocument{
+		OpenAPI: OpenAPIVersion,
+		Info:    info,
+		Paths:   make(map[string]map[string]*OpenAPIOperation),
+	}
+	builder := &openAPISchemaBuilder{
+		schemas: make(map[string]*OpenAPISchema),
+		names:   make(map[reflect.Type]string),
+	}
+	for _, r := range e.Routers() {
+		if r.Method == "" {
+			continue
+		}
+		route, disabled := e.lookupRoute(r.Method, r.Route)
+		// 禁用的路由响应为404，不生成文档
+		if disabled {
+			continue
+		}
+		op := &OpenAPIOperation{
+			Responses: make(map[string]*OpenAPIResponse),
+		}
+		for _, name := range extractParamNames(r.Route) {
+			op.Parameters = append(op.Parameters, OpenAPIParameter{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   &OpenAPISchema{Type: "string"},
+			})
+		}
+		status := http.StatusOK
+		var responseSchema *OpenAPISchema
+		var d *RouteDoc
+		if route != nil {
+			d = route.doc
+		}
+		if d != nil {
+			op.OperationID = d.OperationID
+			op.Summary = d.Summary
+			op.Description = d.Description
+			op.Tags = d.Tags
+			op.Deprecated = d.Deprecated
+			op.Parameters = append(op.Parameters, d.Parameters...)
+			if schema := builder.schemaOf(d.Request); schema != nil {
+				op.RequestBody = &OpenAPIRequestBody{
+					Required: true,
+					Content: map[string]*OpenAPIMediaType{
+						"application/json": {
+							Schema: schema,
+						},
+					},
+				}
+			}
+			if d.ResponseStatus != 0 {
+				status = d.ResponseStatus
+			}
+			responseSchema = builder.schemaOf(d.Response)
+		}
+		resp := &OpenAPIResponse{
+			Description: http.StatusText(status),
+		}
+		if responseSchema != nil {
+			resp.Content = map[string]*OpenAPIMediaType{
+				"application/json": {
+					Schema: responseSchema,
+				},
+			}
+		}
+		op.Responses[strconv.Itoa(status)] = resp
+
+		path := toOpenAPIPath(r.Route)
+		item := doc.Paths[path]
+		if item == nil {
+			item = make(map[string]*OpenAPIOperation)
+			doc.Paths[path] = item
+		}
+		item[strings.ToLower(r.Method)] = op
+	}
+	if len(builder.schemas) != 0 {
+		doc.Components = &OpenAPIComponents{
+			Schemas: builder.schemas,
+		}
+	}
+	return doc
+}
+
+// openAPIRouteKey the key of route which affects the openapi document
+type openAPIRouteKey struct {
+	method   string
+	route    string
+	doc      *RouteDoc
+	disabled bool
+}
+
+// openAPIRouteKeys returns the keys of routes, they are used to
+// check whether the routes are changed.
+func (e *Elton) openAPIRouteKeys() []openAPIRouteKey {
+	routers := e.Routers()
+	keys := make([]openAPIRouteKey, 0, len(routers))
+	for _, r := range routers {
+		key := openAPIRouteKey{
+			method: r.Method,
+			route:  r.Route,
+		}
+		if route, disabled := e.lookupRoute(r.Method, r.Route); route != nil {
+			key.doc = route.doc
+			key.disabled = disabled
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// NewOpenAPIHandler returns a handler which serves the openapi document
+// of elton as json. The document is regenerated when the routes are changed
+// (e.g. added, removed, disabled or the route table is updated).
+func (e *Elton) NewOpenAPIHandler(info OpenAPIInfo) Handler {
+	var mu sync.Mutex
+	var keys []openAPIRouteKey
+	var buf []byte
+	return func(c *Context) error {
+		currentKeys := e.openAPIRouteKeys()
+		mu.Lock()
+		defer mu.Unlock()
+		if buf == nil || !slices.Equal(keys, currentKeys) {
+			data, err := json.Marshal(e.OpenAPI(info))
+			if err != nil {
+				return err
+			}
+			buf = data
+			keys = currentKeys
+		}
+		c.SetHeader(HeaderContentType, MIMEApplicationJSON)
+		c.BodyBuffer = bytes.NewBuffer(slices.Clone(buf))
+		return nil
+	}
+}
@@ -0,0 +1,190 @@
+// MIT License
+
+// Copyright (c) 2020 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package elton
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type openAPIBase struct {
+	ID        int       `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type openAPIUser struct {
+	openAPIBase
+	Account string            `json:"account"`
+	Name    string            `json:"name,omitempty"`
+	Avatar  []byte            `json:"avatar,omitempty"`
+	Roles   []string          `json:"roles,omitempty"`
+	Extra   map[string]any    `json:"extra,omitempty"`
+	Friends []*openAPIUser    `json:"friends,omitempty"`
+	Score   *float64          `json:"score"`
+	Labels  map[string]string `json:"-"`
+	secret  string
+}
+
+func TestToOpenAPIPath(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("/", toOpenAPIPath("/{$}"))
+	assert.Equal("/users/", toOpenAPIPath("/users/{$}"))
+	assert.Equal("/users/{id}", toOpenAPIPath("/users/{id}"))
+	assert.Equal("/files/{path}", toOpenAPIPath("/files/{path...}"))
+}
+
+func TestOpenAPI(t *testing.T) {
+	assert := assert.New(t)
+	e := New()
+	noop := func(c *Context) error {
+		return nil
+	}
+	e.GET("/", noop)
+	e.GET("/users/:id", noop).Describe(&RouteDoc{
+		OperationID: "getUser",
+		Summary:     "get user",
+		Tags:        []string{"user"},
+		Response:    &openAPIUser{},
+		Parameters: []OpenAPIParameter{
+			{
+				Name: "fields",
+				In:   "query",
+			},
+		},
+	})
+	e.Multi([]string{"POST", "PUT"}, "/users", noop).Describe(&RouteDoc{
+		Summary:        "save user",
+		Request:        openAPIUser{},
+		ResponseStatus: 201,
+	})
+	g := NewGroup("/files")
+	g.GET("/*", noop).Describe(&RouteDoc{
+		Summary:  "get file",
+		Response: []byte{},
+	})
+	e.AddGroup(g)
+	e.GET("/openapi.json", e.NewOpenAPIHandler(OpenAPIInfo{
+		Title:   "elton",
+		Version: "1.0.0",
+	}))
+
+	doc := e.OpenAPI(OpenAPIInfo{
+		Title:   "elton",
+		Version: "1.0.0",
+	})
+	assert.Equal(OpenAPIVersion, doc.OpenAPI)
+	assert.Equal(5, len(doc.Paths))
+	assert.Equal("OK", doc.Paths["/"]["get"].Responses["200"].Description)
+
+	op := doc.Paths["/users/{id}"]["get"]
+	assert.Equal("getUser", op.OperationID)
+	assert.Equal([]string{"user"}, op.Tags)
+	assert.Equal(2, len(op.Parameters))
+	assert.Equal("id", op.Parameters[0].Name)
+	assert.Equal("path", op.Parameters[0].In)
+	assert.True(op.Parameters[0].Required)
+	assert.Equal("fields", op.Parameters[1].Name)
+	assert.Equal("#/components/schemas/openAPIUser", op.Responses["200"].Content["application/json"].Schema.Ref)
+
+	for _, method := range []string{"post", "put"} {
+		op = doc.Paths["/users"][method]
+		assert.Equal("save user", op.Summary)
+		assert.Equal("#/components/schemas/openAPIUser", op.RequestBody.Content["application/json"].Schema.Ref)
+		assert.Equal("Created", op.Responses["201"].Description)
+	}
+
+	op = doc.Paths["/files/{path}"]["get"]
+	assert.Equal("get file", op.Summary)
+	assert.Equal("path", op.Parameters[0].Name)
+	assert.Equal("byte", op.Responses["200"].Content["application/json"].Schema.Format)
+
+	user := doc.Components.Schemas["openAPIUser"]
+	assert.Equal([]string{"id", "createdAt", "account"}, user.Required)
+	assert.Equal(9, len(user.Properties))
+	assert.Equal("date-time", user.Properties["createdAt"].Format)
+	assert.Equal("int64", user.Properties["id"].Format)
+	assert.Equal("byte", user.Properties["avatar"].Format)
+	assert.Equal("string", user.Properties["roles"].Items.Type)
+	assert.Equal("object", user.Properties["extra"].Type)
+	assert.Equal("#/components/schemas/openAPIUser", user.Properties["friends"].Items.Ref)
+	assert.Equal("double", user.Properties["score"].Format)
+
+	req := httptest.NewRequest("GET", "/openapi.json", nil)
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal(MIMEApplicationJSON, resp.Header().Get(HeaderContentType))
+	result := OpenAPIDocument{}
+	assert.Nil(json.Unmarshal(resp.Body.Bytes(), &result))
+	assert.Equal("elton", result.Info.Title)
+	assert.Equal(5, len(result.Paths))
+}
+
+func TestOpenAPIHandlerRefresh(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New()
+	noop := func(c *Context) error {
+		return nil
+	}
+	e.GET("/users", noop)
+	e.GET("/openapi.json", e.NewOpenAPIHandler(OpenAPIInfo{
+		Title:   "elton",
+		Version: "1.0.0",
+	}))
+	getPaths := func() []string {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/openapi.json", nil))
+		assert.Equal(200, resp.Code)
+		doc := OpenAPIDocument{}
+		assert.Nil(json.Unmarshal(resp.Body.Bytes(), &doc))
+		paths := make([]string, 0, len(doc.Paths))
+		for path := range doc.Paths {
+			paths = append(paths, path)
+		}
+		slices.Sort(paths)
+		return paths
+	}
+	assert.Equal([]string{"/openapi.json", "/users"}, getPaths())
+
+	// 更新路由表
+	err := e.UpdateRouteTable(func(t *RouteTable) error {
+		_, err := t.Handle("GET", "/books", noop)
+		return err
+	})
+	assert.Nil(err)
+	assert.Equal([]string{"/books", "/openapi.json", "/users"}, getPaths())
+
+	// 禁用路由
+	e.RouteTable().Route("GET", "/books").Disable()
+	assert.Equal([]string{"/openapi.json", "/users"}, getPaths())
+
+	// 删除路由
+	assert.Nil(e.RemoveRoute("GET", "/users"))
+	assert.Equal([]string{"/openapi.json"}, getPaths())
+}
@@ -117,6 +117,8 @@ type edgeWriter struct {
 	handled     bool
 	headerWrote bool
 	buf         []byte
+	// hostParamNames the param names of matched host pattern
+	hostParamNames []string
 }
 
 var edgeWriterPool = sync.Pool{
@@ -133,6 +135,7 @@ func acquireEdgeWriter(w http.ResponseWriter) *edgeWriter {
 	ew.handled = false
 	ew.headerWrote = false
 	ew.buf = ew.buf[:0]
+	ew.hostParamNames = nil
 	return ew
 }
 
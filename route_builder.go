@@ -0,0 +1,265 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package elton
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/vicanso/hes"
+)
+
+// ErrRouteNameNotFound the route of name is not found
+var ErrRouteNameNotFound = &hes.Error{
+	StatusCode: http.StatusInternalServerError,
+	Message:    "route name not found",
+	Category:   ErrCategory,
+}
+
+// Route the route added by Handle(or GET, POST, Multi...), it is used to set
+// the name, metadata and middlewares of route. The route should be set
+// before it becomes live, it is not safe for concurrent use
+// (except Disable and Enable).
+type Route struct {
+	e *Elton
+	// table the route table which the route is added to
+	table      *RouteTable
+	path       string
+	methods    []string
+	paramNames []string
+	name       string
+	meta       map[string]any
+	// globals the snapshot of global middlewares
+	globals []Handler
+	// middlewares the middlewares of route, they are called after globals
+	middlewares []Handler
+	handlerList []Handler
+	// handlers the handler chain: globals + middlewares + handlerList
+	handlers []Handler
+	// handler the http handler of route
+	handler  http.HandlerFunc
+	doc      *RouteDoc
+	disabled atomic.Bool
+	// mount the mount point of Mount/MountHandler
+	mount *mountPoint
+	// requestType and responseType the types of typed handler
+	requestType  reflect.Type
+	responseType reflect.Type
+}
+
+// clone returns a copy of route for the route table,
+// the http handler is recreated for the copied route.
+func (r *Route) clone(t *RouteTable) *Route {
+	nr := &Route{
+		e:            r.e,
+		table:        t,
+		path:         r.path,
+		methods:      slices.Clone(r.methods),
+		paramNames:   r.paramNames,
+		name:         r.name,
+		meta:         maps.Clone(r.meta),
+		globals:      r.globals,
+		middlewares:  slices.Clone(r.middlewares),
+		handlerList:  r.handlerList,
+		doc:          r.doc,
+		mount:        r.mount,
+		requestType:  r.requestType,
+		responseType: r.responseType,
+	}
+	nr.disabled.Store(r.disabled.Load())
+	nr.buildHandlers()
+	nr.handler = r.e.newRouteHandler(nr)
+	return nr
+}
+
+func (r *Route) buildHandlers() {
+	handlers := make([]Handler, 0, len(r.globals)+len(r.middlewares)+len(r.handlerList))
+	handlers = append(handlers, r.globals...)
+	handlers = append(handlers, r.middlewares...)
+	handlers = append(handlers, r.handlerList...)
+	r.handlers = handlers
+}
+
+// Path returns the ServeMux pattern path of route
+func (r *Route) Path() string {
+	return r.path
+}
+
+// Methods returns the http methods of route
+func (r *Route) Methods() []string {
+	return slices.Clone(r.methods)
+}
+
+// Name sets the name of route, it is used by URLFor.
+// It will throw a panic if the name is used by other path.
+func (r *Route) Name(name string) *Route {
+	routeNames := r.table.routeNames
+	// 相同path的路由可使用相同的名称（如group的Multi）
+	if exists, ok := routeNames[name]; ok && exists != r && exists.path != r.path {
+		panic(fmt.Errorf("route name %s is used by %s", name, exists.path))
+	}
+	if r.name != "" && routeNames[r.name] == r {
+		delete(routeNames, r.name)
+	}
+	r.name = name
+	routeNames[name] = r
+	return r
+}
+
+// Meta sets the metadata of route, it can be got by Context.RouteMeta
+// in middleware, e.g. the timeout or cache policy of route.
+func (r *Route) Meta(key string, value any) *Route {
+	if r.meta == nil {
+		r.meta = make(map[string]any)
+	}
+	r.meta[key] = value
+	return r
+}
+
+// Use adds the middlewares of route, they are called after the global middlewares
+// and before the handlers of route.
+func (r *Route) Use(handlerList ...Handler) *Route {
+	for _, fn := range handlerList {
+		r.e.ensureFunctionName(fn)
+	}
+	r.middlewares = append(r.middlewares, handlerList...)
+	r.buildHandlers()
+	return r
+}
+
+// Describe sets the document of route, the types of typed
+// route are filled if the request or response is not set.
+func (r *Route) Describe(doc *RouteDoc) *Route {
+	if r.requestType != nil {
+		doc = r.typedDoc(doc)
+	}
+	if doc != nil {
+		r.doc = doc
+	}
+	return r
+}
+
+// Disable disables the route, the request of route will be
+// handled as not found until it is enabled.
+func (r *Route) Disable() *Route {
+	r.disabled.Store(true)
+	return r
+}
+
+// Enable enables the route
+func (r *Route) Enable() *Route {
+	r.disabled.Store(false)
+	return r
+}
+
+// Disabled returns true if the route is disabled
+func (r *Route) Disabled() bool {
+	return r.disabled.Load()
+}
+
+// RouteName returns the name of matched route
+func (c *Context) RouteName() string {
+	if c.matchedRoute == nil {
+		return ""
+	}
+	return c.matchedRoute.name
+}
+
+// RouteMeta returns the metadata of matched route
+func (c *Context) RouteMeta(key string) (any, bool) {
+	if c.matchedRoute == nil {
+		return nil, false
+	}
+	value, ok := c.matchedRoute.meta[key]
+	return value, ok
+}
+
+// GetRouteMeta returns the metadata of matched route.
+// The zero value of T will be returned if the key does not exist
+// or the value doesn't match type T.
+func GetRouteMeta[T any](c *Context, key string) T {
+	var zero T
+	value, exists := c.RouteMeta(key)
+	if !exists || value == nil {
+		return zero
+	}
+	v, ok := value.(T)
+	if !ok {
+		return zero
+	}
+	return v
+}
+
+// URLFor returns the url path of named route, the wildcards of path
+// are replaced by the params, "{name...}" keeps the slash of value.
+func (e *Elton) URLFor(name string, params map[string]string) (string, error) {
+	route, ok := e.RouteTable().routeNames[name]
+	if !ok {
+		return "", ErrRouteNameNotFound.Clone().WithExtra("name", name)
+	}
+	path := route.path
+	var b strings.Builder
+	b.Grow(len(path))
+	for {
+		start := strings.IndexByte(path, '{')
+		if start < 0 {
+			b.WriteString(path)
+			break
+		}
+		end := strings.IndexByte(path[start:], '}')
+		if end < 0 {
+			b.WriteString(path)
+			break
+		}
+		end += start
+		b.WriteString(path[:start])
+		key := path[start+1 : end]
+		path = path[end+1:]
+		if key == "$" {
+			continue
+		}
+		key, catchAll := strings.CutSuffix(key, "...")
+		value, ok := params[key]
+		if !ok {
+			return "", hes.New(fmt.Sprintf("param %s of route %s is required", key, name),
+				hes.WithStatus(http.StatusInternalServerError),
+				hes.WithCategory(ErrCategory))
+		}
+		if !catchAll {
+			b.WriteString(url.PathEscape(value))
+			continue
+		}
+		segments := strings.Split(value, "/")
+		for i, segment := range segments {
+			segments[i] = url.PathEscape(segment)
+		}
+		b.WriteString(strings.Join(segments, "/"))
+	}
+	return b.String(), nil
+}
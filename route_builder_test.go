@@ -0,0 +1,184 @@
+// MIT License
+
+// Copyright (c) 2020 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package elton
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/hes"
+)
+
+func TestRoute(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New()
+	e.Use(func(c *Context) error {
+		// 全局中间件可获取路由的元数据
+		c.Set("timeout", GetRouteMeta[time.Duration](c, "timeout"))
+		c.Set("order", "global")
+		return c.Next()
+	})
+	route := e.GET("/users/{id}", func(c *Context) error {
+		assert.Equal("user", c.RouteName())
+		value, ok := c.RouteMeta("tags")
+		assert.True(ok)
+		assert.Equal([]string{"user"}, value)
+		_, ok = c.RouteMeta("not-exists")
+		assert.False(ok)
+		assert.Equal(time.Second, GetContextValue[time.Duration](c, "timeout"))
+		assert.Equal("global,route", GetContextValue[string](c, "order"))
+		c.BodyBuffer = nil
+		return nil
+	}).Name("user").
+		Meta("timeout", time.Second).
+		Meta("tags", []string{"user"}).
+		Use(func(c *Context) error {
+			c.Set("order", GetContextValue[string](c, "order")+",route")
+			return c.Next()
+		})
+	assert.Equal("/users/{id}", route.Path())
+	assert.Equal([]string{"GET"}, route.Methods())
+
+	e.GET("/", func(c *Context) error {
+		assert.Empty(c.RouteName())
+		assert.Equal(time.Duration(0), GetRouteMeta[time.Duration](c, "timeout"))
+		return nil
+	})
+
+	for _, url := range []string{"/users/1", "/"} {
+		req := httptest.NewRequest("GET", url, nil)
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(200, resp.Code)
+	}
+
+	c := NewContext(nil, nil)
+	assert.Empty(c.RouteName())
+	_, ok := c.RouteMeta("timeout")
+	assert.False(ok)
+}
+
+func TestRouteName(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New()
+	noop := func(c *Context) error {
+		return nil
+	}
+	route := e.Multi([]string{"GET", "POST"}, "/users", noop).Name("users")
+	assert.Equal([]string{"GET", "POST"}, route.Methods())
+	// 重命名
+	route.Name("userList")
+	_, err := e.URLFor("users", nil)
+	assert.Equal("route name not found", hes.Wrap(err).Message)
+
+	// 相同的path可使用相同的名称
+	e.PUT("/users", noop).Name("userList")
+	assert.Panics(func() {
+		e.GET("/books", noop).Name("userList")
+	})
+}
+
+func TestURLFor(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New()
+	noop := func(c *Context) error {
+		return nil
+	}
+	e.GET("/", noop).Name("home")
+	e.GET("/users/:id/books/{bookID}", noop).Name("book")
+	e.GET("/files/*", noop).Name("file")
+
+	g := NewGroup("/api")
+	g.GET("/users/{id}", noop).Name("apiUser").Meta("version", 1)
+	e.AddGroup(g)
+
+	tests := []struct {
+		name   string
+		params map[string]string
+		url    string
+		err    string
+	}{
+		{
+			name: "home",
+			url:  "/",
+		},
+		{
+			name: "book",
+			params: map[string]string{
+				"id":     "tree xie",
+				"bookID": "a/b",
+			},
+			url: "/users/tree%20xie/books/a%2Fb",
+		},
+		{
+			name: "file",
+			params: map[string]string{
+				"path": "a b/c.txt",
+			},
+			url: "/files/a%20b/c.txt",
+		},
+		{
+			name: "apiUser",
+			params: map[string]string{
+				"id": "1",
+			},
+			url: "/api/users/1",
+		},
+		{
+			name: "book",
+			params: map[string]string{
+				"id": "1",
+			},
+			err: "param bookID of route book is required",
+		},
+	}
+	for _, tt := range tests {
+		url, err := e.URLFor(tt.name, tt.params)
+		if tt.err != "" {
+			assert.Equal(tt.err, hes.Wrap(err).Message)
+			continue
+		}
+		assert.Nil(err)
+		assert.Equal(tt.url, url)
+	}
+
+	// group的路由元数据
+	done := false
+	e2 := New()
+	e2.Use(func(c *Context) error {
+		assert.Equal(1, GetRouteMeta[int](c, "version"))
+		assert.Equal("apiUser", c.RouteName())
+		done = true
+		return c.Next()
+	})
+	e2.AddGroup(g)
+	req := httptest.NewRequest("GET", "/api/users/1", nil)
+	resp := httptest.NewRecorder()
+	e2.ServeHTTP(resp, req)
+	assert.True(done)
+}
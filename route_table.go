@@ -0,0 +1,312 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package elton
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/vicanso/hes"
+)
+
+var (
+	// ErrRouteNotFound the route is not found in route table
+	ErrRouteNotFound = &hes.Error{
+		StatusCode: http.StatusInternalServerError,
+		Message:    "route not found",
+		Category:   ErrCategory,
+	}
+	// ErrRouteTableMismatch the route table is not created by the elton instance
+	ErrRouteTableMismatch = &hes.Error{
+		StatusCode: http.StatusInternalServerError,
+		Message:    "route table is not created by this elton",
+		Category:   ErrCategory,
+	}
+)
+
+type (
+	// RouteTable the route table of elton, the live route table can be
+	// replaced by SwapRouteTable or UpdateRouteTable without restarting
+	// the server, the in-flight requests are not affected.
+	// Unlike Elton.Handle, the invalid or conflicting route is returned as
+	// error instead of panic. The route table is not safe for concurrent use,
+	// build it before it becomes live.
+	RouteTable struct {
+		e *Elton
+		// mux is the standard library router (Go 1.22+ patterns / wildcards)
+		mux *http.ServeMux
+		// entries the registered method and route in order
+		entries []routeEntry
+		// lastRoute the route added by the last handle
+		lastRoute *Route
+		// routeNames the named routes, it is used by URLFor
+		routeNames map[string]*Route
+	}
+	routeEntry struct {
+		method string
+		route  *Route
+	}
+)
+
+func (entry routeEntry) pattern() string {
+	if entry.method == "" {
+		return entry.route.path
+	}
+	return entry.method + " " + entry.route.path
+}
+
+// NewRouteTable returns a new empty route table, the global
+// middlewares are snapshotted when the route is added.
+func (e *Elton) NewRouteTable() *RouteTable {
+	return &RouteTable{
+		e:          e,
+		mux:        http.NewServeMux(),
+		routeNames: make(map[string]*Route),
+	}
+}
+
+// RouteTable returns the live route table
+func (e *Elton) RouteTable() *RouteTable {
+	return e.table.Load()
+}
+
+// SwapRouteTable replaces the live route table and returns the old one.
+func (e *Elton) SwapRouteTable(t *RouteTable) (*RouteTable, error) {
+	if t == nil || t.e != e {
+		return nil, ErrRouteTableMismatch
+	}
+	e.tableMu.Lock()
+	defer e.tableMu.Unlock()
+	return e.table.Swap(t), nil
+}
+
+// UpdateRouteTable clones the live route table and calls fn to change it,
+// the new route table becomes live if fn returns nil, e.g.
+//
+//	err := e.UpdateRouteTable(func(t *elton.RouteTable) error {
+//		_, err := t.Handle("GET", "/plugins/{name}", handler)
+//		return err
+//	})
+func (e *Elton) UpdateRouteTable(fn func(t *RouteTable) error) error {
+	e.tableMu.Lock()
+	defer e.tableMu.Unlock()
+	t := e.table.Load().Clone()
+	if err := fn(t); err != nil {
+		return err
+	}
+	e.table.Store(t)
+	return nil
+}
+
+// RemoveRoute removes the route of method and path from the live route table
+func (e *Elton) RemoveRoute(method, path string) error {
+	return e.UpdateRouteTable(func(t *RouteTable) error {
+		return t.Remove(method, path)
+	})
+}
+
+// register registers the route to mux, the panic of mux is returned as error.
+func (t *RouteTable) register(entry routeEntry) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = hes.New(fmt.Sprint(r),
+				hes.WithStatus(http.StatusInternalServerError),
+				hes.WithCategory(ErrCategory))
+		}
+	}()
+	t.mux.Handle(entry.pattern(), entry.route.handler)
+	t.entries = append(t.entries, entry)
+	return nil
+}
+
+// rebuild creates a new mux and registers all entries,
+// the entries are valid so it will not fail.
+func (t *RouteTable) rebuild() {
+	entries := t.entries
+	t.mux = http.NewServeMux()
+	t.entries = make([]routeEntry, 0, len(entries))
+	for _, entry := range entries {
+		_ = t.register(entry)
+	}
+}
+
+// Handle adds http handle function to the route table,
+// the error will be returned if the route is invalid or conflicted.
+func (t *RouteTable) Handle(method, path string, handlerList ...Handler) (*Route, error) {
+	return t.Multi([]string{method}, path, handlerList...)
+}
+
+// Multi adds multi methods to the route table, the methods share the same route.
+// None of methods is added if any of them fails.
+func (t *RouteTable) Multi(methods []string, path string, handlerList ...Handler) (*Route, error) {
+	route := t.e.newRoute(path, handlerList)
+	route.table = t
+	count := len(t.entries)
+	for _, method := range methods {
+		err := t.register(routeEntry{
+			method: method,
+			route:  route,
+		})
+		if err != nil {
+			// 回滚已添加的method
+			if len(t.entries) != count {
+				t.entries = t.entries[:count]
+				t.rebuild()
+			}
+			return nil, err
+		}
+	}
+	route.methods = append(route.methods, methods...)
+	t.lastRoute = route
+	return route, nil
+}
+
+// AddGroup adds the group and its sub groups to the route table
+func (t *RouteTable) AddGroup(groups ...*Group) error {
+	for _, g := range groups {
+		for _, r := range g.routers {
+			route, err := t.Handle(r.Method, r.Path, r.HandleList...)
+			if err != nil {
+				return err
+			}
+			route.requestType = r.requestType
+			route.responseType = r.responseType
+			route.Describe(r.Doc)
+			if r.Name != "" {
+				route.Name(r.Name)
+			}
+			for k, v := range r.Meta {
+				route.Meta(k, v)
+			}
+		}
+		if err := t.AddGroup(g.children...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove removes the route of method and path, the path can use
+// the legacy syntax(e.g. :id) as Handle. The error will be returned
+// if the route is not found.
+func (t *RouteTable) Remove(method, path string) error {
+	path = normalizeRoutePath(path)
+	entries := make([]routeEntry, 0, len(t.entries))
+	var removed *Route
+	for _, entry := range t.entries {
+		if entry.method == method && entry.route.path == path {
+			removed = entry.route
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if removed == nil {
+		return ErrRouteNotFound.Clone().WithExtra("route", method+" "+path)
+	}
+	// 创建新的slice，避免修改Methods返回前的数据
+	removed.methods = slices.DeleteFunc(slices.Clone(removed.methods), func(item string) bool {
+		return item == method
+	})
+	t.entries = entries
+	t.rebuild()
+	// 删除已不存在的路由名称
+	for name, route := range t.routeNames {
+		if !t.contains(route) {
+			delete(t.routeNames, name)
+		}
+	}
+	if t.lastRoute != nil && !t.contains(t.lastRoute) {
+		t.lastRoute = nil
+	}
+	return nil
+}
+
+func (t *RouteTable) contains(route *Route) bool {
+	for _, entry := range t.entries {
+		if entry.route == route {
+			return true
+		}
+	}
+	return false
+}
+
+// find returns the route of method and path
+func (t *RouteTable) find(method, path string) *Route {
+	for _, entry := range t.entries {
+		if entry.method == method && entry.route.path == path {
+			return entry.route
+		}
+	}
+	return nil
+}
+
+// Route returns the route of method and path, nil will be returned if not found.
+func (t *RouteTable) Route(method, path string) *Route {
+	return t.find(method, normalizeRoutePath(path))
+}
+
+// Routers returns routers of route table,
+// The routers of mounted elton are merged with the mount prefix.
+func (t *RouteTable) Routers() []RouterInfo {
+	routers := make([]RouterInfo, 0, len(t.entries))
+	for _, entry := range t.entries {
+		if mp := entry.route.mount; mp != nil && mp.child != nil {
+			routers = append(routers, mp.routers()...)
+			continue
+		}
+		routers = append(routers, RouterInfo{
+			Method: entry.method,
+			Route:  entry.route.path,
+		})
+	}
+	return routers
+}
+
+// Clone returns a copy of route table, the routes are also copied,
+// so the changes of cloned table(e.g. Name, Use or Disable of route)
+// do not affect the original one.
+func (t *RouteTable) Clone() *RouteTable {
+	nt := t.e.NewRouteTable()
+	routes := make(map[*Route]*Route, len(t.entries))
+	nt.entries = make([]routeEntry, 0, len(t.entries))
+	for _, entry := range t.entries {
+		route, ok := routes[entry.route]
+		if !ok {
+			route = entry.route.clone(nt)
+			routes[entry.route] = route
+		}
+		nt.entries = append(nt.entries, routeEntry{
+			method: entry.method,
+			route:  route,
+		})
+	}
+	nt.rebuild()
+	nt.lastRoute = routes[t.lastRoute]
+	for name, route := range t.routeNames {
+		if r, ok := routes[route]; ok {
+			nt.routeNames[name] = r
+		}
+	}
+	return nt
+}
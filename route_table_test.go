@@ -0,0 +1,286 @@
+// MIT License
+
+// Copyright (c) 2020 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package elton
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/hes"
+)
+
+func doRouteTableRequest(e *Elton, method, url string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, nil)
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestRouteTableError(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New()
+	noop := func(c *Context) error {
+		return nil
+	}
+	table := e.NewRouteTable()
+	_, err := table.Handle("GET", "/users/{id}", noop)
+	assert.Nil(err)
+
+	// 冲突的路由返回error
+	_, err = table.Handle("GET", "/users/{name}", noop)
+	assert.NotNil(err)
+	assert.Equal(ErrCategory, hes.Wrap(err).Category)
+
+	// 非法的路由返回error
+	_, err = table.Handle("GET", "/books/{id:[0-9]+}", noop)
+	assert.NotNil(err)
+
+	// multi中任一失败则全部不添加
+	_, err = table.Multi([]string{"POST", "GET"}, "/users/{id}", noop)
+	assert.NotNil(err)
+	assert.Equal([]RouterInfo{
+		{
+			Method: "GET",
+			Route:  "/users/{id}",
+		},
+	}, table.Routers())
+
+	_, err = e.SwapRouteTable(nil)
+	assert.Equal(ErrRouteTableMismatch, err)
+	_, err = e.SwapRouteTable(New().NewRouteTable())
+	assert.Equal(ErrRouteTableMismatch, err)
+}
+
+func TestSwapRouteTable(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New()
+	e.GET("/v1", func(c *Context) error {
+		c.BodyBuffer = nil
+		return nil
+	}).Name("version")
+
+	table := e.NewRouteTable()
+	route, err := table.Handle("GET", "/v2", func(c *Context) error {
+		return nil
+	})
+	assert.Nil(err)
+	route.Name("version")
+	assert.Equal(route, table.Route("GET", "/v2"))
+	assert.Nil(table.Route("GET", "/v1"))
+
+	assert.Equal(200, doRouteTableRequest(e, "GET", "/v1").Code)
+	assert.Equal(404, doRouteTableRequest(e, "GET", "/v2").Code)
+
+	old, err := e.SwapRouteTable(table)
+	assert.Nil(err)
+	assert.Equal(table, e.RouteTable())
+	assert.Equal([]RouterInfo{{Method: "GET", Route: "/v1"}}, old.Routers())
+	assert.Equal([]RouterInfo{{Method: "GET", Route: "/v2"}}, e.Routers())
+	assert.Equal(404, doRouteTableRequest(e, "GET", "/v1").Code)
+	assert.Equal(200, doRouteTableRequest(e, "GET", "/v2").Code)
+	url, err := e.URLFor("version", nil)
+	assert.Nil(err)
+	assert.Equal("/v2", url)
+}
+
+func TestSwapRouteTableInFlight(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New()
+	started := make(chan struct{})
+	done := make(chan struct{})
+	e.GET("/slow", func(c *Context) error {
+		close(started)
+		<-done
+		c.BodyBuffer = nil
+		c.Body = "slow"
+		return nil
+	})
+	result := make(chan int)
+	go func() {
+		result <- doRouteTableRequest(e, "GET", "/slow").Code
+	}()
+	<-started
+	_, err := e.SwapRouteTable(e.NewRouteTable())
+	assert.Nil(err)
+	// 新请求使用新的路由表
+	assert.Equal(404, doRouteTableRequest(e, "GET", "/slow").Code)
+	close(done)
+	// 处理中的请求不受影响
+	assert.Equal(200, <-result)
+}
+
+func TestUpdateRouteTable(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New()
+	noop := func(c *Context) error {
+		return nil
+	}
+	e.GET("/users/:id", noop).Name("user")
+	e.Multi([]string{"GET", "POST"}, "/books", noop).Name("books")
+
+	err := e.UpdateRouteTable(func(t *RouteTable) error {
+		_, err := t.Handle("GET", "/plugins/{name}", noop)
+		return err
+	})
+	assert.Nil(err)
+	assert.Equal(200, doRouteTableRequest(e, "GET", "/plugins/a").Code)
+	assert.Equal(3+1, len(e.Routers()))
+
+	// 失败时不替换路由表
+	current := e.RouteTable()
+	err = e.UpdateRouteTable(func(t *RouteTable) error {
+		_, err := t.Handle("GET", "/plugins/{id}", noop)
+		return err
+	})
+	assert.NotNil(err)
+	assert.Equal(current, e.RouteTable())
+
+	// 删除路由
+	assert.Nil(e.RemoveRoute("GET", "/users/:id"))
+	assert.Equal(404, doRouteTableRequest(e, "GET", "/users/1").Code)
+	_, err = e.URLFor("user", map[string]string{"id": "1"})
+	assert.Equal(ErrRouteNameNotFound.Message, hes.Wrap(err).Message)
+	err = e.RemoveRoute("GET", "/users/:id")
+	assert.Equal(ErrRouteNotFound.Message, hes.Wrap(err).Message)
+
+	// multi的路由删除其中一个method
+	assert.Nil(e.RemoveRoute("POST", "/books"))
+	assert.Equal(405, doRouteTableRequest(e, "POST", "/books").Code)
+	assert.Equal(200, doRouteTableRequest(e, "GET", "/books").Code)
+	url, err := e.URLFor("books", nil)
+	assert.Nil(err)
+	assert.Equal("/books", url)
+}
+
+func TestUpdateRouteTableIsolation(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New()
+	count := 0
+	e.GET("/users", func(c *Context) error {
+		c.BodyBuffer = bytes.NewBufferString("users")
+		return nil
+	})
+	e.Multi([]string{"GET", "POST"}, "/books", func(c *Context) error {
+		return nil
+	})
+	live := e.RouteTable()
+
+	err := e.UpdateRouteTable(func(t *RouteTable) error {
+		route := t.Route("GET", "/users")
+		// 修改复制的路由表不影响当前路由表
+		route.Name("users").Use(func(c *Context) error {
+			count++
+			return c.Next()
+		})
+		t.Route("GET", "/books").Disable()
+		assert.Nil(live.routeNames["users"])
+		assert.Equal(200, doRouteTableRequest(e, "GET", "/users").Code)
+		assert.Equal(200, doRouteTableRequest(e, "GET", "/books").Code)
+		assert.Equal(0, count)
+		return nil
+	})
+	assert.Nil(err)
+
+	// 替换后生效
+	url, err := e.URLFor("users", nil)
+	assert.Nil(err)
+	assert.Equal("/users", url)
+	assert.Equal("users", doRouteTableRequest(e, "GET", "/users").Body.String())
+	assert.Equal(1, count)
+	assert.Equal(404, doRouteTableRequest(e, "GET", "/books").Code)
+	assert.False(live.Route("GET", "/books").Disabled())
+
+	// 删除multi路由的method
+	assert.Nil(e.RemoveRoute("POST", "/books"))
+	assert.Equal([]string{"GET"}, e.RouteTable().Route("GET", "/books").Methods())
+	assert.Equal([]string{"GET", "POST"}, live.Route("GET", "/books").Methods())
+}
+
+func TestUpdateRouteTableRace(t *testing.T) {
+	e := New()
+	e.GET("/", func(c *Context) error {
+		return nil
+	})
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	var started sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		started.Add(1)
+		go func() {
+			defer wg.Done()
+			doRouteTableRequest(e, "GET", "/")
+			started.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					doRouteTableRequest(e, "GET", "/")
+				}
+			}
+		}()
+	}
+	// 请求处理中更新路由表
+	started.Wait()
+	for i := 0; i < 200; i++ {
+		_ = e.UpdateRouteTable(func(t *RouteTable) error {
+			route := t.Route("GET", "/")
+			route.Use(func(c *Context) error {
+				return c.Next()
+			})
+			route.Name("home").Meta("index", i)
+			if i%2 == 0 {
+				route.Disable()
+			} else {
+				route.Enable()
+			}
+			return nil
+		})
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestRouteDisable(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New()
+	route := e.GET("/", func(c *Context) error {
+		return nil
+	})
+	assert.False(route.Disabled())
+	route.Disable()
+	assert.True(route.Disabled())
+	assert.Equal(404, doRouteTableRequest(e, "GET", "/").Code)
+	route.Enable()
+	assert.Equal(200, doRouteTableRequest(e, "GET", "/").Code)
+}
@@ -0,0 +1,212 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package elton
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSSEClosed the sse writer is closed
+var ErrSSEClosed = errors.New("sse writer is closed")
+
+// SSEWriter server-sent events writer, it writes the events
+// to response directly and flushes after each event.
+// It is closed when the client is gone or the handler returns.
+type SSEWriter struct {
+	resp   http.ResponseWriter
+	rc     *http.ResponseController
+	ctx    context.Context
+	mu     sync.Mutex
+	closed bool
+	done   chan struct{}
+	buf    bytes.Buffer
+}
+
+// SSE sets the response headers for server-sent events(text/event-stream),
+// writes the status code and returns the sse writer.
+// The context is committed, so the Body and BodyBuffer will be ignored.
+// Calling SSE more than once returns the same writer.
+func (c *Context) SSE() *SSEWriter {
+	if c.sse != nil {
+		return c.sse
+	}
+	h := c.Header()
+	h.Set(HeaderContentType, MIMETextEventStream)
+	h.Set(HeaderCacheControl, "no-cache")
+	// 避免nginx等反向代理缓存
+	h.Set("X-Accel-Buffering", "no")
+	h.Del(HeaderContentLength)
+	c.Committed = true
+
+	w := &SSEWriter{
+		resp: c.Response,
+		rc:   http.NewResponseController(c.Response),
+		ctx:  c.Context(),
+		done: make(chan struct{}),
+	}
+	c.sse = w
+	status := c.StatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	c.Response.WriteHeader(status)
+	_ = w.flush()
+	go func() {
+		select {
+		case <-w.ctx.Done():
+			w.Close()
+		case <-w.done:
+		}
+	}()
+	return w
+}
+
+func (w *SSEWriter) flush() error {
+	err := w.rc.Flush()
+	if errors.Is(err, http.ErrNotSupported) {
+		return nil
+	}
+	return err
+}
+
+// write writes the buffer of event and flushes it
+func (w *SSEWriter) write(fn func(buf *bytes.Buffer)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrSSEClosed
+	}
+	w.buf.Reset()
+	fn(&w.buf)
+	if _, err := w.resp.Write(w.buf.Bytes()); err != nil {
+		return err
+	}
+	return w.flush()
+}
+
+// Send sends an event, the event and id are omitted if empty.
+// The data is written directly if it is string or []byte,
+// otherwise it is marshaled as json. Multi-line data is split
+// into multiple data fields.
+func (w *SSEWriter) Send(event, id string, data any) error {
+	var value string
+	switch data := data.(type) {
+	case string:
+		value = data
+	case []byte:
+		value = string(data)
+	default:
+		buf, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		value = string(buf)
+	}
+	return w.write(func(buf *bytes.Buffer) {
+		if event != "" {
+			buf.WriteString("event: ")
+			buf.WriteString(removeNewline(event))
+			buf.WriteByte('\n')
+		}
+		if id != "" {
+			buf.WriteString("id: ")
+			buf.WriteString(removeNewline(id))
+			buf.WriteByte('\n')
+		}
+		value = strings.ReplaceAll(value, "\r\n", "\n")
+		for _, line := range strings.Split(value, "\n") {
+			buf.WriteString("data: ")
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+		}
+		buf.WriteByte('\n')
+	})
+}
+
+// Retry sends the reconnection time hint to client
+func (w *SSEWriter) Retry(d time.Duration) error {
+	return w.write(func(buf *bytes.Buffer) {
+		buf.WriteString("retry: ")
+		buf.WriteString(strconv.FormatInt(d.Milliseconds(), 10))
+		buf.WriteString("\n\n")
+	})
+}
+
+// Comment sends a comment, it is ignored by client
+// and is usually used to keep the connection alive.
+func (w *SSEWriter) Comment(text string) error {
+	return w.write(func(buf *bytes.Buffer) {
+		buf.WriteString(": ")
+		buf.WriteString(removeNewline(text))
+		buf.WriteString("\n\n")
+	})
+}
+
+// KeepAlive sends the keep-alive comment periodically
+// until the writer is closed.
+func (w *SSEWriter) KeepAlive(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.done:
+				return
+			case <-ticker.C:
+				if w.Comment("keep-alive") != nil {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Done returns a channel that's closed when the writer is closed,
+// e.g. the client is gone.
+func (w *SSEWriter) Done() <-chan struct{} {
+	return w.done
+}
+
+// Close closes the writer, it is called automatically
+// when the client is gone or the handler returns.
+func (w *SSEWriter) Close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	w.closed = true
+	close(w.done)
+}
+
+func removeNewline(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
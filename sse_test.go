@@ -0,0 +1,114 @@
+// MIT License
+
+// Copyright (c) 2020 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package elton
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSSE(t *testing.T) {
+	assert := assert.New(t)
+	e := New()
+	e.GET("/events", func(c *Context) error {
+		sse := c.SSE()
+		assert.Equal(sse, c.SSE())
+		_ = sse.Retry(3 * time.Second)
+		_ = sse.Send("message", "1", "hello\nworld")
+		_ = sse.Send("", "", map[string]int{
+			"count": 1,
+		})
+		_ = sse.Comment("ping")
+		// ignored as the context is committed
+		c.Body = "abc"
+		return nil
+	})
+	req := httptest.NewRequest("GET", "/events", nil)
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.True(resp.Flushed)
+	assert.Equal(MIMETextEventStream, resp.Header().Get(HeaderContentType))
+	assert.Equal("no-cache", resp.Header().Get(HeaderCacheControl))
+	assert.Equal("retry: 3000\n\nevent: message\nid: 1\ndata: hello\ndata: world\n\ndata: {\"count\":1}\n\n: ping\n\n", resp.Body.String())
+}
+
+func TestSSEClose(t *testing.T) {
+	assert := assert.New(t)
+	closed := make(chan error, 1)
+	e := New()
+	e.GET("/events", func(c *Context) error {
+		sse := c.SSE()
+		sse.KeepAlive(10 * time.Millisecond)
+		_ = sse.Send("message", "", "hello")
+		<-sse.Done()
+		closed <- sse.Send("message", "", "hello")
+		return nil
+	})
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(err)
+	reader := bufio.NewReader(resp.Body)
+	lines := make([]string, 0)
+	for len(lines) < 4 {
+		line, err := reader.ReadString('\n')
+		assert.Nil(err)
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	assert.Equal([]string{"event: message", "data: hello", "", ": keep-alive"}, lines)
+	// client is gone
+	cancel()
+	_ = resp.Body.Close()
+	select {
+	case err := <-closed:
+		assert.Equal(ErrSSEClosed, err)
+	case <-time.After(3 * time.Second):
+		assert.Fail("sse is not closed")
+	}
+}
+
+func TestSSECloseAfterHandler(t *testing.T) {
+	assert := assert.New(t)
+	var sse *SSEWriter
+	e := New()
+	e.GET("/events", func(c *Context) error {
+		sse = c.SSE()
+		return nil
+	})
+	req := httptest.NewRequest("GET", "/events", nil)
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	<-sse.Done()
+	assert.Equal(ErrSSEClosed, sse.Comment("abc"))
+}
@@ -26,6 +26,7 @@ import (
 	"context"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -42,6 +43,17 @@ type (
 	Trace struct {
 		calculateDone bool
 		Infos         TraceInfos
+
+		// 以下为分布式追踪相关，仅在设置了Elton.Tracer时有效
+		mu sync.Mutex
+		// tracer the tracer of request
+		tracer *Tracer
+		// recording whether the spans are recorded(sampled)
+		recording bool
+		// current the current span, middleware span is the parent of next one
+		current *Span
+		// spans all the recorded spans, the first one is root span
+		spans []*Span
 	}
 )
 
@@ -54,15 +66,81 @@ func NewTrace() *Trace {
 
 // Start starts a sub trace and return done function
 // for sub trace.
+// If distributed tracing is enabled, a child span of current span
+// is created as well.
 func (t *Trace) Start(name string) func() {
 	startedAt := time.Now()
 	info := &TraceInfo{
 		Name: name,
 	}
 	t.Add(info)
+	span := t.newSpan(name, startedAt)
 	return func() {
 		info.Duration = time.Since(startedAt)
+		if span != nil {
+			t.mu.Lock()
+			span.EndTime = startedAt.Add(info.Duration)
+			t.mu.Unlock()
+		}
+	}
+}
+
+// CurrentSpan returns the current span of trace,
+// it returns nil if distributed tracing is not enabled.
+func (t *Trace) CurrentSpan() *Span {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.current
+}
+
+// newSpan creates a child span of current span,
+// it returns nil if the trace is not recording.
+func (t *Trace) newSpan(name string, startedAt time.Time) *Span {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.recording || t.current == nil {
+		return nil
+	}
+	parent := t.current
+	sc := parent.SpanContext
+	sc.SpanID = newSpanID()
+	sc.Remote = false
+	span := &Span{
+		Name:         name,
+		Kind:         SpanKindInternal,
+		SpanContext:  sc,
+		ParentSpanID: parent.SpanContext.SpanID,
+		StartTime:    startedAt,
+		parent:       parent,
+	}
+	t.spans = append(t.spans, span)
+	return span
+}
+
+// startMiddlewareSpan creates the span of middleware and sets it as current span,
+// so the span of next middleware is its child.
+func (t *Trace) startMiddlewareSpan(name string, startedAt time.Time) *Span {
+	span := t.newSpan(name, startedAt)
+	if span != nil {
+		t.mu.Lock()
+		t.current = span
+		t.mu.Unlock()
+	}
+	return span
+}
+
+// endMiddlewareSpan ends the span of middleware and restores the current span
+func (t *Trace) endMiddlewareSpan(span *Span, d time.Duration, err error) {
+	if span == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span.EndTime = span.StartTime.Add(d)
+	if err != nil {
+		span.SetError(err)
 	}
+	t.current = span.parent
 }
 
 // Add adds trace info to trace
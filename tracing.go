@@ -0,0 +1,454 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package elton
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vicanso/hes"
+)
+
+const (
+	// HeaderTraceparent w3c trace context traceparent
+	HeaderTraceparent = "Traceparent"
+	// HeaderTracestate w3c trace context tracestate
+	HeaderTracestate = "Tracestate"
+
+	// TraceFlagsSampled the sampled flag of trace flags
+	TraceFlagsSampled byte = 0x01
+
+	// maxTracestateMembers the max list members of tracestate
+	maxTracestateMembers = 32
+)
+
+// SpanKind the kind of span
+type SpanKind int
+
+const (
+	// SpanKindInternal internal operation, e.g. middleware or sub trace
+	SpanKindInternal SpanKind = 1
+	// SpanKindServer the handling of http request
+	SpanKindServer SpanKind = 2
+	// SpanKindClient the outgoing request
+	SpanKindClient SpanKind = 3
+)
+
+// SpanStatus the status of span
+type SpanStatus int
+
+const (
+	// SpanStatusUnset the default status
+	SpanStatusUnset SpanStatus = 0
+	// SpanStatusOK the operation completed successfully
+	SpanStatusOK SpanStatus = 1
+	// SpanStatusError the operation contains an error
+	SpanStatusError SpanStatus = 2
+)
+
+var (
+	// ErrInvalidTraceparent the traceparent is invalid
+	ErrInvalidTraceparent = errors.New("invalid traceparent")
+	// ErrTracerRequireExporter the exporter of tracer is nil
+	ErrTracerRequireExporter = errors.New("exporter of tracer is required")
+)
+
+type (
+	// TraceID the 16 bytes trace id
+	TraceID [16]byte
+	// SpanID the 8 bytes span id
+	SpanID [8]byte
+	// SpanContext the identity of span, it is propagated by
+	// traceparent and tracestate header.
+	SpanContext struct {
+		TraceID    TraceID
+		SpanID     SpanID
+		TraceFlags byte
+		TraceState string
+		// Remote whether the span context is propagated from remote
+		Remote bool
+	}
+	// Span a unit of work in a trace. The span is not safe for concurrent use,
+	// it should only be modified by the goroutine which starts it.
+	Span struct {
+		Name          string
+		Kind          SpanKind
+		SpanContext   SpanContext
+		ParentSpanID  SpanID
+		StartTime     time.Time
+		EndTime       time.Time
+		Attributes    map[string]any
+		Status        SpanStatus
+		StatusMessage string
+		// parent the parent span in the same process
+		parent *Span
+	}
+	// SpanExporter exports the finished spans to tracing backend
+	SpanExporter interface {
+		// ExportSpans exports the spans of a request, it is called
+		// after the request is done, so it should not block too long.
+		ExportSpans(ctx context.Context, spans []*Span) error
+		// Shutdown flushes the pending spans and releases the resources
+		Shutdown(ctx context.Context) error
+	}
+	// TracerConfig tracer config
+	TracerConfig struct {
+		// Exporter the span exporter, it is required
+		Exporter SpanExporter
+		// Sampler decides whether the request without remote parent is sampled,
+		// all requests are sampled if it is nil. The request with remote parent
+		// follows the sampled flag of parent.
+		Sampler func(c *Context) bool
+		// OnError is called when export spans fails
+		OnError func(err error)
+	}
+	// Tracer creates the spans of request and exports them,
+	// set it to Elton.Tracer to enable distributed tracing.
+	Tracer struct {
+		exporter SpanExporter
+		sampler  func(c *Context) bool
+		onError  func(err error)
+	}
+	// InMemoryExporter stores the spans in memory, it is useful for test
+	InMemoryExporter struct {
+		mu    sync.Mutex
+		spans []*Span
+	}
+)
+
+// String returns the hex string of trace id
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid returns true if the trace id is not all zero
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// String returns the hex string of span id
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid returns true if the span id is not all zero
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		binary.BigEndian.PutUint64(id[:8], rand.Uint64())
+		binary.BigEndian.PutUint64(id[8:], rand.Uint64())
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		binary.BigEndian.PutUint64(id[:], rand.Uint64())
+	}
+	return id
+}
+
+// IsValid returns true if both trace id and span id are valid
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// IsSampled returns true if the sampled flag is set
+func (sc SpanContext) IsSampled() bool {
+	return sc.TraceFlags&TraceFlagsSampled != 0
+}
+
+// Traceparent returns the traceparent header value(version 00)
+func (sc SpanContext) Traceparent() string {
+	var b strings.Builder
+	b.Grow(55)
+	b.WriteString("00-")
+	b.WriteString(sc.TraceID.String())
+	b.WriteByte('-')
+	b.WriteString(sc.SpanID.String())
+	b.WriteByte('-')
+	b.WriteString(hex.EncodeToString([]byte{sc.TraceFlags}))
+	return b.String()
+}
+
+func decodeLowerHex(dst []byte, s string) bool {
+	// 规范要求只能为小写
+	if strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+// ParseTraceparent parses the traceparent and tracestate header value,
+// the returned span context is marked as remote.
+// The tracestate is ignored if the traceparent is invalid.
+func ParseTraceparent(traceparent, tracestate string) (SpanContext, error) {
+	sc := SpanContext{}
+	traceparent = strings.TrimSpace(traceparent)
+	// version-traceid-parentid-flags
+	if len(traceparent) < 55 {
+		return sc, ErrInvalidTraceparent
+	}
+	version := traceparent[0:2]
+	var buf [1]byte
+	if !decodeLowerHex(buf[:], version) || version == "ff" {
+		return sc, ErrInvalidTraceparent
+	}
+	// version 00 must be exactly 55 bytes, the future versions may have more fields
+	if len(traceparent) > 55 && (version == "00" || traceparent[55] != '-') {
+		return sc, ErrInvalidTraceparent
+	}
+	if traceparent[2] != '-' || traceparent[35] != '-' || traceparent[52] != '-' {
+		return sc, ErrInvalidTraceparent
+	}
+	if !decodeLowerHex(sc.TraceID[:], traceparent[3:35]) ||
+		!decodeLowerHex(sc.SpanID[:], traceparent[36:52]) ||
+		!decodeLowerHex(buf[:], traceparent[53:55]) {
+		return sc, ErrInvalidTraceparent
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	sc.TraceFlags = buf[0]
+	sc.TraceState = normalizeTracestate(tracestate)
+	sc.Remote = true
+	return sc, nil
+}
+
+// normalizeTracestate removes the empty and invalid list members,
+// and keeps at most 32 members.
+func normalizeTracestate(tracestate string) string {
+	if tracestate == "" {
+		return ""
+	}
+	members := make([]string, 0, 4)
+	for _, member := range strings.Split(tracestate, ",") {
+		member = strings.TrimSpace(member)
+		key, value, ok := strings.Cut(member, "=")
+		if !ok || key == "" || value == "" {
+			continue
+		}
+		members = append(members, member)
+		if len(members) >= maxTracestateMembers {
+			break
+		}
+	}
+	return strings.Join(members, ",")
+}
+
+// SetAttribute sets the attribute of span
+func (s *Span) SetAttribute(key string, value any) *Span {
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]any)
+	}
+	s.Attributes[key] = value
+	return s
+}
+
+// SetError sets the status of span to error
+func (s *Span) SetError(err error) *Span {
+	s.Status = SpanStatusError
+	s.StatusMessage = err.Error()
+	return s
+}
+
+// Duration returns the duration of span, it is zero if the span is not ended
+func (s *Span) Duration() time.Duration {
+	if s.EndTime.IsZero() {
+		return 0
+	}
+	return s.EndTime.Sub(s.StartTime)
+}
+
+// InjectTraceContext sets the traceparent and tracestate header
+// of the current span in context, it is used to propagate the trace
+// to downstream service. Nothing is set if the context has no span.
+func InjectTraceContext(ctx context.Context, header http.Header) {
+	span := SpanFromContext(ctx)
+	if span == nil {
+		return
+	}
+	header.Set(HeaderTraceparent, span.SpanContext.Traceparent())
+	if span.SpanContext.TraceState != "" {
+		header.Set(HeaderTracestate, span.SpanContext.TraceState)
+	} else {
+		header.Del(HeaderTracestate)
+	}
+}
+
+// SpanFromContext returns the current span of trace in context,
+// it returns nil if tracing is not enabled.
+func SpanFromContext(ctx context.Context) *Span {
+	trace, ok := ctx.Value(ContextTraceKey).(*Trace)
+	if !ok {
+		return nil
+	}
+	return trace.CurrentSpan()
+}
+
+// NewTracer returns a new tracer, it will throw a panic if the exporter is nil.
+func NewTracer(config TracerConfig) *Tracer {
+	if config.Exporter == nil {
+		panic(ErrTracerRequireExporter)
+	}
+	return &Tracer{
+		exporter: config.Exporter,
+		sampler:  config.Sampler,
+		onError:  config.OnError,
+	}
+}
+
+// Shutdown shutdowns the exporter of tracer
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	return t.exporter.Shutdown(ctx)
+}
+
+// startRequest creates the root span of request, the trace id and
+// parent span id are got from traceparent if it is valid.
+func (t *Tracer) startRequest(c *Context, trace *Trace) {
+	req := c.Request
+	root := &Span{
+		Name:      req.Method + " " + c.Route,
+		Kind:      SpanKindServer,
+		StartTime: time.Now(),
+		Attributes: map[string]any{
+			"http.request.method": req.Method,
+			"http.route":          c.Route,
+			"url.path":            req.URL.Path,
+		},
+	}
+	sampled := true
+	parent, err := ParseTraceparent(req.Header.Get(HeaderTraceparent), req.Header.Get(HeaderTracestate))
+	if err == nil {
+		root.SpanContext.TraceID = parent.TraceID
+		root.SpanContext.TraceFlags = parent.TraceFlags
+		root.SpanContext.TraceState = parent.TraceState
+		root.ParentSpanID = parent.SpanID
+		sampled = parent.IsSampled()
+	} else {
+		root.SpanContext.TraceID = newTraceID()
+		if t.sampler != nil {
+			sampled = t.sampler(c)
+		}
+		if sampled {
+			root.SpanContext.TraceFlags = TraceFlagsSampled
+		}
+	}
+	root.SpanContext.SpanID = newSpanID()
+	trace.tracer = t
+	trace.recording = sampled
+	trace.current = root
+	if sampled {
+		trace.spans = append(trace.spans, root)
+	}
+}
+
+// endRequest ends the root span and exports the spans of request
+func (t *Tracer) endRequest(c *Context, trace *Trace, err error) {
+	trace.mu.Lock()
+	// 只导出已结束的span
+	spans := make([]*Span, 0, len(trace.spans))
+	var root *Span
+	if len(trace.spans) != 0 {
+		root = trace.spans[0]
+	}
+	for _, span := range trace.spans {
+		if span == root || !span.EndTime.IsZero() {
+			spans = append(spans, span)
+		}
+	}
+	trace.recording = false
+	trace.mu.Unlock()
+	if root == nil {
+		return
+	}
+	status := c.StatusCode
+	if err != nil {
+		status = http.StatusInternalServerError
+		he := &hes.Error{}
+		if errors.As(err, &he) {
+			status = he.StatusCode
+		}
+		root.SetError(err)
+	} else if status == 0 {
+		status = http.StatusOK
+	}
+	root.SetAttribute("http.response.status_code", status)
+	if status >= http.StatusInternalServerError && root.Status == SpanStatusUnset {
+		root.Status = SpanStatusError
+		root.StatusMessage = strconv.Itoa(status)
+	}
+	root.EndTime = time.Now()
+
+	exportErr := t.exporter.ExportSpans(context.Background(), spans)
+	if exportErr != nil && t.onError != nil {
+		t.onError(exportErr)
+	}
+}
+
+// NewInMemoryExporter returns a new in-memory exporter
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+// ExportSpans stores the spans
+func (e *InMemoryExporter) ExportSpans(_ context.Context, spans []*Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+// Shutdown does nothing
+func (e *InMemoryExporter) Shutdown(_ context.Context) error {
+	return nil
+}
+
+// Spans returns the exported spans
+func (e *InMemoryExporter) Spans() []*Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.spans)
+}
+
+// Reset removes all the exported spans
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
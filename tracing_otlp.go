@@ -0,0 +1,349 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package elton
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultOTLPEndpoint the default endpoint of otlp/http traces
+	DefaultOTLPEndpoint = "http://localhost:4318/v1/traces"
+	otlpScopeName       = "github.com/vicanso/elton"
+)
+
+// ErrOTLPQueueFull the queue of otlp exporter is full, the spans are dropped
+var ErrOTLPQueueFull = errors.New("otlp exporter queue is full")
+
+type (
+	// OTLPHTTPExporterConfig otlp/http json exporter config
+	OTLPHTTPExporterConfig struct {
+		// Endpoint the url of traces, default is DefaultOTLPEndpoint
+		Endpoint string
+		// Headers the extra headers of request, e.g. authorization
+		Headers map[string]string
+		// ServiceName the service.name of resource
+		ServiceName string
+		// ResourceAttributes the other attributes of resource
+		ResourceAttributes map[string]any
+		// Client the http client, default timeout is 10s
+		Client *http.Client
+		// BatchSize the max spans of one request, default is 512
+		BatchSize int
+		// MaxQueueSize the max pending spans, the new spans are dropped
+		// if the queue is full, default is 4 * BatchSize
+		MaxQueueSize int
+		// FlushInterval the interval of flush, default is 5s
+		FlushInterval time.Duration
+		// OnError is called when the spans are failed to send in background
+		OnError func(err error)
+	}
+	// OTLPHTTPExporter exports spans as otlp/http json, the spans
+	// are buffered and sent in background by batch.
+	OTLPHTTPExporter struct {
+		config   OTLPHTTPExporterConfig
+		resource otlpResource
+
+		mu    sync.Mutex
+		queue []*Span
+
+		flushCh   chan struct{}
+		stopCh    chan struct{}
+		stoppedCh chan struct{}
+		stopOnce  sync.Once
+	}
+
+	otlpTraceRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes,omitempty"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		TraceState        string         `json:"traceState,omitempty"`
+		Flags             uint32         `json:"flags,omitempty"`
+		Name              string         `json:"name"`
+		Kind              int            `json:"kind"`
+		StartTimeUnixNano uint64         `json:"startTimeUnixNano,string"`
+		EndTimeUnixNano   uint64         `json:"endTimeUnixNano,string"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+	otlpAnyValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+)
+
+// NewOTLPHTTPExporter returns a new otlp/http json exporter,
+// Shutdown should be called to flush the pending spans before exit.
+func NewOTLPHTTPExporter(config OTLPHTTPExporterConfig) *OTLPHTTPExporter {
+	if config.Endpoint == "" {
+		config.Endpoint = DefaultOTLPEndpoint
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{
+			Timeout: 10 * time.Second,
+		}
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 512
+	}
+	if config.MaxQueueSize <= 0 {
+		config.MaxQueueSize = 4 * config.BatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 5 * time.Second
+	}
+	attrs := make(map[string]any, len(config.ResourceAttributes)+1)
+	for k, v := range config.ResourceAttributes {
+		attrs[k] = v
+	}
+	if config.ServiceName != "" {
+		attrs["service.name"] = config.ServiceName
+	}
+	e := &OTLPHTTPExporter{
+		config: config,
+		resource: otlpResource{
+			Attributes: toOTLPAttributes(attrs),
+		},
+		flushCh:   make(chan struct{}, 1),
+		stopCh:    make(chan struct{}),
+		stoppedCh: make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+func (e *OTLPHTTPExporter) run() {
+	defer close(e.stoppedCh)
+	ticker := time.NewTicker(e.config.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.stopCh:
+			return
+		case <-ticker.C:
+		case <-e.flushCh:
+		}
+		if err := e.Flush(context.Background()); err != nil && e.config.OnError != nil {
+			e.config.OnError(err)
+		}
+	}
+}
+
+// ExportSpans adds the spans to queue, they are sent
+// when the batch is full or the flush interval is reached.
+func (e *OTLPHTTPExporter) ExportSpans(_ context.Context, spans []*Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.queue)+len(spans) > e.config.MaxQueueSize {
+		return ErrOTLPQueueFull
+	}
+	e.queue = append(e.queue, spans...)
+	if len(e.queue) >= e.config.BatchSize {
+		select {
+		case e.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Flush sends all the pending spans by batch
+func (e *OTLPHTTPExporter) Flush(ctx context.Context) error {
+	for {
+		e.mu.Lock()
+		size := min(len(e.queue), e.config.BatchSize)
+		batch := e.queue[:size:size]
+		e.queue = e.queue[size:]
+		e.mu.Unlock()
+		if size == 0 {
+			return nil
+		}
+		if err := e.send(ctx, batch); err != nil {
+			return err
+		}
+	}
+}
+
+// Shutdown stops the background flush and sends the pending spans
+func (e *OTLPHTTPExporter) Shutdown(ctx context.Context) error {
+	e.stopOnce.Do(func() {
+		close(e.stopCh)
+	})
+	select {
+	case <-e.stoppedCh:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return e.Flush(ctx)
+}
+
+func (e *OTLPHTTPExporter) send(ctx context.Context, spans []*Span) error {
+	buf, err := json.Marshal(e.newTraceRequest(spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.config.Endpoint, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(HeaderContentType, "application/json")
+	for k, v := range e.config.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.config.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("otlp export failed, status: %d, body: %s", resp.StatusCode, body)
+	}
+	return nil
+}
+
+func (e *OTLPHTTPExporter) newTraceRequest(spans []*Span) *otlpTraceRequest {
+	items := make([]otlpSpan, len(spans))
+	for i, span := range spans {
+		item := otlpSpan{
+			TraceID:           span.SpanContext.TraceID.String(),
+			SpanID:            span.SpanContext.SpanID.String(),
+			TraceState:        span.SpanContext.TraceState,
+			Flags:             uint32(span.SpanContext.TraceFlags),
+			Name:              span.Name,
+			Kind:              int(span.Kind),
+			StartTimeUnixNano: uint64(span.StartTime.UnixNano()),
+			EndTimeUnixNano:   uint64(span.EndTime.UnixNano()),
+			Attributes:        toOTLPAttributes(span.Attributes),
+			Status: otlpStatus{
+				Code:    int(span.Status),
+				Message: span.StatusMessage,
+			},
+		}
+		if span.ParentSpanID.IsValid() {
+			item.ParentSpanID = span.ParentSpanID.String()
+		}
+		items[i] = item
+	}
+	return &otlpTraceRequest{
+		ResourceSpans: []otlpResourceSpans{
+			{
+				Resource: e.resource,
+				ScopeSpans: []otlpScopeSpans{
+					{
+						Scope: otlpScope{
+							Name: otlpScopeName,
+						},
+						Spans: items,
+					},
+				},
+			},
+		},
+	}
+}
+
+func toOTLPAttributes(attrs map[string]any) []otlpKeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	result := make([]otlpKeyValue, len(keys))
+	for i, k := range keys {
+		result[i] = otlpKeyValue{
+			Key:   k,
+			Value: toOTLPAnyValue(attrs[k]),
+		}
+	}
+	return result
+}
+
+func toOTLPAnyValue(value any) otlpAnyValue {
+	// int64需以字符串形式输出
+	intValue := func(v int64) otlpAnyValue {
+		s := strconv.FormatInt(v, 10)
+		return otlpAnyValue{IntValue: &s}
+	}
+	switch v := value.(type) {
+	case string:
+		return otlpAnyValue{StringValue: &v}
+	case bool:
+		return otlpAnyValue{BoolValue: &v}
+	case int:
+		return intValue(int64(v))
+	case int32:
+		return intValue(int64(v))
+	case int64:
+		return intValue(v)
+	case uint32:
+		return intValue(int64(v))
+	case float32:
+		f := float64(v)
+		return otlpAnyValue{DoubleValue: &f}
+	case float64:
+		return otlpAnyValue{DoubleValue: &v}
+	default:
+		s := fmt.Sprint(v)
+		return otlpAnyValue{StringValue: &s}
+	}
+}
@@ -0,0 +1,164 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package elton
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOTLPHTTPExporter(t *testing.T) {
+	assert := assert.New(t)
+
+	bodies := make(chan map[string]any, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("application/json", r.Header.Get(HeaderContentType))
+		assert.Equal("token", r.Header.Get("X-Token"))
+		buf, _ := io.ReadAll(r.Body)
+		data := make(map[string]any)
+		_ = json.Unmarshal(buf, &data)
+		bodies <- data
+	}))
+	defer server.Close()
+
+	exporter := NewOTLPHTTPExporter(OTLPHTTPExporterConfig{
+		Endpoint:    server.URL,
+		Headers:     map[string]string{"X-Token": "token"},
+		ServiceName: "test",
+		BatchSize:   2,
+		// 只通过batch或shutdown触发
+		FlushInterval: time.Hour,
+	})
+	startedAt := time.Unix(1, 0)
+	span := &Span{
+		Name: "GET /",
+		Kind: SpanKindServer,
+		SpanContext: SpanContext{
+			TraceID:    TraceID{1},
+			SpanID:     SpanID{2},
+			TraceFlags: TraceFlagsSampled,
+		},
+		StartTime: startedAt,
+		EndTime:   startedAt.Add(time.Second),
+		Attributes: map[string]any{
+			"status": 200,
+			"path":   "/",
+			"ok":     true,
+			"ratio":  0.5,
+		},
+		Status:        SpanStatusError,
+		StatusMessage: "fail",
+	}
+	child := &Span{
+		Name: "db",
+		Kind: SpanKindInternal,
+		SpanContext: SpanContext{
+			TraceID: TraceID{1},
+			SpanID:  SpanID{3},
+		},
+		ParentSpanID: SpanID{2},
+		StartTime:    startedAt,
+		EndTime:      startedAt,
+	}
+	assert.Nil(exporter.ExportSpans(context.Background(), []*Span{span, child}))
+
+	var data map[string]any
+	select {
+	case data = <-bodies:
+	case <-time.After(3 * time.Second):
+		assert.Fail("export timeout")
+		return
+	}
+	resourceSpans := data["resourceSpans"].([]any)[0].(map[string]any)
+	assert.Equal([]any{
+		map[string]any{
+			"key": "service.name",
+			"value": map[string]any{
+				"stringValue": "test",
+			},
+		},
+	}, resourceSpans["resource"].(map[string]any)["attributes"])
+	scopeSpans := resourceSpans["scopeSpans"].([]any)[0].(map[string]any)
+	spans := scopeSpans["spans"].([]any)
+	assert.Equal(2, len(spans))
+	assert.Equal(map[string]any{
+		"traceId":           "01000000000000000000000000000000",
+		"spanId":            "0200000000000000",
+		"flags":             float64(1),
+		"name":              "GET /",
+		"kind":              float64(2),
+		"startTimeUnixNano": "1000000000",
+		"endTimeUnixNano":   "2000000000",
+		"attributes": []any{
+			map[string]any{"key": "ok", "value": map[string]any{"boolValue": true}},
+			map[string]any{"key": "path", "value": map[string]any{"stringValue": "/"}},
+			map[string]any{"key": "ratio", "value": map[string]any{"doubleValue": 0.5}},
+			map[string]any{"key": "status", "value": map[string]any{"intValue": "200"}},
+		},
+		"status": map[string]any{
+			"code":    float64(2),
+			"message": "fail",
+		},
+	}, spans[0])
+	assert.Equal("0200000000000000", spans[1].(map[string]any)["parentSpanId"])
+
+	// shutdown时发送剩余的span
+	assert.Nil(exporter.ExportSpans(context.Background(), []*Span{child}))
+	assert.Nil(exporter.Shutdown(context.Background()))
+	data = <-bodies
+	resourceSpans = data["resourceSpans"].([]any)[0].(map[string]any)
+	scopeSpans = resourceSpans["scopeSpans"].([]any)[0].(map[string]any)
+	assert.Equal(1, len(scopeSpans["spans"].([]any)))
+}
+
+func TestOTLPHTTPExporterError(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("bad request"))
+	}))
+	defer server.Close()
+
+	exporter := NewOTLPHTTPExporter(OTLPHTTPExporterConfig{
+		Endpoint:      server.URL,
+		BatchSize:     10,
+		MaxQueueSize:  1,
+		FlushInterval: time.Hour,
+	})
+	defer func() {
+		_ = exporter.Shutdown(context.Background())
+	}()
+	span := &Span{}
+	assert.Equal(ErrOTLPQueueFull, exporter.ExportSpans(context.Background(), []*Span{span, span}))
+	assert.Nil(exporter.ExportSpans(context.Background(), []*Span{span}))
+	err := exporter.Flush(context.Background())
+	assert.Equal("otlp export failed, status: 400, body: bad request", err.Error())
+}
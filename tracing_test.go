@@ -0,0 +1,230 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package elton
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/hes"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	assert := assert.New(t)
+
+	sc, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", " a=1, ,b , c=2")
+	assert.Nil(err)
+	assert.Equal("4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal("00f067aa0ba902b7", sc.SpanID.String())
+	assert.True(sc.IsSampled())
+	assert.True(sc.Remote)
+	assert.Equal("a=1,c=2", sc.TraceState)
+	assert.Equal("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", sc.Traceparent())
+
+	// 未来版本允许更多字段
+	sc, err = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra", "")
+	assert.Nil(err)
+	assert.False(sc.IsSampled())
+
+	for _, value := range []string{
+		"",
+		// 版本ff非法
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		// 大写
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		// trace id全为0
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		// span id全为0
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		// 版本00不允许更多字段
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736_00f067aa0ba902b7-01",
+	} {
+		_, err := ParseTraceparent(value, "a=1")
+		assert.Equal(ErrInvalidTraceparent, err, value)
+	}
+}
+
+func TestNewTracerPanic(t *testing.T) {
+	assert := assert.New(t)
+	assert.PanicsWithValue(ErrTracerRequireExporter, func() {
+		NewTracer(TracerConfig{})
+	})
+}
+
+func TestTracer(t *testing.T) {
+	assert := assert.New(t)
+
+	exporter := NewInMemoryExporter()
+	e := New()
+	e.Tracer = NewTracer(TracerConfig{
+		Exporter: exporter,
+	})
+	entry := func(c *Context) error {
+		return c.Next()
+	}
+	e.Use(entry)
+	e.SetFunctionName(entry, "entry")
+	ignoreFn := func(c *Context) error {
+		return c.Next()
+	}
+	e.Use(ignoreFn)
+	e.SetFunctionName(ignoreFn, "-")
+
+	var traceparent string
+	handler := func(c *Context) error {
+		done := c.Trace().Start("db")
+		time.Sleep(time.Millisecond)
+		done()
+		h := make(http.Header)
+		InjectTraceContext(c.Context(), h)
+		traceparent = h.Get(HeaderTraceparent)
+		c.Span().SetAttribute("user", "tree")
+		return nil
+	}
+	e.GET("/users/{id}", handler)
+	e.SetFunctionName(handler, "getUser")
+	e.GET("/error", func(c *Context) error {
+		return hes.New("service unavailable", hes.WithStatus(http.StatusServiceUnavailable))
+	})
+
+	t.Run("remote parent", func(t *testing.T) {
+		exporter.Reset()
+		req := httptest.NewRequest("GET", "/users/1", nil)
+		req.Header.Set(HeaderTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		req.Header.Set(HeaderTracestate, "a=1")
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(200, resp.Code)
+
+		spans := exporter.Spans()
+		assert.Equal(4, len(spans))
+		root := spans[0]
+		assert.Equal("GET /users/{id}", root.Name)
+		assert.Equal(SpanKindServer, root.Kind)
+		assert.Equal("4bf92f3577b34da6a3ce929d0e0e4736", root.SpanContext.TraceID.String())
+		assert.Equal("00f067aa0ba902b7", root.ParentSpanID.String())
+		assert.Equal("a=1", root.SpanContext.TraceState)
+		assert.Equal(200, root.Attributes["http.response.status_code"])
+		assert.Equal("/users/{id}", root.Attributes["http.route"])
+		assert.Equal(SpanStatusUnset, root.Status)
+
+		// 中间件的span为嵌套关系
+		entrySpan := spans[1]
+		assert.Equal("entry", entrySpan.Name)
+		assert.Equal(root.SpanContext.SpanID, entrySpan.ParentSpanID)
+		handlerSpan := spans[2]
+		assert.Equal("getUser", handlerSpan.Name)
+		assert.Equal(entrySpan.SpanContext.SpanID, handlerSpan.ParentSpanID)
+		assert.Equal("tree", handlerSpan.Attributes["user"])
+		dbSpan := spans[3]
+		assert.Equal("db", dbSpan.Name)
+		assert.Equal(handlerSpan.SpanContext.SpanID, dbSpan.ParentSpanID)
+		assert.True(dbSpan.Duration() >= time.Millisecond)
+
+		for _, span := range spans {
+			assert.Equal(root.SpanContext.TraceID, span.SpanContext.TraceID)
+			assert.False(span.EndTime.IsZero())
+		}
+		// 透传的parent为当前span
+		assert.Equal(handlerSpan.SpanContext.Traceparent(), traceparent)
+	})
+
+	t.Run("not sampled parent", func(t *testing.T) {
+		exporter.Reset()
+		req := httptest.NewRequest("GET", "/users/1", nil)
+		req.Header.Set(HeaderTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(200, resp.Code)
+		assert.Empty(exporter.Spans())
+		// 未采样也需要透传trace id
+		sc, err := ParseTraceparent(traceparent, "")
+		assert.Nil(err)
+		assert.Equal("4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+		assert.False(sc.IsSampled())
+	})
+
+	t.Run("new trace with error", func(t *testing.T) {
+		exporter.Reset()
+		req := httptest.NewRequest("GET", "/error", nil)
+		req.Header.Set(HeaderTraceparent, "invalid")
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(503, resp.Code)
+
+		spans := exporter.Spans()
+		assert.Equal(3, len(spans))
+		root := spans[0]
+		assert.True(root.SpanContext.IsValid())
+		assert.True(root.SpanContext.IsSampled())
+		assert.False(root.ParentSpanID.IsValid())
+		assert.Equal(SpanStatusError, root.Status)
+		assert.Equal(503, root.Attributes["http.response.status_code"])
+		assert.Equal(SpanStatusError, spans[2].Status)
+		assert.Equal("statusCode=503, message=service unavailable", spans[2].StatusMessage)
+	})
+}
+
+type errorExporter struct {
+	InMemoryExporter
+}
+
+func (e *errorExporter) ExportSpans(_ context.Context, _ []*Span) error {
+	return errors.New("export fail")
+}
+
+func TestTracerSampler(t *testing.T) {
+	assert := assert.New(t)
+
+	var exportErr error
+	e := New()
+	e.Tracer = NewTracer(TracerConfig{
+		Exporter: &errorExporter{},
+		Sampler: func(c *Context) bool {
+			return c.QueryParam("sampled") != ""
+		},
+		OnError: func(err error) {
+			exportErr = err
+		},
+	})
+	e.GET("/", func(c *Context) error {
+		assert.Equal(c.Span().SpanContext.IsSampled(), c.QueryParam("sampled") != "")
+		return nil
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Nil(exportErr)
+
+	req = httptest.NewRequest("GET", "/?sampled=1", nil)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal("export fail", exportErr.Error())
+}
@@ -0,0 +1,171 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package elton
+
+import (
+	"net/http"
+	"reflect"
+	"slices"
+)
+
+// TypedHandler the handler with typed request and response,
+// it is adapted to Handler by Typed.
+type TypedHandler[Req, Resp any] func(c *Context, req Req) (Resp, error)
+
+// Typed adapts the typed handler to Handler. The request params(route params,
+// query, header and body) are decoded and validated to Req by Bind, so Req
+// should be a struct(use struct{} if no param). The returned Resp is set as
+// Context.Body, it is rendered by the responder middleware, e.g.
+//
+//	e.GET("/users/{id}", elton.Typed(func(c *elton.Context, req GetUserParams) (*User, error) {
+//		return findUser(req.ID)
+//	}))
+//
+// Use HandleTyped or GroupHandleTyped to add the types to the document of route.
+// It will throw a panic if the validate tag of Req is invalid.
+func Typed[Req, Resp any](fn TypedHandler[Req, Resp]) Handler {
+	// 创建时校验validate tag，避免处理请求时才出错
+	if err := checkBind(reflect.TypeFor[Req]()); err != nil {
+		panic(err)
+	}
+	return func(c *Context) error {
+		req, err := Bind[Req](c)
+		if err != nil {
+			return err
+		}
+		resp, err := fn(c, req)
+		if err != nil {
+			return err
+		}
+		// 已自行响应（如Pipe、NoContent）则不设置body
+		if c.Committed || c.StatusCode == http.StatusNoContent {
+			return nil
+		}
+		c.Body = resp
+		return nil
+	}
+}
+
+// HandleTyped adds the typed handler to elton, the middlewares are called
+// before the handler. The types of Req and Resp are saved to the route and
+// its document(request body, query and header parameters, response), e.g.
+//
+//	elton.HandleTyped(e, "POST", "/users", createUser).Describe(&elton.RouteDoc{
+//		Summary: "create user",
+//	})
+func HandleTyped[Req, Resp any](e *Elton, method, path string, fn TypedHandler[Req, Resp], middlewares ...Handler) *Route {
+	route := e.Handle(method, path, append(slices.Clone(middlewares), Typed(fn))...)
+	route.requestType = reflect.TypeFor[Req]()
+	route.responseType = reflect.TypeFor[Resp]()
+	return route.Describe(route.doc)
+}
+
+// GroupHandleTyped adds the typed handler to group, it is the same as
+// HandleTyped but the route is added to elton by AddGroup.
+func GroupHandleTyped[Req, Resp any](g *Group, method, path string, fn TypedHandler[Req, Resp], middlewares ...Handler) *Group {
+	g.handle(method, path, append(slices.Clone(middlewares), Typed(fn))...)
+	r := g.routers[g.lastRouterIndex]
+	r.requestType = reflect.TypeFor[Req]()
+	r.responseType = reflect.TypeFor[Resp]()
+	return g
+}
+
+// RequestType returns the type of request, it is set by HandleTyped
+// or GroupHandleTyped, nil will be returned if not typed.
+func (r *Route) RequestType() reflect.Type {
+	return r.requestType
+}
+
+// ResponseType returns the type of response, it is set by HandleTyped
+// or GroupHandleTyped, nil will be returned if not typed.
+func (r *Route) ResponseType() reflect.Type {
+	return r.responseType
+}
+
+// typedDoc returns a copy of doc which is filled with the types of route,
+// the fields set by doc are kept.
+func (r *Route) typedDoc(doc *RouteDoc) *RouteDoc {
+	d := &RouteDoc{}
+	if doc != nil {
+		*d = *doc
+		d.Parameters = slices.Clone(doc.Parameters)
+	}
+	if d.Response == nil && r.responseType != typedEmptyType {
+		d.Response = r.responseType
+	}
+	reqType := r.requestType
+	for reqType.Kind() == reflect.Pointer {
+		reqType = reqType.Elem()
+	}
+	if reqType.Kind() != reflect.Struct {
+		return d
+	}
+	if d.Request == nil && reqType.NumField() != 0 && typedHasBody(r.methods) {
+		d.Request = reqType
+	}
+	builder := &openAPISchemaBuilder{
+		schemas: make(map[string]*OpenAPISchema),
+		names:   make(map[reflect.Type]string),
+	}
+	// Typed创建时已校验validate tag
+	bs, _ := getBindStruct(reqType)
+	for _, field := range bs.fields {
+		param := OpenAPIParameter{
+			Name: field.query,
+			In:   "query",
+		}
+		if field.header != "" {
+			param.Name = field.header
+			param.In = "header"
+		}
+		if param.Name == "" || d.hasParameter(param.Name, param.In) {
+			continue
+		}
+		param.Schema = builder.build(reqType.FieldByIndex(field.index).Type)
+		param.Required = slices.ContainsFunc(field.rules, func(rule *bindRule) bool {
+			return rule.name == BindRuleRequired
+		})
+		d.Parameters = append(d.Parameters, param)
+	}
+	return d
+}
+
+func (d *RouteDoc) hasParameter(name, in string) bool {
+	return slices.ContainsFunc(d.Parameters, func(p OpenAPIParameter) bool {
+		return p.Name == name && p.In == in
+	})
+}
+
+var typedEmptyType = reflect.TypeFor[struct{}]()
+
+// typedHasBody returns true if any method has request body
+func typedHasBody(methods []string) bool {
+	for _, method := range methods {
+		switch method {
+		case http.MethodGet, http.MethodHead, http.MethodDelete, http.MethodOptions, http.MethodTrace:
+		default:
+			return true
+		}
+	}
+	return false
+}
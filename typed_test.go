@@ -0,0 +1,159 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package elton
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/hes"
+)
+
+type typedCreateUser struct {
+	Account string `json:"account" validate:"required"`
+	Notify  bool   `query:"notify"`
+	Token   string `json:"-" header:"X-Token" validate:"required"`
+}
+
+type typedUser struct {
+	ID      int    `json:"id"`
+	Account string `json:"account"`
+}
+
+func createTypedUser(c *Context, req typedCreateUser) (*typedUser, error) {
+	if req.Account == "admin" {
+		return nil, errors.New("account is reserved")
+	}
+	c.StatusCode = http.StatusCreated
+	return &typedUser{
+		ID:      1,
+		Account: req.Account,
+	}, nil
+}
+
+func TestTyped(t *testing.T) {
+	assert := assert.New(t)
+
+	fn := Typed(createTypedUser)
+
+	c := newBindContext("POST", "/users?notify=true", "application/json", `{"account":"tree"}`)
+	c.Request.Header.Set("X-Token", "abc")
+	assert.Nil(fn(c))
+	assert.Equal(http.StatusCreated, c.StatusCode)
+	assert.Equal(&typedUser{
+		ID:      1,
+		Account: "tree",
+	}, c.Body)
+
+	// 参数校验失败
+	c = newBindContext("POST", "/users", "application/json", `{"account":"tree"}`)
+	err := fn(c)
+	he, ok := err.(*hes.Error)
+	assert.True(ok)
+	assert.Equal(http.StatusBadRequest, he.StatusCode)
+	assert.Equal(1, len(he.Errs))
+	assert.Nil(c.Body)
+
+	c = newBindContext("POST", "/users", "application/json", `{"account":"admin"}`)
+	c.Request.Header.Set("X-Token", "abc")
+	assert.Equal("account is reserved", fn(c).Error())
+	assert.Nil(c.Body)
+
+	// NoContent不设置body
+	fn = Typed(func(c *Context, _ struct{}) (*typedUser, error) {
+		c.NoContent()
+		return nil, nil
+	})
+	c = newBindContext("DELETE", "/users/1", "", "")
+	assert.Nil(fn(c))
+	assert.Equal(http.StatusNoContent, c.StatusCode)
+	assert.Nil(c.Body)
+}
+
+func TestHandleTyped(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New()
+	route := HandleTyped(e, "POST", "/users", createTypedUser).Describe(&RouteDoc{
+		Summary: "create user",
+	})
+	assert.Equal(reflect.TypeFor[typedCreateUser](), route.RequestType())
+	assert.Equal(reflect.TypeFor[*typedUser](), route.ResponseType())
+
+	doc := e.GetRouteDoc("POST", "/users")
+	assert.Equal("create user", doc.Summary)
+	assert.Equal(route.RequestType(), doc.Request)
+	assert.Equal(route.ResponseType(), doc.Response)
+	assert.Equal([]OpenAPIParameter{
+		{
+			Name:   "notify",
+			In:     "query",
+			Schema: &OpenAPISchema{Type: "boolean"},
+		},
+		{
+			Name:     "X-Token",
+			In:       "header",
+			Required: true,
+			Schema:   &OpenAPISchema{Type: "string"},
+		},
+	}, doc.Parameters)
+
+	// GET无request body
+	HandleTyped(e, "GET", "/users/{id}", func(c *Context, req struct {
+		ID int `param:"id"`
+	}) (*typedUser, error) {
+		return &typedUser{ID: req.ID}, nil
+	})
+	doc = e.GetRouteDoc("GET", "/users/{id}")
+	assert.Nil(doc.Request)
+	assert.Empty(doc.Parameters)
+	assert.Equal(reflect.TypeFor[*typedUser](), doc.Response)
+
+	api := e.OpenAPI(OpenAPIInfo{
+		Title: "test",
+	})
+	op := api.Paths["/users"]["post"]
+	assert.Equal("create user", op.Summary)
+	assert.Equal("#/components/schemas/typedCreateUser", op.RequestBody.Content["application/json"].Schema.Ref)
+	assert.Equal("#/components/schemas/typedUser", op.Responses["200"].Content["application/json"].Schema.Ref)
+	assert.Equal(2, len(op.Parameters))
+}
+
+func TestGroupHandleTyped(t *testing.T) {
+	assert := assert.New(t)
+
+	g := NewGroup("/api")
+	GroupHandleTyped(g, "POST", "/users", createTypedUser)
+	e := New()
+	e.AddGroup(g)
+
+	route := e.RouteTable().Route("POST", "/api/users")
+	assert.Equal(reflect.TypeFor[typedCreateUser](), route.RequestType())
+	assert.Equal(reflect.TypeFor[*typedUser](), route.ResponseType())
+	doc := e.GetRouteDoc("POST", "/api/users")
+	assert.NotNil(doc)
+	assert.Equal(route.RequestType(), doc.Request)
+}
@@ -0,0 +1,221 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package elton
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// virtualHost the virtual host of host pattern, e.g. "{tenant}.example.com"
+type virtualHost struct {
+	pattern string
+	// labels the labels of host pattern(without the leading wildcard),
+	// the param label is "{name}"
+	labels []string
+	// wildcard whether the pattern starts with "*."
+	wildcard   bool
+	paramNames []string
+	elton      *Elton
+}
+
+// parseHostPattern parses the host pattern, the pattern supports:
+//   - exact host: example.com
+//   - param label: {tenant}.example.com, the label is saved to params
+//   - leading wildcard: *.example.com, it matches one or more labels
+func parseHostPattern(pattern string) (*virtualHost, error) {
+	host := strings.TrimSuffix(strings.ToLower(pattern), ".")
+	if host == "" {
+		return nil, fmt.Errorf("elton: host pattern is empty")
+	}
+	vh := &virtualHost{
+		pattern: host,
+	}
+	if rest, ok := strings.CutPrefix(host, "*."); ok {
+		vh.wildcard = true
+		host = rest
+	}
+	vh.labels = strings.Split(host, ".")
+	for _, label := range vh.labels {
+		if label == "" || strings.Contains(label, "*") {
+			return nil, fmt.Errorf("elton: invalid host pattern %q", pattern)
+		}
+		if !strings.HasPrefix(label, "{") {
+			if strings.ContainsAny(label, "{}") {
+				return nil, fmt.Errorf("elton: invalid host pattern %q", pattern)
+			}
+			continue
+		}
+		name, ok := strings.CutSuffix(label[1:], "}")
+		if !ok || name == "" || strings.ContainsAny(name, "{}") {
+			return nil, fmt.Errorf("elton: invalid host pattern %q", pattern)
+		}
+		if slices.Contains(vh.paramNames, name) {
+			return nil, fmt.Errorf("elton: duplicate param %q in host pattern %q", name, pattern)
+		}
+		vh.paramNames = append(vh.paramNames, name)
+	}
+	return vh, nil
+}
+
+// isExact returns true if the pattern has neither param nor wildcard
+func (vh *virtualHost) isExact() bool {
+	return !vh.wildcard && len(vh.paramNames) == 0
+}
+
+// match matches the labels of host, the values of params are returned
+func (vh *virtualHost) match(labels []string) ([]string, bool) {
+	count := len(vh.labels)
+	if vh.wildcard {
+		// 通配符至少匹配一个label
+		if len(labels) <= count {
+			return nil, false
+		}
+		labels = labels[len(labels)-count:]
+	} else if len(labels) != count {
+		return nil, false
+	}
+	var values []string
+	for i, label := range vh.labels {
+		if label[0] == '{' {
+			values = append(values, labels[i])
+			continue
+		}
+		if label != labels[i] {
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+// requestHostname returns the lower case host name of request without port
+func requestHostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// matchHost returns the virtual host which matches the host of request,
+// the exact host is matched first, then the patterns in the order of adding.
+// The host params are set to the path values of request.
+func (e *Elton) matchHost(req *http.Request) *virtualHost {
+	hostname := requestHostname(req.Host)
+	if vh, ok := e.exactHosts[hostname]; ok {
+		return vh
+	}
+	if len(e.hosts) == 0 || hostname == "" {
+		return nil
+	}
+	labels := strings.Split(hostname, ".")
+	for _, vh := range e.hosts {
+		values, ok := vh.match(labels)
+		if !ok {
+			continue
+		}
+		for i, name := range vh.paramNames {
+			req.SetPathValue(name, values[i])
+		}
+		return vh
+	}
+	return nil
+}
+
+// AddHost adds the elton instance as virtual host of the host pattern,
+// the request of which host matches the pattern is handled by the child
+// (its pre middlewares, middlewares and routes), the other requests are
+// handled by the current elton instance as default host.
+// The status of child is ignored, the status of current elton is used.
+// The host pattern supports exact host(example.com), param label({tenant}.example.com)
+// and leading wildcard(*.example.com), the host params can be got by Context.Param.
+// It will throw a panic if the pattern is invalid or already added.
+// The virtual hosts should be added before the server is started.
+func (e *Elton) AddHost(pattern string, child *Elton) *Elton {
+	if child == nil || child == e {
+		panic(fmt.Errorf("elton: invalid elton of host %q", pattern))
+	}
+	vh, err := parseHostPattern(pattern)
+	if err != nil {
+		panic(err)
+	}
+	if e.findHost(vh.pattern) != nil {
+		panic(fmt.Errorf("elton: host pattern %q is already added", pattern))
+	}
+	vh.elton = child
+	if vh.isExact() {
+		if e.exactHosts == nil {
+			e.exactHosts = make(map[string]*virtualHost)
+		}
+		e.exactHosts[vh.pattern] = vh
+	}
+	e.hosts = append(e.hosts, vh)
+	return e
+}
+
+func (e *Elton) findHost(pattern string) *virtualHost {
+	for _, vh := range e.hosts {
+		if vh.pattern == pattern {
+			return vh
+		}
+	}
+	return nil
+}
+
+// VirtualHost returns the elton instance of the host pattern, a new instance
+// is created and added if not exists. The new instance inherits the
+// handlers(error, not found...), trace, signed keys, listeners and
+// global middlewares of current elton at the time, e.g.
+//
+//	e.VirtualHost("{tenant}.example.com").AddGroup(tenantGroup)
+func (e *Elton) VirtualHost(pattern string) *Elton {
+	vh, err := parseHostPattern(pattern)
+	if err != nil {
+		panic(err)
+	}
+	if exists := e.findHost(vh.pattern); exists != nil {
+		return exists.elton
+	}
+	child := NewWithoutServer()
+	child.ErrorHandler = e.ErrorHandler
+	child.NotFoundHandler = e.NotFoundHandler
+	child.MethodNotAllowedHandler = e.MethodNotAllowedHandler
+	child.GenerateID = e.GenerateID
+	child.EnableTrace = e.EnableTrace
+	child.Tracer = e.Tracer
+	child.SignedKeys = e.SignedKeys
+	child.errorListeners = slices.Clone(e.errorListeners)
+	child.traceListeners = slices.Clone(e.traceListeners)
+	child.doneListeners = slices.Clone(e.doneListeners)
+	child.beforeListeners = slices.Clone(e.beforeListeners)
+	e.functionInfosMutex.RLock()
+	for k, v := range e.functionInfos {
+		child.functionInfos[k] = v
+	}
+	e.functionInfosMutex.RUnlock()
+	child.middlewares = slices.Clone(e.middlewares)
+	e.AddHost(pattern, child)
+	return child
+}
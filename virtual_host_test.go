@@ -0,0 +1,217 @@
+// MIT License
+
+// Copyright (c) 2020 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package elton
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHostPattern(t *testing.T) {
+	assert := assert.New(t)
+
+	vh, err := parseHostPattern("*.{tenant}.Example.com.")
+	assert.Nil(err)
+	assert.Equal("*.{tenant}.example.com", vh.pattern)
+	assert.True(vh.wildcard)
+	assert.Equal([]string{"{tenant}", "example", "com"}, vh.labels)
+	assert.Equal([]string{"tenant"}, vh.paramNames)
+	assert.False(vh.isExact())
+
+	values, ok := vh.match([]string{"a", "b", "t1", "example", "com"})
+	assert.True(ok)
+	assert.Equal([]string{"t1"}, values)
+	// 通配符至少匹配一个label
+	_, ok = vh.match([]string{"t1", "example", "com"})
+	assert.False(ok)
+
+	for _, pattern := range []string{
+		"",
+		"a.*.com",
+		"a..com",
+		"{}.example.com",
+		"{a.example.com",
+		"a{b}.example.com",
+		"{a}.{a}.com",
+	} {
+		_, err := parseHostPattern(pattern)
+		assert.NotNil(err, pattern)
+	}
+}
+
+func TestVirtualHost(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New()
+	e.Use(func(c *Context) error {
+		c.SetHeader("X-Global", "1")
+		return c.Next()
+	})
+	e.GET("/", func(c *Context) error {
+		c.BodyBuffer = bytes.NewBufferString("default")
+		return nil
+	})
+
+	// 子实例继承全局中间件
+	tenant := e.VirtualHost("{tenant}.example.com")
+	assert.Equal(tenant, e.VirtualHost("{tenant}.example.com"))
+	tenant.GET("/users/{id}", func(c *Context) error {
+		c.BodyBuffer = bytes.NewBufferString(c.Param("tenant") + ":" + c.Param("id"))
+		return nil
+	})
+
+	api := New()
+	api.GET("/", func(c *Context) error {
+		c.BodyBuffer = bytes.NewBufferString("api")
+		return nil
+	})
+	e.AddHost("api.example.com", api)
+
+	g := NewGroup("/static")
+	g.GET("/{path...}", func(c *Context) error {
+		c.BodyBuffer = bytes.NewBufferString("static:" + c.Param("path"))
+		return nil
+	})
+	e.VirtualHost("*.cdn.example.com").AddGroup(g)
+
+	tests := []struct {
+		url    string
+		status int
+		body   string
+		global string
+	}{
+		{
+			url:    "http://example.com/",
+			status: 200,
+			body:   "default",
+			global: "1",
+		},
+		// exact host优先
+		{
+			url:    "http://API.example.com:8080/",
+			status: 200,
+			body:   "api",
+		},
+		{
+			url:    "http://t1.example.com/users/1",
+			status: 200,
+			body:   "t1:1",
+			global: "1",
+		},
+		{
+			url:    "http://t1.example.com/",
+			status: 404,
+			body:   "Not Found",
+		},
+		{
+			url:    "http://a.b.cdn.example.com/static/js/app.js",
+			status: 200,
+			body:   "static:js/app.js",
+			global: "1",
+		},
+		// 未匹配则使用默认主机
+		{
+			url:    "http://a.b.example.com/",
+			status: 200,
+			body:   "default",
+			global: "1",
+		},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.url, nil)
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(tt.status, resp.Code, tt.url)
+		assert.Equal(tt.body, resp.Body.String(), tt.url)
+		assert.Equal(tt.global, resp.Header().Get("X-Global"), tt.url)
+	}
+
+	// 共享默认实例的状态
+	e.status.Store(int32(StatusClosing))
+	req := httptest.NewRequest("GET", "http://api.example.com/", nil)
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(503, resp.Code)
+
+	assert.Panics(func() {
+		e.AddHost("api.example.com", New())
+	})
+	assert.Panics(func() {
+		e.AddHost("a.example.com", e)
+	})
+	assert.Panics(func() {
+		e.VirtualHost("a.*.com")
+	})
+}
+
+func TestVirtualHostParams(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New()
+	e.VirtualHost("{tenant}.example.com").GET("/", func(c *Context) error {
+		assert.Equal(map[string]string{
+			"tenant": "t1",
+		}, c.Params.ToMap())
+		return nil
+	})
+	req := httptest.NewRequest("GET", "http://t1.example.com/", nil)
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+}
+
+func TestVirtualHostParamsMultiPattern(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New()
+	child := New()
+	var params map[string]string
+	child.GET("/users/{id}", func(c *Context) error {
+		params = c.Params.ToMap()
+		return nil
+	})
+	// 同一子实例挂载于多个host，仅返回所匹配host的参数
+	e.AddHost("{tenant}.a.com", child)
+	e.AddHost("{org}.b.com", child)
+
+	req := httptest.NewRequest("GET", "http://t1.a.com/users/1", nil)
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal(map[string]string{
+		"id":     "1",
+		"tenant": "t1",
+	}, params)
+
+	req = httptest.NewRequest("GET", "http://o1.b.com/users/2", nil)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal(map[string]string{
+		"id":  "2",
+		"org": "o1",
+	}, params)
+}
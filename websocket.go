@@ -0,0 +1,731 @@
+// MIT License
+
+// Copyright (c) 2026 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package elton
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/vicanso/hes"
+)
+
+// websocket message types, the same as the opcode of RFC 6455
+const (
+	WebSocketContinuationFrame = 0
+	WebSocketTextMessage       = 1
+	WebSocketBinaryMessage     = 2
+	WebSocketCloseMessage      = 8
+	WebSocketPingMessage       = 9
+	WebSocketPongMessage       = 10
+)
+
+// websocket close codes of RFC 6455
+const (
+	WebSocketCloseNormalClosure           = 1000
+	WebSocketCloseGoingAway               = 1001
+	WebSocketCloseProtocolError           = 1002
+	WebSocketCloseUnsupportedData         = 1003
+	WebSocketCloseNoStatusReceived        = 1005
+	WebSocketCloseAbnormalClosure         = 1006
+	WebSocketCloseInvalidFramePayloadData = 1007
+	WebSocketClosePolicyViolation         = 1008
+	WebSocketCloseMessageTooBig           = 1009
+	WebSocketCloseMandatoryExtension      = 1010
+	WebSocketCloseInternalServerErr       = 1011
+)
+
+const (
+	// HeaderSecWebSocketKey Sec-WebSocket-Key
+	HeaderSecWebSocketKey = "Sec-WebSocket-Key"
+	// HeaderSecWebSocketAccept Sec-WebSocket-Accept
+	HeaderSecWebSocketAccept = "Sec-WebSocket-Accept"
+	// HeaderSecWebSocketVersion Sec-WebSocket-Version
+	HeaderSecWebSocketVersion = "Sec-WebSocket-Version"
+	// HeaderSecWebSocketProtocol Sec-WebSocket-Protocol
+	HeaderSecWebSocketProtocol = "Sec-WebSocket-Protocol"
+	// HeaderSecWebSocketExtensions Sec-WebSocket-Extensions
+	HeaderSecWebSocketExtensions = "Sec-WebSocket-Extensions"
+
+	webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	// defaultWebSocketReadLimit default max size of message
+	defaultWebSocketReadLimit = 32 * 1024 * 1024
+	// webSocketPreallocSize the max size of payload which is allocated
+	// by the frame length, the larger payload is read as stream
+	webSocketPreallocSize  = 64 * 1024
+	maxControlFramePayload = 125
+	permessageDeflate      = "permessage-deflate"
+	finalBit               = 0x80
+	rsv1Bit                = 0x40
+	maskBit                = 0x80
+)
+
+var (
+	// ErrWebSocketRequireHandler websocket handler is nil
+	ErrWebSocketRequireHandler = errors.New("require handler for websocket")
+	// ErrWebSocketBadHandshake the handshake request is invalid
+	ErrWebSocketBadHandshake = &hes.Error{
+		StatusCode: http.StatusBadRequest,
+		Message:    "websocket: bad handshake",
+		Category:   ErrCategory,
+	}
+	// ErrWebSocketUnsupportedVersion the version of websocket is not 13
+	ErrWebSocketUnsupportedVersion = &hes.Error{
+		StatusCode: http.StatusUpgradeRequired,
+		Message:    "websocket: unsupported version",
+		Category:   ErrCategory,
+	}
+	// ErrWebSocketOriginNotAllowed the origin is not allowed
+	ErrWebSocketOriginNotAllowed = &hes.Error{
+		StatusCode: http.StatusForbidden,
+		Message:    "websocket: origin is not allowed",
+		Category:   ErrCategory,
+	}
+	// ErrWebSocketHijackNotSupported the response writer can not be hijacked(e.g. http2)
+	ErrWebSocketHijackNotSupported = &hes.Error{
+		StatusCode: http.StatusInternalServerError,
+		Message:    "websocket: hijack is not supported",
+		Category:   ErrCategory,
+	}
+	// ErrWebSocketClosed write to a closed websocket connection
+	ErrWebSocketClosed = errors.New("websocket: connection is closed")
+	// ErrWebSocketInvalidMessageType the message type is invalid
+	ErrWebSocketInvalidMessageType = errors.New("websocket: invalid message type")
+)
+
+type (
+	// WebSocketHandler websocket handler, it is called after upgraded,
+	// the connection is closed after it returns.
+	WebSocketHandler func(c *Context, conn *WebSocketConn) error
+	// WebSocketConfig websocket config
+	WebSocketConfig struct {
+		// Handler the handler of websocket connection
+		Handler WebSocketHandler
+		// CheckOrigin check the origin of request, the default allows
+		// the request without origin or the origin's host equals to the host
+		CheckOrigin func(c *Context) bool
+		// Subprotocols the supported subprotocols in order of preference
+		Subprotocols []string
+		// EnableCompression negotiates permessage-deflate(without context takeover)
+		EnableCompression bool
+		// ReadLimit the max size of message, default is 32MB
+		ReadLimit int64
+	}
+	// WebSocketCloseError the close frame received or the
+	// protocol error which closes the connection
+	WebSocketCloseError struct {
+		Code int
+		Text string
+	}
+	// WebSocketConn websocket connection
+	WebSocketConn struct {
+		conn        net.Conn
+		br          *bufio.Reader
+		bw          *bufio.Writer
+		isServer    bool
+		compress    bool
+		subprotocol string
+		readLimit   int64
+		pongHandler func(data []byte) error
+
+		// writeMu protects bw and closeSent
+		writeMu   sync.Mutex
+		closeSent bool
+		closeOnce sync.Once
+	}
+	webSocketFrame struct {
+		fin     bool
+		rsv1    bool
+		opcode  int
+		payload []byte
+	}
+)
+
+func (e *WebSocketCloseError) Error() string {
+	return "websocket: close " + strconv.Itoa(e.Code) + " " + e.Text
+}
+
+// IsWebSocketCloseError returns whether the error is close error of the codes,
+// any close error matches if codes is empty.
+func IsWebSocketCloseError(err error, codes ...int) bool {
+	var ce *WebSocketCloseError
+	if !errors.As(err, &ce) {
+		return false
+	}
+	if len(codes) == 0 {
+		return true
+	}
+	for _, code := range codes {
+		if ce.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// headerContainsToken returns whether the comma-separated header contains the token
+func headerContainsToken(h http.Header, key, token string) bool {
+	for _, value := range h.Values(key) {
+		for _, item := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(item), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// webSocketAcceptKey computes the Sec-WebSocket-Accept of key
+func webSocketAcceptKey(key string) string {
+	h := sha1.New()
+	_, _ = h.Write([]byte(key + webSocketGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func defaultWebSocketCheckOrigin(c *Context) bool {
+	origin := c.GetRequestHeader("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, c.Request.Host)
+}
+
+// negotiateDeflate returns whether the permessage-deflate offer is acceptable,
+// the server_max_window_bits is not supported as the window of flate is fixed.
+func negotiateDeflate(h http.Header) bool {
+	for _, value := range h.Values(HeaderSecWebSocketExtensions) {
+		for _, ext := range strings.Split(value, ",") {
+			params := strings.Split(ext, ";")
+			if strings.TrimSpace(params[0]) != permessageDeflate {
+				continue
+			}
+			ok := true
+			for _, param := range params[1:] {
+				name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if name == "server_max_window_bits" && strings.Trim(value, `"`) != "15" {
+					ok = false
+				}
+			}
+			if ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NewWebSocket returns a websocket handler, it upgrades the connection
+// after the middlewares, so the middlewares such as logger and stats work
+// as usual(the status is 101). The connection is closed after the handler returns.
+// It will throw a panic if the handler is nil.
+func NewWebSocket(config WebSocketConfig) Handler {
+	if config.Handler == nil {
+		panic(ErrWebSocketRequireHandler)
+	}
+	checkOrigin := config.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = defaultWebSocketCheckOrigin
+	}
+	readLimit := config.ReadLimit
+	if readLimit <= 0 {
+		readLimit = defaultWebSocketReadLimit
+	}
+	return func(c *Context) error {
+		req := c.Request
+		if req.Method != http.MethodGet ||
+			!headerContainsToken(req.Header, HeaderConnection, "upgrade") ||
+			!headerContainsToken(req.Header, HeaderUpgrade, "websocket") {
+			return ErrWebSocketBadHandshake
+		}
+		if req.Header.Get(HeaderSecWebSocketVersion) != "13" {
+			c.SetHeader(HeaderSecWebSocketVersion, "13")
+			return ErrWebSocketUnsupportedVersion
+		}
+		key := req.Header.Get(HeaderSecWebSocketKey)
+		if buf, err := base64.StdEncoding.DecodeString(key); err != nil || len(buf) != 16 {
+			return ErrWebSocketBadHandshake
+		}
+		if !checkOrigin(c) {
+			return ErrWebSocketOriginNotAllowed
+		}
+		subprotocol := ""
+		for _, protocol := range config.Subprotocols {
+			if headerContainsToken(req.Header, HeaderSecWebSocketProtocol, protocol) {
+				subprotocol = protocol
+				break
+			}
+		}
+		compress := config.EnableCompression && negotiateDeflate(req.Header)
+
+		conn, rw, err := http.NewResponseController(c.Response).Hijack()
+		if err != nil {
+			return ErrWebSocketHijackNotSupported.WithCause(err)
+		}
+		c.Committed = true
+		c.StatusCode = http.StatusSwitchingProtocols
+
+		h := c.Header().Clone()
+		h.Set(HeaderUpgrade, "websocket")
+		h.Set(HeaderConnection, "Upgrade")
+		h.Set(HeaderSecWebSocketAccept, webSocketAcceptKey(key))
+		if subprotocol != "" {
+			h.Set(HeaderSecWebSocketProtocol, subprotocol)
+		}
+		if compress {
+			h.Set(HeaderSecWebSocketExtensions, permessageDeflate+"; server_no_context_takeover; client_no_context_takeover")
+		}
+		h.Del(HeaderContentLength)
+		_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+		_ = h.Write(rw)
+		_, _ = rw.WriteString("\r\n")
+		if err := rw.Flush(); err != nil {
+			_ = conn.Close()
+			return err
+		}
+		// 握手的超时设置不应影响websocket连接
+		_ = conn.SetDeadline(time.Time{})
+
+		ws := newWebSocketConn(conn, rw.Reader, rw.Writer, true, compress)
+		ws.subprotocol = subprotocol
+		ws.readLimit = readLimit
+		err = config.Handler(c, ws)
+		if err != nil {
+			_ = ws.WriteClose(WebSocketCloseInternalServerErr, "")
+		}
+		_ = ws.Close()
+		return err
+	}
+}
+
+func newWebSocketConn(conn net.Conn, br *bufio.Reader, bw *bufio.Writer, isServer, compress bool) *WebSocketConn {
+	return &WebSocketConn{
+		conn:      conn,
+		br:        br,
+		bw:        bw,
+		isServer:  isServer,
+		compress:  compress,
+		readLimit: defaultWebSocketReadLimit,
+	}
+}
+
+// Subprotocol returns the negotiated subprotocol
+func (ws *WebSocketConn) Subprotocol() string {
+	return ws.subprotocol
+}
+
+// NetConn returns the underlying connection
+func (ws *WebSocketConn) NetConn() net.Conn {
+	return ws.conn
+}
+
+// RemoteAddr returns the remote address
+func (ws *WebSocketConn) RemoteAddr() net.Addr {
+	return ws.conn.RemoteAddr()
+}
+
+// SetReadDeadline sets the read deadline of the underlying connection
+func (ws *WebSocketConn) SetReadDeadline(t time.Time) error {
+	return ws.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the write deadline of the underlying connection
+func (ws *WebSocketConn) SetWriteDeadline(t time.Time) error {
+	return ws.conn.SetWriteDeadline(t)
+}
+
+// SetReadLimit sets the max size of message, limit <= 0 means no limit
+// (the payload is read as stream instead of allocated by the frame length).
+func (ws *WebSocketConn) SetReadLimit(limit int64) {
+	ws.readLimit = limit
+}
+
+// SetPongHandler sets the handler for pong message,
+// it is called in ReadMessage.
+func (ws *WebSocketConn) SetPongHandler(fn func(data []byte) error) {
+	ws.pongHandler = fn
+}
+
+// fail sends the close frame of code and closes the connection
+func (ws *WebSocketConn) fail(code int, text string) error {
+	_ = ws.WriteClose(code, text)
+	_ = ws.Close()
+	return &WebSocketCloseError{
+		Code: code,
+		Text: text,
+	}
+}
+
+func (ws *WebSocketConn) readFrame() (*webSocketFrame, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(ws.br, header[:2]); err != nil {
+		return nil, err
+	}
+	f := &webSocketFrame{
+		fin:    header[0]&finalBit != 0,
+		rsv1:   header[0]&rsv1Bit != 0,
+		opcode: int(header[0] & 0x0f),
+	}
+	if header[0]&0x30 != 0 {
+		return nil, ws.fail(WebSocketCloseProtocolError, "reserved bits are set")
+	}
+	masked := header[1]&maskBit != 0
+	if masked != ws.isServer {
+		return nil, ws.fail(WebSocketCloseProtocolError, "invalid mask bit")
+	}
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		if _, err := io.ReadFull(ws.br, header[:2]); err != nil {
+			return nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(header[:2]))
+	case 127:
+		if _, err := io.ReadFull(ws.br, header[:8]); err != nil {
+			return nil, err
+		}
+		length = binary.BigEndian.Uint64(header[:8])
+		// 最高位必须为0
+		if length > math.MaxInt64 {
+			return nil, ws.fail(WebSocketCloseProtocolError, "invalid payload length")
+		}
+	}
+
+	switch f.opcode {
+	case WebSocketCloseMessage, WebSocketPingMessage, WebSocketPongMessage:
+		if !f.fin || length > maxControlFramePayload || f.rsv1 {
+			return nil, ws.fail(WebSocketCloseProtocolError, "invalid control frame")
+		}
+	case WebSocketContinuationFrame, WebSocketTextMessage, WebSocketBinaryMessage:
+		// rsv1 只可用于压缩消息的首帧
+		if f.rsv1 && (!ws.compress || f.opcode == WebSocketContinuationFrame) {
+			return nil, ws.fail(WebSocketCloseProtocolError, "unexpected rsv1 bit")
+		}
+	default:
+		return nil, ws.fail(WebSocketCloseProtocolError, "unknown opcode "+strconv.Itoa(f.opcode))
+	}
+	if ws.readLimit > 0 && length > uint64(ws.readLimit) {
+		return nil, ws.fail(WebSocketCloseMessageTooBig, "message is too big")
+	}
+
+	var maskKey [4]byte
+	if masked {
+		if _, err := io.ReadFull(ws.br, maskKey[:]); err != nil {
+			return nil, err
+		}
+	}
+	if length <= webSocketPreallocSize {
+		f.payload = make([]byte, length)
+		if _, err := io.ReadFull(ws.br, f.payload); err != nil {
+			return nil, err
+		}
+	} else {
+		// 长度由客户端指定，不可直接按长度分配内存，
+		// 按实际读取的数据增长
+		payload, err := io.ReadAll(io.LimitReader(ws.br, int64(length)))
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(payload)) != length {
+			return nil, io.ErrUnexpectedEOF
+		}
+		f.payload = payload
+	}
+	if masked {
+		maskBytes(maskKey, f.payload)
+	}
+	return f, nil
+}
+
+// ReadMessage reads a complete message(the fragmented frames are joined),
+// the ping is replied automatically and the pong is passed to pong handler.
+// A *WebSocketCloseError is returned if the close frame is received
+// (the close frame is replied) or the protocol is violated.
+func (ws *WebSocketConn) ReadMessage() (int, []byte, error) {
+	messageType := 0
+	compressed := false
+	var data []byte
+	for {
+		f, err := ws.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch f.opcode {
+		case WebSocketPingMessage:
+			if err := ws.writeFrame(WebSocketPongMessage, false, f.payload); err != nil && err != ErrWebSocketClosed {
+				return 0, nil, err
+			}
+			continue
+		case WebSocketPongMessage:
+			if ws.pongHandler != nil {
+				if err := ws.pongHandler(f.payload); err != nil {
+					return 0, nil, err
+				}
+			}
+			continue
+		case WebSocketCloseMessage:
+			return 0, nil, ws.handleClose(f.payload)
+		case WebSocketContinuationFrame:
+			if messageType == 0 {
+				return 0, nil, ws.fail(WebSocketCloseProtocolError, "unexpected continuation frame")
+			}
+		default:
+			if messageType != 0 {
+				return 0, nil, ws.fail(WebSocketCloseProtocolError, "expect continuation frame")
+			}
+			messageType = f.opcode
+			compressed = f.rsv1
+		}
+		if ws.readLimit > 0 && int64(len(data)+len(f.payload)) > ws.readLimit {
+			return 0, nil, ws.fail(WebSocketCloseMessageTooBig, "message is too big")
+		}
+		data = append(data, f.payload...)
+		if f.fin {
+			break
+		}
+	}
+	if compressed {
+		buf, err := ws.decompress(data)
+		if err != nil {
+			return 0, nil, err
+		}
+		data = buf
+	}
+	if messageType == WebSocketTextMessage && !utf8.Valid(data) {
+		return 0, nil, ws.fail(WebSocketCloseInvalidFramePayloadData, "invalid utf-8")
+	}
+	return messageType, data, nil
+}
+
+// handleClose validates the close payload, replies the close frame
+// and returns the close error.
+func (ws *WebSocketConn) handleClose(payload []byte) error {
+	code := WebSocketCloseNoStatusReceived
+	text := ""
+	if len(payload) == 1 {
+		return ws.fail(WebSocketCloseProtocolError, "invalid close payload")
+	}
+	if len(payload) >= 2 {
+		code = int(binary.BigEndian.Uint16(payload))
+		text = string(payload[2:])
+		if !isValidCloseCode(code) {
+			return ws.fail(WebSocketCloseProtocolError, "invalid close code")
+		}
+		if !utf8.ValidString(text) {
+			return ws.fail(WebSocketCloseInvalidFramePayloadData, "invalid utf-8")
+		}
+	}
+	replyCode := code
+	if replyCode == WebSocketCloseNoStatusReceived {
+		replyCode = WebSocketCloseNormalClosure
+	}
+	_ = ws.WriteClose(replyCode, "")
+	_ = ws.Close()
+	return &WebSocketCloseError{
+		Code: code,
+		Text: text,
+	}
+}
+
+func isValidCloseCode(code int) bool {
+	switch code {
+	case WebSocketCloseNormalClosure,
+		WebSocketCloseGoingAway,
+		WebSocketCloseProtocolError,
+		WebSocketCloseUnsupportedData,
+		WebSocketCloseInvalidFramePayloadData,
+		WebSocketClosePolicyViolation,
+		WebSocketCloseMessageTooBig,
+		WebSocketCloseMandatoryExtension,
+		WebSocketCloseInternalServerErr:
+		return true
+	}
+	// 3000-3999 registered, 4000-4999 private use
+	return code >= 3000 && code <= 4999
+}
+
+func (ws *WebSocketConn) decompress(data []byte) ([]byte, error) {
+	// 补充压缩时去除的尾部，以及一个final的空块使reader可以正常结束
+	r := flate.NewReader(io.MultiReader(
+		bytes.NewReader(data),
+		strings.NewReader("\x00\x00\xff\xff\x01\x00\x00\xff\xff"),
+	))
+	defer r.Close()
+	limit := ws.readLimit
+	if limit <= 0 {
+		limit = defaultWebSocketReadLimit
+	}
+	buf, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, ws.fail(WebSocketCloseInvalidFramePayloadData, "invalid compressed data")
+	}
+	if int64(len(buf)) > limit {
+		return nil, ws.fail(WebSocketCloseMessageTooBig, "message is too big")
+	}
+	return buf, nil
+}
+
+func compressWebSocketData(data []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w, err := flate.NewWriter(buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	// 去除sync flush的尾部 00 00 ff ff
+	return bytes.TrimSuffix(buf.Bytes(), []byte{0x00, 0x00, 0xff, 0xff}), nil
+}
+
+func maskBytes(key [4]byte, data []byte) {
+	for i := range data {
+		data[i] ^= key[i&3]
+	}
+}
+
+func (ws *WebSocketConn) writeFrame(opcode int, rsv1 bool, payload []byte) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	if ws.closeSent {
+		return ErrWebSocketClosed
+	}
+	if opcode == WebSocketCloseMessage {
+		ws.closeSent = true
+	}
+	var header [14]byte
+	header[0] = byte(opcode) | finalBit
+	if rsv1 {
+		header[0] |= rsv1Bit
+	}
+	n := 2
+	length := len(payload)
+	switch {
+	case length <= 125:
+		header[1] = byte(length)
+	case length <= 0xffff:
+		header[1] = 126
+		binary.BigEndian.PutUint16(header[2:], uint16(length))
+		n += 2
+	default:
+		header[1] = 127
+		binary.BigEndian.PutUint64(header[2:], uint64(length))
+		n += 8
+	}
+	// 客户端发送的数据需要mask
+	if !ws.isServer {
+		header[1] |= maskBit
+		var key [4]byte
+		if _, err := rand.Read(key[:]); err != nil {
+			return err
+		}
+		copy(header[n:], key[:])
+		n += 4
+		payload = bytes.Clone(payload)
+		maskBytes(key, payload)
+	}
+	if _, err := ws.bw.Write(header[:n]); err != nil {
+		return err
+	}
+	if _, err := ws.bw.Write(payload); err != nil {
+		return err
+	}
+	return ws.bw.Flush()
+}
+
+// WriteMessage writes the message, the text and binary message
+// is compressed if permessage-deflate is negotiated.
+func (ws *WebSocketConn) WriteMessage(messageType int, data []byte) error {
+	switch messageType {
+	case WebSocketTextMessage, WebSocketBinaryMessage:
+		if !ws.compress {
+			return ws.writeFrame(messageType, false, data)
+		}
+		buf, err := compressWebSocketData(data)
+		if err != nil {
+			return err
+		}
+		return ws.writeFrame(messageType, true, buf)
+	case WebSocketPingMessage, WebSocketPongMessage, WebSocketCloseMessage:
+		if len(data) > maxControlFramePayload {
+			return ErrWebSocketInvalidMessageType
+		}
+		return ws.writeFrame(messageType, false, data)
+	}
+	return ErrWebSocketInvalidMessageType
+}
+
+// WriteText writes the text message
+func (ws *WebSocketConn) WriteText(text string) error {
+	return ws.WriteMessage(WebSocketTextMessage, []byte(text))
+}
+
+// Ping sends the ping message
+func (ws *WebSocketConn) Ping(data []byte) error {
+	return ws.WriteMessage(WebSocketPingMessage, data)
+}
+
+// WriteClose sends the close frame of code and text, the
+// connection should be closed after the close frame is received.
+func (ws *WebSocketConn) WriteClose(code int, text string) error {
+	payload := make([]byte, 2, 2+len(text))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, text...)
+	if len(payload) > maxControlFramePayload {
+		return fmt.Errorf("websocket: close text is too long")
+	}
+	return ws.writeFrame(WebSocketCloseMessage, false, payload)
+}
+
+// Close sends the normal close frame if no close frame is sent,
+// and closes the underlying connection.
+func (ws *WebSocketConn) Close() error {
+	var err error
+	ws.closeOnce.Do(func() {
+		_ = ws.WriteClose(WebSocketCloseNormalClosure, "")
+		err = ws.conn.Close()
+	})
+	return err
+}
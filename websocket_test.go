@@ -0,0 +1,378 @@
+// MIT License
+
+// Copyright (c) 2020 Tree Xie
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package elton
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// dialWebSocket dials the websocket server as client for test
+func dialWebSocket(t *testing.T, server *httptest.Server, path string, header http.Header) (*WebSocketConn, *http.Response) {
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("GET", server.URL+path, nil)
+	req.Header.Set(HeaderConnection, "Upgrade")
+	req.Header.Set(HeaderUpgrade, "websocket")
+	req.Header.Set(HeaderSecWebSocketVersion, "13")
+	req.Header.Set(HeaderSecWebSocketKey, "dGhlIHNhbXBsZSBub25jZQ==")
+	for key, values := range header {
+		req.Header.Set(key, values[0])
+	}
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		_ = conn.Close()
+		return nil, resp
+	}
+	compress := strings.Contains(resp.Header.Get(HeaderSecWebSocketExtensions), permessageDeflate)
+	return newWebSocketConn(conn, br, bufio.NewWriter(conn), false, compress), resp
+}
+
+func newWebSocketTestServer(config WebSocketConfig) (*httptest.Server, chan int) {
+	e := New()
+	status := make(chan int, 1)
+	e.Use(func(c *Context) error {
+		err := c.Next()
+		status <- c.StatusCode
+		return err
+	})
+	if config.Handler == nil {
+		// echo
+		config.Handler = func(c *Context, conn *WebSocketConn) error {
+			for {
+				messageType, data, err := conn.ReadMessage()
+				if err != nil {
+					if IsWebSocketCloseError(err, WebSocketCloseNormalClosure) {
+						return nil
+					}
+					return err
+				}
+				if err := conn.WriteMessage(messageType, data); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	e.GET("/ws", NewWebSocket(config))
+	return httptest.NewServer(e), status
+}
+
+func TestWebSocketAcceptKey(t *testing.T) {
+	assert := assert.New(t)
+	// the example of RFC 6455
+	assert.Equal("s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", webSocketAcceptKey("dGhlIHNhbXBsZSBub25jZQ=="))
+}
+
+func TestWebSocketHandshake(t *testing.T) {
+	assert := assert.New(t)
+	assert.PanicsWithValue(ErrWebSocketRequireHandler, func() {
+		NewWebSocket(WebSocketConfig{})
+	})
+
+	e := New()
+	e.GET("/ws", NewWebSocket(WebSocketConfig{
+		Handler: func(c *Context, conn *WebSocketConn) error {
+			return nil
+		},
+	}))
+	tests := []struct {
+		header http.Header
+		status int
+	}{
+		{
+			header: http.Header{},
+			status: 400,
+		},
+		{
+			header: http.Header{
+				HeaderConnection:          []string{"keep-alive, Upgrade"},
+				HeaderUpgrade:             []string{"websocket"},
+				HeaderSecWebSocketVersion: []string{"8"},
+			},
+			status: 426,
+		},
+		{
+			header: http.Header{
+				HeaderConnection:          []string{"Upgrade"},
+				HeaderUpgrade:             []string{"websocket"},
+				HeaderSecWebSocketVersion: []string{"13"},
+				HeaderSecWebSocketKey:     []string{"abc"},
+			},
+			status: 400,
+		},
+		{
+			header: http.Header{
+				HeaderConnection:          []string{"Upgrade"},
+				HeaderUpgrade:             []string{"websocket"},
+				HeaderSecWebSocketVersion: []string{"13"},
+				HeaderSecWebSocketKey:     []string{"dGhlIHNhbXBsZSBub25jZQ=="},
+				"Origin":                  []string{"https://test.com"},
+			},
+			status: 403,
+		},
+		{
+			// http.ResponseRecorder does not support hijack
+			header: http.Header{
+				HeaderConnection:          []string{"Upgrade"},
+				HeaderUpgrade:             []string{"websocket"},
+				HeaderSecWebSocketVersion: []string{"13"},
+				HeaderSecWebSocketKey:     []string{"dGhlIHNhbXBsZSBub25jZQ=="},
+				"Origin":                  []string{"http://example.com"},
+			},
+			status: 500,
+		},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/ws", nil)
+		for key, values := range tt.header {
+			req.Header.Set(key, values[0])
+		}
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(tt.status, resp.Code)
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		assert := assert.New(t)
+		server, status := newWebSocketTestServer(WebSocketConfig{
+			Subprotocols:      []string{"chat", "json"},
+			EnableCompression: compress,
+		})
+
+		conn, resp := dialWebSocket(t, server, "/ws", http.Header{
+			HeaderSecWebSocketProtocol:   []string{"json, chat"},
+			HeaderSecWebSocketExtensions: []string{"permessage-deflate; client_max_window_bits"},
+		})
+		assert.Equal(http.StatusSwitchingProtocols, resp.StatusCode)
+		assert.Equal("s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get(HeaderSecWebSocketAccept))
+		assert.Equal("chat", resp.Header.Get(HeaderSecWebSocketProtocol))
+		assert.Equal(compress, conn.compress)
+
+		// echo
+		assert.Nil(conn.WriteText("hello"))
+		messageType, data, err := conn.ReadMessage()
+		assert.Nil(err)
+		assert.Equal(WebSocketTextMessage, messageType)
+		assert.Equal("hello", string(data))
+
+		large := bytes.Repeat([]byte("elton"), 30000)
+		assert.Nil(conn.WriteMessage(WebSocketBinaryMessage, large))
+		messageType, data, err = conn.ReadMessage()
+		assert.Nil(err)
+		assert.Equal(WebSocketBinaryMessage, messageType)
+		assert.Equal(large, data)
+
+		// ping
+		pong := make(chan string, 1)
+		conn.SetPongHandler(func(data []byte) error {
+			pong <- string(data)
+			return nil
+		})
+		assert.Nil(conn.Ping([]byte("ping")))
+		assert.Nil(conn.WriteText("after ping"))
+		_, data, err = conn.ReadMessage()
+		assert.Nil(err)
+		assert.Equal("after ping", string(data))
+		assert.Equal("ping", <-pong)
+
+		// close
+		assert.Nil(conn.WriteClose(WebSocketCloseNormalClosure, "bye"))
+		_, _, err = conn.ReadMessage()
+		assert.True(IsWebSocketCloseError(err, WebSocketCloseNormalClosure))
+		_ = conn.Close()
+		server.Close()
+		assert.Equal(http.StatusSwitchingProtocols, <-status)
+	}
+}
+
+// writeRawFrame writes the frame without validation for test
+func writeRawFrame(conn *WebSocketConn, b0 byte, payload []byte) {
+	header := []byte{b0, maskBit | byte(len(payload))}
+	key := [4]byte{1, 2, 3, 4}
+	header = append(header, key[:]...)
+	payload = bytes.Clone(payload)
+	maskBytes(key, payload)
+	_, _ = conn.conn.Write(append(header, payload...))
+}
+
+func TestWebSocketFragmentation(t *testing.T) {
+	assert := assert.New(t)
+	server, _ := newWebSocketTestServer(WebSocketConfig{})
+	defer server.Close()
+	conn, _ := dialWebSocket(t, server, "/ws", nil)
+	defer conn.Close()
+
+	// fragmented message with ping between the frames
+	writeRawFrame(conn, WebSocketTextMessage, []byte("hel"))
+	writeRawFrame(conn, finalBit|WebSocketPingMessage, []byte("p"))
+	writeRawFrame(conn, WebSocketContinuationFrame, []byte("lo "))
+	writeRawFrame(conn, finalBit|WebSocketContinuationFrame, []byte("world"))
+	messageType, data, err := conn.ReadMessage()
+	assert.Nil(err)
+	assert.Equal(WebSocketTextMessage, messageType)
+	assert.Equal("hello world", string(data))
+}
+
+func TestWebSocketProtocolError(t *testing.T) {
+	tests := []struct {
+		b0      byte
+		payload []byte
+		code    int
+	}{
+		// continuation without start
+		{
+			b0:   finalBit | WebSocketContinuationFrame,
+			code: WebSocketCloseProtocolError,
+		},
+		// reserved bits
+		{
+			b0:   finalBit | 0x20 | WebSocketTextMessage,
+			code: WebSocketCloseProtocolError,
+		},
+		// rsv1 without compression
+		{
+			b0:   finalBit | rsv1Bit | WebSocketTextMessage,
+			code: WebSocketCloseProtocolError,
+		},
+		// unknown opcode
+		{
+			b0:   finalBit | 3,
+			code: WebSocketCloseProtocolError,
+		},
+		// fragmented control frame
+		{
+			b0:   WebSocketPingMessage,
+			code: WebSocketCloseProtocolError,
+		},
+		// invalid utf-8
+		{
+			b0:      finalBit | WebSocketTextMessage,
+			payload: []byte{0xff, 0xfe},
+			code:    WebSocketCloseInvalidFramePayloadData,
+		},
+		// invalid close code
+		{
+			b0:      finalBit | WebSocketCloseMessage,
+			payload: binary.BigEndian.AppendUint16(nil, 1004),
+			code:    WebSocketCloseProtocolError,
+		},
+		// message too big
+		{
+			b0:      finalBit | WebSocketBinaryMessage,
+			payload: []byte("0123456789a"),
+			code:    WebSocketCloseMessageTooBig,
+		},
+	}
+	for _, tt := range tests {
+		assert := assert.New(t)
+		serverErr := make(chan error, 1)
+		server, _ := newWebSocketTestServer(WebSocketConfig{
+			ReadLimit: 10,
+			Handler: func(c *Context, conn *WebSocketConn) error {
+				_, _, err := conn.ReadMessage()
+				serverErr <- err
+				return nil
+			},
+		})
+		conn, _ := dialWebSocket(t, server, "/ws", nil)
+		writeRawFrame(conn, tt.b0, tt.payload)
+
+		_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+		_, _, err := conn.ReadMessage()
+		assert.True(IsWebSocketCloseError(err, tt.code), err)
+		assert.True(IsWebSocketCloseError(<-serverErr, tt.code))
+		_ = conn.Close()
+		server.Close()
+	}
+}
+
+func TestWebSocketUnlimitedRead(t *testing.T) {
+	assert := assert.New(t)
+	serverErr := make(chan error, 1)
+	serverData := make(chan []byte, 1)
+	server, _ := newWebSocketTestServer(WebSocketConfig{
+		Handler: func(c *Context, conn *WebSocketConn) error {
+			conn.SetReadLimit(0)
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				serverErr <- err
+				return nil
+			}
+			serverData <- data
+			_, _, err = conn.ReadMessage()
+			serverErr <- err
+			return nil
+		},
+	})
+	defer server.Close()
+	conn, _ := dialWebSocket(t, server, "/ws", nil)
+
+	// 大于预分配大小的消息
+	data := bytes.Repeat([]byte("a"), 2*webSocketPreallocSize)
+	assert.Nil(conn.WriteMessage(WebSocketBinaryMessage, data))
+	assert.Equal(data, <-serverData)
+
+	// 帧头声明的长度远大于实际数据，不会按长度分配内存
+	header := []byte{finalBit | WebSocketBinaryMessage, maskBit | 127}
+	header = binary.BigEndian.AppendUint64(header, 1<<40)
+	header = append(header, 1, 2, 3, 4)
+	_, _ = conn.conn.Write(append(header, "abc"...))
+	_ = conn.conn.Close()
+	err := <-serverErr
+	assert.NotNil(err)
+	assert.False(IsWebSocketCloseError(err, WebSocketCloseMessageTooBig))
+}
+
+func TestWebSocketHandlerError(t *testing.T) {
+	assert := assert.New(t)
+	server, _ := newWebSocketTestServer(WebSocketConfig{
+		Handler: func(c *Context, conn *WebSocketConn) error {
+			return ErrWebSocketInvalidMessageType
+		},
+	})
+	defer server.Close()
+	conn, _ := dialWebSocket(t, server, "/ws", nil)
+	defer conn.Close()
+	_, _, err := conn.ReadMessage()
+	assert.True(IsWebSocketCloseError(err, WebSocketCloseInternalServerErr))
+}